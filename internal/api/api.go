@@ -0,0 +1,507 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"pkdindustries/soulshack/internal/commands"
+	"pkdindustries/soulshack/internal/config"
+	"pkdindustries/soulshack/internal/core"
+	"pkdindustries/soulshack/internal/irc"
+	"pkdindustries/soulshack/internal/store"
+)
+
+// sendTimeout bounds how long a send request waits for the send queue
+const sendTimeout = 30 * time.Second
+
+// Sender delivers a message to a channel or nick, waiting until it is sent
+type Sender interface {
+	Message(ctx context.Context, target, text string)
+}
+
+// Server is the HTTP admin API. Every request needs the configured token as
+// a bearer token, and is handled with owner rights.
+type Server struct {
+	cfg    *config.Configuration
+	sys    core.System
+	sender Sender
+}
+
+// New creates the admin API for the running bot
+func New(cfg *config.Configuration, sys core.System, sender Sender) *Server {
+	return &Server{cfg: cfg.Global(), sys: sys, sender: sender}
+}
+
+// Handler returns the API routes, behind token authentication
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/config", s.listConfig)
+	mux.HandleFunc("GET /api/config/{key}", s.getConfig)
+	mux.HandleFunc("PUT /api/config/{key}", s.audited("/set", s.setConfig))
+	mux.HandleFunc("DELETE /api/config/{key}", s.audited("/unset", s.unsetConfig))
+	mux.HandleFunc("GET /api/tools", s.listTools)
+	mux.HandleFunc("POST /api/tools", s.audited("/tools add", s.addTool))
+	mux.HandleFunc("DELETE /api/tools/{pattern}", s.audited("/tools remove", s.removeTools))
+	mux.HandleFunc("GET /api/admins", s.listAdmins)
+	mux.HandleFunc("POST /api/admins", s.audited("/admins add", s.addAdmin))
+	mux.HandleFunc("DELETE /api/admins/{mask}", s.audited("/admins remove", s.removeAdmin))
+	mux.HandleFunc("GET /api/sessions", s.listSessions)
+	mux.HandleFunc("GET /api/sessions/{name}", s.sessionStats)
+	mux.HandleFunc("POST /api/send", s.audited("send", s.send))
+	return s.authenticate(mux)
+}
+
+// Serve runs the API on addr until ctx is done. It refuses to start without
+// a token.
+func (s *Server) Serve(ctx context.Context, addr string) {
+	if s.cfg.Snapshot().Bot.AdminToken == "" {
+		slog.Error("admin_api_disabled", "addr", addr, "reason", "no admintoken set")
+		return
+	}
+	server := &http.Server{Addr: addr, Handler: s.Handler(), ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	slog.Info("admin_api_listening", "addr", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("admin_api_failed", "addr", addr, "error", err)
+	}
+}
+
+// authenticate checks the bearer token. The token is read per request so a
+// reload can rotate it.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		want := s.cfg.Snapshot().Bot.AdminToken
+		if !ok || want == "" || subtle.ConstantTimeCompare([]byte(token), []byte(want)) != 1 {
+			slog.Warn("admin_api_unauthorized", "remote", r.RemoteAddr, "method", r.Method, "path", r.URL.Path)
+			writeError(w, http.StatusUnauthorized, "invalid or missing token")
+			return
+		}
+		slog.Info("admin_api_request", "remote", r.RemoteAddr, "method", r.Method, "path", r.URL.Path)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// auditWriter records what a request changing the bot did, so it can be
+// audited once its handler is done
+type auditWriter struct {
+	http.ResponseWriter
+	channel string
+	args    string
+	status  int
+	detail  string
+}
+
+func (a *auditWriter) WriteHeader(status int) {
+	a.status = status
+	a.ResponseWriter.WriteHeader(status)
+}
+
+// audited records each request to a handler in the audit log, as commands
+// sent over IRC are, with the remote address in place of a hostmask
+func (s *Server) audited(action string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		aw := &auditWriter{ResponseWriter: w, status: http.StatusOK}
+		next(aw, r)
+
+		entry := store.AuditEntry{
+			Kind:     "api",
+			Action:   action,
+			Nick:     "api",
+			Hostmask: r.RemoteAddr,
+			Channel:  aw.channel,
+			Args:     aw.args,
+			Outcome:  "ok",
+			Detail:   aw.detail,
+		}
+		if aw.status >= http.StatusBadRequest {
+			entry.Outcome = "error"
+		}
+		slog.Info("audit", "kind", entry.Kind, "action", entry.Action, "remote", r.RemoteAddr, "outcome", entry.Outcome)
+		if err := s.sys.GetAudit().Record(entry); err != nil {
+			slog.Error("audit_record_failed", "error", err)
+		}
+	}
+}
+
+// auditArgs sets the channel and arguments audited for the request
+func auditArgs(w http.ResponseWriter, channel string, args ...string) {
+	if a, ok := w.(*auditWriter); ok {
+		a.channel, a.args = channel, strings.Join(args, " ")
+	}
+}
+
+// Config
+
+// configValue is a config key's value, globally or in a channel
+type configValue struct {
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+	Channel string `json:"channel,omitempty"`
+}
+
+// listConfig returns every key, with a channel's effective values when
+// ?channel= is given
+func (s *Server) listConfig(w http.ResponseWriter, r *http.Request) {
+	channel := r.URL.Query().Get("channel")
+	cfg := s.scoped(channel)
+	values := []configValue{}
+	for _, key := range config.Keys() {
+		values = append(values, configValue{Key: key, Value: config.Fields[key].Masked(cfg), Channel: channel})
+	}
+	writeJSON(w, http.StatusOK, values)
+}
+
+func (s *Server) getConfig(w http.ResponseWriter, r *http.Request) {
+	key, channel := r.PathValue("key"), r.URL.Query().Get("channel")
+	field, ok := config.Fields[key]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown key %s", key))
+		return
+	}
+	writeJSON(w, http.StatusOK, configValue{Key: key, Value: field.Masked(s.scoped(channel)), Channel: channel})
+}
+
+// setConfig sets a key globally, or overrides it for the channel in the body
+func (s *Server) setConfig(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	var body configValue
+	if !readJSON(w, r, &body) {
+		return
+	}
+	field, ok := config.Fields[key]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown key %s", key))
+		return
+	}
+	value := body.Value
+	if field.Secret {
+		value = config.MaskAPIKey(value)
+	}
+	auditArgs(w, body.Channel, key, value)
+
+	if body.Channel != "" {
+		if !field.Channel {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("%s cannot be set per channel", key))
+			return
+		}
+		if err := s.cfg.SetChannelOverride(body.Channel, key, body.Value); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.clearSession(body.Channel)
+	} else {
+		var err error
+		s.cfg.Update(func(cfg *config.Configuration) { err = field.Set(cfg, body.Value) })
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		// Same rule as /set: API keys, URLs and models need a new client
+		if strings.Contains(key, "key") || strings.Contains(key, "url") || strings.Contains(key, "model") {
+			if err := s.sys.UpdateLLM(*s.cfg.Snapshot().API); err != nil {
+				slog.Error("llm_update_failed", "error", err)
+			}
+		}
+	}
+
+	slog.Info("config_changed", "key", key, "channel", body.Channel, "source", "api")
+	s.autosave()
+	writeJSON(w, http.StatusOK, configValue{Key: key, Value: field.Masked(s.scoped(body.Channel)), Channel: body.Channel})
+}
+
+// unsetConfig removes a channel override given by ?channel=
+func (s *Server) unsetConfig(w http.ResponseWriter, r *http.Request) {
+	key, channel := r.PathValue("key"), r.URL.Query().Get("channel")
+	if channel == "" {
+		writeError(w, http.StatusBadRequest, "channel is required, only channel overrides can be removed")
+		return
+	}
+	auditArgs(w, channel, key)
+	if !s.cfg.Overrides.Unset(channel, key) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("%s has no override for %s", channel, key))
+		return
+	}
+	s.clearSession(channel)
+	s.autosave()
+	writeJSON(w, http.StatusOK, configValue{Key: key, Value: config.Fields[key].Masked(s.scoped(channel)), Channel: channel})
+}
+
+// scoped returns the effective config for channel, or the global config
+func (s *Server) scoped(channel string) *config.Configuration {
+	return s.cfg.ForChannel(channel)
+}
+
+// clearSession resets a channel's session after its config changed
+func (s *Server) clearSession(channel string) {
+	if store := s.sys.GetSessionStore(); store.Exists(channel) {
+		store.Delete(channel)
+	}
+}
+
+// autosave writes changes back to the config file when autosave is on
+func (s *Server) autosave() {
+	if !s.cfg.Snapshot().Bot.AutoSave {
+		return
+	}
+	if _, err := s.cfg.Save(); err != nil {
+		slog.Warn("config_autosave_failed", "path", s.cfg.Path, "error", err)
+	}
+}
+
+// Tools
+
+func (s *Server) listTools(w http.ResponseWriter, r *http.Request) {
+	names := []string{}
+	for _, tool := range s.sys.GetToolRegistry().All() {
+		names = append(names, tool.GetName())
+	}
+	slices.Sort(names)
+	writeJSON(w, http.StatusOK, names)
+}
+
+// addTool loads a tool spec, as /tools add does
+func (s *Server) addTool(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Spec string `json:"spec"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	if body.Spec == "" {
+		writeError(w, http.StatusBadRequest, "spec is required")
+		return
+	}
+	auditArgs(w, "", body.Spec)
+	names, err := s.sys.LoadTool(body.Spec)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if names == nil {
+		names = []string{}
+	}
+	slog.Info("tool_added", "spec", body.Spec, "tools", len(names), "source", "api")
+	writeJSON(w, http.StatusOK, names)
+}
+
+// removeTools removes tools by exact name or glob. A bare namespace like
+// "git" removes git__*, as /tools remove does.
+func (s *Server) removeTools(w http.ResponseWriter, r *http.Request) {
+	pattern := r.PathValue("pattern")
+	auditArgs(w, "", pattern)
+	if !strings.Contains(pattern, "*") && !strings.Contains(pattern, "__") {
+		pattern += "__*"
+	}
+	removed := []string{}
+	for _, tool := range s.sys.GetToolRegistry().All() {
+		name := tool.GetName()
+		if matched, _ := path.Match(pattern, name); matched {
+			s.sys.RemoveTool(name)
+			removed = append(removed, name)
+		}
+	}
+	if len(removed) == 0 {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no tools matched %s", pattern))
+		return
+	}
+	slog.Info("tool_removed", "pattern", pattern, "tools", len(removed), "source", "api")
+	writeJSON(w, http.StatusOK, removed)
+}
+
+// Admins
+
+func (s *Server) listAdmins(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, append([]string{}, s.cfg.Snapshot().Bot.Admins...))
+}
+
+func (s *Server) addAdmin(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Mask string `json:"mask"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	auditArgs(w, "", body.Mask)
+	if err := irc.ValidateAdminMask(body.Mask); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid hostmask: %v", err))
+		return
+	}
+	added := false
+	s.cfg.Update(func(cfg *config.Configuration) {
+		if !slices.Contains(cfg.Bot.Admins, body.Mask) {
+			cfg.Bot.Admins = append(slices.Clip(cfg.Bot.Admins), body.Mask)
+			added = true
+		}
+	})
+	if !added {
+		writeError(w, http.StatusConflict, fmt.Sprintf("already an admin: %s", body.Mask))
+		return
+	}
+	slog.Info("admin_added", "mask", body.Mask, "source", "api")
+	s.autosave()
+	s.listAdmins(w, r)
+}
+
+func (s *Server) removeAdmin(w http.ResponseWriter, r *http.Request) {
+	mask := r.PathValue("mask")
+	auditArgs(w, "", mask)
+	removed := false
+	s.cfg.Update(func(cfg *config.Configuration) {
+		if idx := slices.Index(cfg.Bot.Admins, mask); idx != -1 {
+			// Snapshots share the old slice, so it isn't changed in place
+			cfg.Bot.Admins = slices.Delete(slices.Clone(cfg.Bot.Admins), idx, idx+1)
+			removed = true
+		}
+	})
+	if !removed {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("not an admin: %s", mask))
+		return
+	}
+	slog.Info("admin_removed", "mask", mask, "source", "api")
+	s.autosave()
+	s.listAdmins(w, r)
+}
+
+// Sessions
+
+// sessionStats is the JSON form of commands.SessionStats
+type sessionStats struct {
+	Name          string         `json:"name"`
+	InputTokens   int            `json:"input_tokens"`
+	OutputTokens  int            `json:"output_tokens"`
+	Capacity      float64        `json:"capacity_percent"`
+	MaxTokens     int            `json:"max_tokens"`
+	Messages      int            `json:"messages"`
+	MessageCounts map[string]int `json:"message_counts"`
+	Participants  int            `json:"participants"`
+	ExpiresIn     int            `json:"expires_in_seconds,omitempty"`
+	LastUsed      time.Time      `json:"last_used"`
+}
+
+func toSessionStats(stats commands.SessionStats) sessionStats {
+	return sessionStats{
+		Name:          stats.Name,
+		InputTokens:   stats.InputTokens,
+		OutputTokens:  stats.OutputTokens,
+		Capacity:      stats.Capacity,
+		MaxTokens:     stats.MaxTokens,
+		Messages:      stats.Messages,
+		MessageCounts: stats.MessageCounts,
+		Participants:  stats.Participants,
+		ExpiresIn:     int(stats.ExpiresIn.Seconds()),
+		LastUsed:      stats.LastUsed,
+	}
+}
+
+// listSessions returns the stats of every active session
+func (s *Server) listSessions(w http.ResponseWriter, r *http.Request) {
+	names, err := s.sys.GetSessionStore().List()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	slices.Sort(names)
+	list := []sessionStats{}
+	for _, name := range names {
+		if stats, ok := s.stats(name); ok {
+			list = append(list, stats)
+		}
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// sessionStats returns what /stats shows for one session
+func (s *Server) sessionStats(w http.ResponseWriter, r *http.Request) {
+	stats, ok := s.stats(r.PathValue("name"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no session %s", r.PathValue("name")))
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// stats looks up an existing session without creating it
+func (s *Server) stats(name string) (sessionStats, bool) {
+	store := s.sys.GetSessionStore()
+	if !store.Exists(name) {
+		return sessionStats{}, false
+	}
+	session, err := store.Get(name)
+	if err != nil {
+		return sessionStats{}, false
+	}
+	return toSessionStats(commands.GetSessionStats(session)), true
+}
+
+// Messages
+
+// send posts a message to a channel or nick through the send queue. Each
+// line of the message is sent separately.
+func (s *Server) send(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Target  string `json:"target"`
+		Message string `json:"message"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	if body.Target == "" || strings.ContainsAny(body.Target, " ,\r\n") || body.Message == "" {
+		writeError(w, http.StatusBadRequest, "target and message are required")
+		return
+	}
+	auditArgs(w, "", body.Target, body.Message)
+	ctx, cancel := context.WithTimeout(r.Context(), sendTimeout)
+	defer cancel()
+	lines := 0
+	for line := range strings.Lines(body.Message) {
+		if line = strings.TrimRight(line, "\r\n"); line != "" {
+			s.sender.Message(ctx, body.Target, line)
+			lines++
+		}
+	}
+	if ctx.Err() != nil {
+		writeError(w, http.StatusGatewayTimeout, "timed out waiting for the send queue")
+		return
+	}
+	slog.Info("message_sent", "target", body.Target, "lines", lines, "source", "api")
+	writeJSON(w, http.StatusOK, map[string]int{"lines": lines})
+}
+
+// readJSON decodes a request body, replying with an error when it is invalid
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError replies with an error, which is audited as the detail of an
+// audited request
+func writeError(w http.ResponseWriter, status int, message string) {
+	if a, ok := w.(*auditWriter); ok {
+		a.detail = message
+	}
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/alexschlessinger/pollytool/messages"
+
+	"pkdindustries/soulshack/internal/config"
+	"pkdindustries/soulshack/internal/irc"
+	mocktest "pkdindustries/soulshack/internal/testing"
+)
+
+type sent struct{ target, text string }
+
+type mockSender struct{ sent []sent }
+
+func (m *mockSender) Message(ctx context.Context, target, text string) {
+	m.sent = append(m.sent, sent{target, text})
+}
+
+func newTestServer() (*Server, *mocktest.MockSystem, *mockSender) {
+	cfg := mocktest.DefaultTestConfig()
+	cfg.Bot.AdminToken = "secret"
+	cfg.Overrides = config.NewChannelOverrides()
+	sys := mocktest.NewMockSystem()
+	sender := &mockSender{}
+	return New(cfg, sys, sender), sys, sender
+}
+
+// do sends an authenticated request and decodes the JSON reply into out
+func do(t *testing.T, h http.Handler, method, target, body string, out any) int {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("%s %s: invalid JSON %q: %v", method, target, rec.Body.String(), err)
+		}
+	}
+	return rec.Code
+}
+
+func TestAPI_RequiresToken(t *testing.T) {
+	server, _, _ := newTestServer()
+	h := server.Handler()
+
+	for _, auth := range []string{"", "Bearer wrong", "secret"} {
+		req := httptest.NewRequest("GET", "/api/config", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: expected 401, got %d", auth, rec.Code)
+		}
+	}
+}
+
+func TestAPI_Config(t *testing.T) {
+	server, _, _ := newTestServer()
+	h := server.Handler()
+
+	var value configValue
+	if code := do(t, h, "PUT", "/api/config/model", `{"value": "test/other"}`, &value); code != http.StatusOK || value.Value != "test/other" {
+		t.Fatalf("set model: %d %+v", code, value)
+	}
+	if server.cfg.Model.Model != "test/other" {
+		t.Errorf("expected global model changed, got %s", server.cfg.Model.Model)
+	}
+
+	if code := do(t, h, "PUT", "/api/config/model", `{"value": "test/small", "channel": "#dev"}`, &value); code != http.StatusOK {
+		t.Fatalf("set channel model: %d %+v", code, value)
+	}
+	do(t, h, "GET", "/api/config/model?channel=%23dev", "", &value)
+	if value.Value != "test/small" || value.Channel != "#dev" {
+		t.Errorf("expected #dev override, got %+v", value)
+	}
+
+	var failure map[string]string
+	if code := do(t, h, "PUT", "/api/config/maxtokens", `{"value": "lots"}`, &failure); code != http.StatusBadRequest || failure["error"] == "" {
+		t.Errorf("expected invalid value rejected, got %d %v", code, failure)
+	}
+	if code := do(t, h, "GET", "/api/config/nope", "", &failure); code != http.StatusNotFound {
+		t.Errorf("expected unknown key to 404, got %d", code)
+	}
+
+	if code := do(t, h, "DELETE", "/api/config/model?channel=%23dev", "", &value); code != http.StatusOK || value.Value != "test/other" {
+		t.Errorf("unset: %d %+v", code, value)
+	}
+}
+
+func TestAPI_Admins(t *testing.T) {
+	server, _, _ := newTestServer()
+	h := server.Handler()
+
+	var admins []string
+	if code := do(t, h, "POST", "/api/admins", `{"mask": "$a:alice"}`, &admins); code != http.StatusOK || len(admins) != 1 {
+		t.Fatalf("add admin: %d %v", code, admins)
+	}
+	if code := do(t, h, "POST", "/api/admins", `{"mask": "$a:alice"}`, nil); code != http.StatusConflict {
+		t.Errorf("expected duplicate admin to conflict, got %d", code)
+	}
+	if code := do(t, h, "DELETE", "/api/admins/$a:alice", "", &admins); code != http.StatusOK || len(admins) != 0 {
+		t.Errorf("remove admin: %d %v", code, admins)
+	}
+}
+
+func TestAPI_Tools(t *testing.T) {
+	server, sys, _ := newTestServer()
+	h := server.Handler()
+	irc.RegisterIRCTools(sys.ToolRegistry)
+
+	var added []string
+	if code := do(t, h, "POST", "/api/tools", `{"spec": "irc__op"}`, &added); code != http.StatusOK || len(added) != 1 {
+		t.Fatalf("add tool: %d %v", code, added)
+	}
+	var removed []string
+	if code := do(t, h, "DELETE", "/api/tools/irc", "", &removed); code != http.StatusOK || !slices.Equal(removed, added) {
+		t.Fatalf("remove tools: %d %v", code, removed)
+	}
+	if !slices.Equal(sys.RemovedTools, removed) {
+		t.Errorf("expected removal to go through the system, got %v", sys.RemovedTools)
+	}
+	if code := do(t, h, "DELETE", "/api/tools/irc", "", nil); code != http.StatusNotFound {
+		t.Errorf("expected nothing left to remove, got %d", code)
+	}
+}
+
+func TestAPI_Sessions(t *testing.T) {
+	server, sys, _ := newTestServer()
+	h := server.Handler()
+
+	session, _ := sys.SessionStore.Get("#dev")
+	session.AddMessage(messages.ChatMessage{Role: messages.MessageRoleUser, Content: "(nick:alice) hi"})
+
+	var list []sessionStats
+	if code := do(t, h, "GET", "/api/sessions", "", &list); code != http.StatusOK || len(list) != 1 {
+		t.Fatalf("list sessions: %d %+v", code, list)
+	}
+	if list[0].Name != "#dev" || list[0].Participants != 1 {
+		t.Errorf("unexpected stats: %+v", list[0])
+	}
+	if code := do(t, h, "GET", "/api/sessions/%23nope", "", nil); code != http.StatusNotFound {
+		t.Errorf("expected missing session to 404, got %d", code)
+	}
+	if sys.SessionStore.Exists("#nope") {
+		t.Error("looking up a session should not create it")
+	}
+}
+
+func TestAPI_Send(t *testing.T) {
+	server, _, sender := newTestServer()
+	h := server.Handler()
+
+	if code := do(t, h, "POST", "/api/send", `{"target": "#dev", "message": "one\ntwo\r\n\nthree"}`, nil); code != http.StatusOK {
+		t.Fatalf("send: %d", code)
+	}
+	if len(sender.sent) != 3 || sender.sent[1] != (sent{"#dev", "two"}) {
+		t.Errorf("expected each line sent separately, got %+v", sender.sent)
+	}
+
+	for _, body := range []string{`{"target": "#dev"}`, `{"target": "#dev\r\nQUIT", "message": "x"}`} {
+		if code := do(t, h, "POST", "/api/send", body, nil); code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, code)
+		}
+	}
+}
+
+func TestAPI_Audit(t *testing.T) {
+	server, sys, _ := newTestServer()
+	h := server.Handler()
+
+	do(t, h, "PUT", "/api/config/openaikey", `{"value": "sk-abcdefghijklmnop"}`, nil)
+	do(t, h, "POST", "/api/admins", `{"mask": "$a:alice"}`, nil)
+	do(t, h, "POST", "/api/admins", `{"mask": "$a:alice"}`, nil)
+	do(t, h, "GET", "/api/admins", "", nil)
+
+	entries := sys.Audit.Recent(10, nil)
+	if len(entries) != 3 {
+		t.Fatalf("expected the 3 changes audited, got %d: %+v", len(entries), entries)
+	}
+	set, added, conflict := entries[2], entries[1], entries[0]
+	if set.Action != "/set" || set.Kind != "api" || set.Hostmask == "" || set.Outcome != "ok" {
+		t.Errorf("unexpected entry for /set: %+v", set)
+	}
+	if !strings.HasPrefix(set.Args, "openaikey ") || strings.Contains(set.Args, "abcdefghijklmnop") {
+		t.Errorf("expected the key masked, got %q", set.Args)
+	}
+	if added.Action != "/admins add" || added.Args != "$a:alice" || added.Outcome != "ok" {
+		t.Errorf("unexpected entry for /admins add: %+v", added)
+	}
+	if conflict.Outcome != "error" || !strings.Contains(conflict.Detail, "already an admin") {
+		t.Errorf("expected the duplicate audited as an error, got %+v", conflict)
+	}
+}
@@ -26,6 +26,9 @@ func (b *AddressedBehavior) Check(ctx irc.ChatContextInterface, event *girc.Even
 }
 
 func (b *AddressedBehavior) Execute(ctx irc.ChatContextInterface, event *girc.Event) {
+	if rateLimited(ctx, b.CmdRegistry, false) {
+		return
+	}
 	core.WithRequestLock(ctx, ctx.GetLockKey(), "addressed", func() {
 		b.CmdRegistry.Dispatch(ctx)
 	}, func() {
@@ -4,6 +4,7 @@ import (
 	"github.com/lrstanley/girc"
 
 	"pkdindustries/soulshack/internal/irc"
+	"pkdindustries/soulshack/internal/metrics"
 )
 
 // Behavior defines the interface for event-based behaviors
@@ -14,6 +15,13 @@ type Behavior interface {
 	Execute(ctx irc.ChatContextInterface, event *girc.Event)
 }
 
+// Passive is implemented by behaviors that only observe events. They run
+// whenever their Check passes and never end the search for the first
+// matching behavior, so register them first.
+type Passive interface {
+	Passive()
+}
+
 // Registry manages behavior registration and dispatch
 type Registry struct {
 	behaviors map[string][]Behavior // event type -> behaviors
@@ -40,7 +48,8 @@ func (r *Registry) Handles(event string) bool {
 }
 
 // Process routes an event to registered behaviors, runs Check, and if true runs Execute
-// Returns true after the first matching behavior executes (first-match-wins)
+// Returns true after the first matching behavior executes (first-match-wins);
+// passive behaviors run without stopping the search
 func (r *Registry) Process(ctx irc.ChatContextInterface, event *girc.Event) bool {
 	behaviors, ok := r.behaviors[event.Command]
 	if !ok {
@@ -48,8 +57,15 @@ func (r *Registry) Process(ctx irc.ChatContextInterface, event *girc.Event) bool
 	}
 
 	for _, b := range behaviors {
+		if _, passive := b.(Passive); passive {
+			if b.Check(ctx, event) {
+				b.Execute(ctx, event)
+			}
+			continue
+		}
 		if b.Check(ctx, event) {
 			ctx.GetLogger().Info("behavior_executing", "behavior", b.Name())
+			metrics.BehaviorRequests.WithLabelValues(b.Name()).Inc()
 			b.Execute(ctx, event)
 			return true
 		}
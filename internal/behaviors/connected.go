@@ -8,7 +8,7 @@ import (
 	"pkdindustries/soulshack/internal/irc"
 )
 
-// ConnectedBehavior joins the configured channel when the bot connects
+// ConnectedBehavior joins the configured channels when the bot connects
 type ConnectedBehavior struct{}
 
 func (b *ConnectedBehavior) Name() string {
@@ -25,10 +25,12 @@ func (b *ConnectedBehavior) Check(ctx irc.ChatContextInterface, event *girc.Even
 
 func (b *ConnectedBehavior) Execute(ctx irc.ChatContextInterface, event *girc.Event) {
 	cfg := ctx.GetConfig()
-	slog.Info("channel_joining", "channel", cfg.Server.Channel)
-	if cfg.Server.ChannelKey != "" {
-		ctx.JoinWithKey(cfg.Server.Channel, cfg.Server.ChannelKey)
-	} else {
-		ctx.Join(cfg.Server.Channel)
+	for _, ch := range cfg.Server.Channels {
+		slog.Info("channel_joining", "channel", ch.Name)
+		if ch.Key != "" {
+			ctx.JoinWithKey(ch.Name, ch.Key)
+		} else {
+			ctx.Join(ch.Name)
+		}
 	}
 }
@@ -0,0 +1,67 @@
+package behaviors
+
+import (
+	"strings"
+
+	"github.com/lrstanley/girc"
+
+	"pkdindustries/soulshack/internal/irc"
+)
+
+// ContextBehavior remembers channel lines not addressed to the bot, so it
+// can see them the next time it is addressed
+type ContextBehavior struct{}
+
+func (b *ContextBehavior) Name() string {
+	return "context"
+}
+
+func (b *ContextBehavior) Events() []string {
+	return []string{girc.PRIVMSG}
+}
+
+func (b *ContextBehavior) Passive() {}
+
+func (b *ContextBehavior) Check(ctx irc.ChatContextInterface, event *girc.Event) bool {
+	cfg := ctx.GetConfig()
+	// without addressed mode every line already reaches the bot
+	if cfg.Bot.ContextLines <= 0 || !cfg.Bot.Addressed || ctx.GetChannelName() == "" || ctx.IsAddressed() {
+		return false
+	}
+	return !ignored(ctx, event, cfg.Bot.ContextIgnore)
+}
+
+// ignored reports whether the sender matches an ignore entry: a nick, a
+// hostmask glob or a $a:account. The account comes from the account tag, or
+// from tracking on servers that don't send it.
+func ignored(ctx irc.ChatContextInterface, event *girc.Event, masks []string) bool {
+	if len(masks) == 0 {
+		return false
+	}
+	account, _ := event.Tags.Get("account")
+	if user := ctx.GetUser(event.Source.Name); account == "" && user != nil && user.Account != "*" && user.Account != "0" {
+		account = user.Account
+	}
+	for _, mask := range masks {
+		if !strings.ContainsAny(mask, "!@$") {
+			if irc.MatchMask(mask, event.Source.Name) {
+				return true
+			}
+			continue
+		}
+		if irc.MatchAdmin(event.Source.String(), account, []string{mask}) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *ContextBehavior) Execute(ctx irc.ChatContextInterface, event *girc.Event) {
+	text := event.Last()
+	if event.IsAction() {
+		text = "/me " + event.StripAction()
+	} else if ok, _ := event.IsCTCP(); ok {
+		return
+	}
+	ctx.GetSystem().GetBacklog().Add(ctx.GetChannelName(), ctx.GetSource(), text, ctx.GetConfig().Bot.ContextLines)
+}
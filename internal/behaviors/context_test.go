@@ -0,0 +1,50 @@
+package behaviors
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lrstanley/girc"
+
+	"pkdindustries/soulshack/internal/core"
+	mocktest "pkdindustries/soulshack/internal/testing"
+)
+
+func TestContextBehavior(t *testing.T) {
+	sys := mocktest.NewMockSystem()
+	cfg := mocktest.DefaultTestConfig()
+	cfg.Bot.Addressed = true
+	cfg.Bot.ContextLines = 10
+	cfg.Bot.ContextIgnore = []string{"*!*@bots.example.org", "relay?", "$a:logger"}
+	behavior := &ContextBehavior{}
+
+	events := []struct {
+		source    *girc.Source
+		text      string
+		addressed bool
+	}{
+		{&girc.Source{Name: "alice", Ident: "a", Host: "example.org"}, "the build is red again", false},
+		{&girc.Source{Name: "feedbot", Ident: "f", Host: "bots.example.org"}, "new commit pushed", false},
+		{&girc.Source{Name: "Relay1", Ident: "r", Host: "example.net"}, "<dave> relayed line", false},
+		{&girc.Source{Name: "logbot", Ident: "l", Host: "example.net"}, "logging started", false},
+		{&girc.Source{Name: "bob", Ident: "b", Host: "example.org"}, "\x01ACTION sighs\x01", false},
+		{&girc.Source{Name: "bob", Ident: "b", Host: "example.org"}, "\x01VERSION\x01", false},
+		{&girc.Source{Name: "carol", Ident: "c", Host: "example.org"}, "soulshack: what do you think?", true},
+	}
+	for _, e := range events {
+		ctx := mocktest.NewMockContext().WithConfig(cfg).WithSystem(sys).WithSource(e.source.Name)
+		// logbot's account is only known from tracking, not a tag
+		ctx.Users = map[string]*core.UserInfo{"logbot": {Nick: "logbot", Account: "logger"}}
+		ctx.Addressed = e.addressed
+		event := &girc.Event{Command: girc.PRIVMSG, Source: e.source, Params: []string{"#test", e.text}}
+		if behavior.Check(ctx, event) {
+			behavior.Execute(ctx, event)
+		}
+	}
+
+	lines := sys.Backlog.Take("#test", 0)
+	want := "(nick:alice) the build is red again|(nick:bob) /me sighs"
+	if strings.Join(lines, "|") != want {
+		t.Errorf("expected %q, got %v", want, lines)
+	}
+}
@@ -61,11 +61,17 @@ func (b *ChannelErrorBehavior) Check(ctx irc.ChatContextInterface, event *girc.E
 
 func (b *ChannelErrorBehavior) Execute(ctx irc.ChatContextInterface, event *girc.Event) {
 	cfg := ctx.GetConfig()
-	channel := cfg.Server.Channel
+	reason := channelErrorReasons[event.Command]
+	err := fmt.Errorf("cannot join: %s", reason)
+	// The numeric names the channel after the bot's own nick
 	if len(event.Params) > 1 {
-		channel = event.Params[1]
+		err = fmt.Errorf("cannot join %s: %s", event.Params[1], reason)
 	}
-	reason := channelErrorReasons[event.Command]
-	slog.Error("channel_join_failed", "channel", channel, "reason", reason)
-	ctx.FatalError(fmt.Errorf("cannot join %s: %s", channel, reason))
+	slog.Error("channel_join_failed", "error", err)
+
+	// One bad channel shouldn't take the bot out of the others
+	if len(cfg.Server.Channels) > 1 {
+		return
+	}
+	ctx.FatalError(err)
 }
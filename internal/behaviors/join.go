@@ -32,7 +32,7 @@ func (b *JoinBehavior) Execute(ctx irc.ChatContextInterface, event *girc.Event)
 		outch, err := llm.Complete(ctx, cfg.Bot.Greeting)
 		if err != nil {
 			ctx.GetLogger().Error("join_behavior_error", "error", err)
-			ctx.Reply(err.Error())
+			ctx.Reply(llm.ErrorReply(err))
 			return
 		}
 
@@ -0,0 +1,77 @@
+package behaviors
+
+import (
+	"github.com/lrstanley/girc"
+
+	"pkdindustries/soulshack/internal/irc"
+	"pkdindustries/soulshack/internal/store"
+)
+
+// LoggerBehavior writes channel events to the transcript. The bot's own
+// lines are logged by the send queue, as the server does not echo them.
+type LoggerBehavior struct{}
+
+func (b *LoggerBehavior) Name() string {
+	return "logger"
+}
+
+func (b *LoggerBehavior) Events() []string {
+	return []string{girc.PRIVMSG, girc.JOIN, girc.PART, girc.KICK, girc.TOPIC}
+}
+
+func (b *LoggerBehavior) Passive() {}
+
+func (b *LoggerBehavior) Check(ctx irc.ChatContextInterface, event *girc.Event) bool {
+	return ctx.GetSystem().GetTranscript() != nil && ctx.GetChannelName() != ""
+}
+
+func (b *LoggerBehavior) Execute(ctx irc.ChatContextInterface, event *girc.Event) {
+	line, ok := transcriptLine(event)
+	if !ok {
+		return
+	}
+	line.Channel = ctx.GetChannelName()
+	if err := ctx.GetSystem().GetTranscript().Write(line); err != nil {
+		ctx.GetLogger().Error("transcript_write_failed", "error", err)
+	}
+}
+
+// transcriptLine converts a channel event into a transcript line. CTCP
+// requests other than ACTION are skipped.
+func transcriptLine(event *girc.Event) (store.TranscriptLine, bool) {
+	line := store.TranscriptLine{Time: event.Timestamp, Nick: event.Source.Name}
+	switch event.Command {
+	case girc.PRIVMSG:
+		if event.IsAction() {
+			line.Kind, line.Text = store.TranscriptAction, event.StripAction()
+			break
+		}
+		if ok, _ := event.IsCTCP(); ok {
+			return line, false
+		}
+		line.Kind, line.Text = store.TranscriptMessage, event.Last()
+	case girc.JOIN:
+		line.Kind, line.Host = store.TranscriptJoin, event.Source.Ident+"@"+event.Source.Host
+	case girc.PART:
+		line.Kind, line.Host = store.TranscriptPart, event.Source.Ident+"@"+event.Source.Host
+		if len(event.Params) > 1 {
+			line.Text = event.Last()
+		}
+	case girc.KICK:
+		if len(event.Params) < 2 {
+			return line, false
+		}
+		line.Kind, line.Target = store.TranscriptKick, event.Params[1]
+		if len(event.Params) > 2 {
+			line.Text = event.Last()
+		}
+	case girc.TOPIC:
+		if len(event.Params) < 2 {
+			return line, false
+		}
+		line.Kind, line.Text = store.TranscriptTopic, event.Last()
+	default:
+		return line, false
+	}
+	return line, true
+}
@@ -0,0 +1,77 @@
+package behaviors
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lrstanley/girc"
+
+	"pkdindustries/soulshack/internal/irc"
+	"pkdindustries/soulshack/internal/store"
+	mocktest "pkdindustries/soulshack/internal/testing"
+)
+
+// recordBehavior notes whether it ran
+type recordBehavior struct{ ran bool }
+
+func (b *recordBehavior) Name() string                                           { return "record" }
+func (b *recordBehavior) Events() []string                                       { return []string{girc.PRIVMSG} }
+func (b *recordBehavior) Check(ctx irc.ChatContextInterface, e *girc.Event) bool { return true }
+func (b *recordBehavior) Execute(ctx irc.ChatContextInterface, e *girc.Event)    { b.ran = true }
+
+func TestLoggerBehavior(t *testing.T) {
+	dir := t.TempDir()
+	transcript, _ := store.NewTranscript(dir)
+	sys := mocktest.NewMockSystem()
+	sys.Transcript = transcript
+
+	registry := NewRegistry()
+	registry.Register(&LoggerBehavior{})
+	next := &recordBehavior{}
+	registry.Register(next)
+
+	at := time.Now()
+	source := &girc.Source{Name: "alice", Ident: "al", Host: "example.org"}
+	events := []*girc.Event{
+		{Command: girc.JOIN, Source: source, Params: []string{"#test"}},
+		{Command: girc.PRIVMSG, Source: source, Params: []string{"#test", "hello"}},
+		{Command: girc.PRIVMSG, Source: source, Params: []string{"#test", "\x01ACTION waves\x01"}},
+		{Command: girc.PRIVMSG, Source: source, Params: []string{"#test", "\x01VERSION\x01"}},
+		{Command: girc.KICK, Source: source, Params: []string{"#test", "eve", "spam"}},
+		{Command: girc.TOPIC, Source: source, Params: []string{"#test", "new topic"}},
+		{Command: girc.PART, Source: source, Params: []string{"#test"}},
+	}
+	for _, e := range events {
+		e.Timestamp = at
+		registry.Process(mocktest.NewMockContext().WithSystem(sys), e)
+	}
+	transcript.Close()
+
+	if !next.ran {
+		t.Error("expected the logger not to stop later behaviors")
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "#test", at.Format(time.DateOnly)+".log"))
+	if err != nil {
+		t.Fatalf("expected a transcript: %v", err)
+	}
+	log := string(data)
+	for _, want := range []string{
+		"-!- alice [al@example.org] has joined #test",
+		"<alice> hello",
+		"* alice waves",
+		"-!- eve was kicked from #test by alice [spam]",
+		"-!- alice changed the topic of #test to: new topic",
+		"-!- alice [al@example.org] has left #test",
+	} {
+		if !strings.Contains(log, want) {
+			t.Errorf("expected %q in transcript:\n%s", want, log)
+		}
+	}
+	if strings.Contains(log, "VERSION") {
+		t.Error("expected CTCP requests skipped")
+	}
+}
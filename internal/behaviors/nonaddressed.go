@@ -27,6 +27,9 @@ func (b *NonAddressedBehavior) Check(ctx irc.ChatContextInterface, event *girc.E
 }
 
 func (b *NonAddressedBehavior) Execute(ctx irc.ChatContextInterface, event *girc.Event) {
+	if rateLimited(ctx, b.CmdRegistry, true) {
+		return
+	}
 	core.WithRequestLock(ctx, ctx.GetLockKey(), "nonaddressed", func() {
 		b.CmdRegistry.Dispatch(ctx)
 	}, func() {
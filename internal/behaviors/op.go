@@ -37,6 +37,10 @@ func (b *OpBehavior) Check(ctx irc.ChatContextInterface, event *girc.Event) bool
 }
 
 func (b *OpBehavior) Execute(ctx irc.ChatContextInterface, event *girc.Event) {
+	// Limited against whoever changed the mode, and dropped quietly
+	if rateLimited(ctx, nil, true) {
+		return
+	}
 	core.WithRequestLock(ctx, ctx.GetLockKey(), "op", func() {
 		cfg := ctx.GetConfig()
 		changedBy := event.Source.Name
@@ -51,7 +55,7 @@ func (b *OpBehavior) Execute(ctx irc.ChatContextInterface, event *girc.Event) {
 
 		if err != nil {
 			ctx.GetLogger().Error("op_behavior_error", "error", err)
-			ctx.Reply(err.Error())
+			ctx.Reply(llm.ErrorReply(err))
 			return
 		}
 
@@ -0,0 +1,32 @@
+package behaviors
+
+import (
+	"fmt"
+
+	"pkdindustries/soulshack/internal/commands"
+	"pkdindustries/soulshack/internal/irc"
+)
+
+// rateLimited reports whether a chat request is over its rate limit, and
+// turns it away politely if so, once per wait. Quiet requests, such as lines
+// not addressed to the bot, are dropped without a reply. Only LLM
+// completions are limited; commands registered by name always go through.
+// Behaviors that only ever complete pass a nil registry.
+func rateLimited(ctx irc.ChatContextInterface, registry *commands.Registry, quiet bool) bool {
+	if registry != nil {
+		if _, ok := registry.Get(ctx.GetCommand()); ok {
+			return false
+		}
+	}
+	limiter := ctx.GetSystem().GetRateLimiter()
+	wait := limiter.Allow(ctx)
+	if wait == 0 {
+		return false
+	}
+	ctx.GetLogger().Info("rate_limited", "wait", wait.String())
+	if quiet || !limiter.Notify(ctx, wait) {
+		return true
+	}
+	ctx.Reply(fmt.Sprintf("Sorry, that's more requests than I can take right now. Please try again in %s.", wait))
+	return true
+}
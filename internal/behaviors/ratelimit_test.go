@@ -0,0 +1,93 @@
+package behaviors
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lrstanley/girc"
+
+	"pkdindustries/soulshack/internal/commands"
+	mocktest "pkdindustries/soulshack/internal/testing"
+)
+
+func TestRateLimited_OnlyCompletions(t *testing.T) {
+	registry := commands.NewRegistry()
+	registry.Register(&commands.VersionCommand{})
+
+	sys := mocktest.NewMockSystem()
+	cfg := mocktest.DefaultTestConfig()
+	cfg.Bot.UserRPM = 1
+
+	first := mocktest.NewMockContext().WithConfig(cfg).WithSystem(sys).WithArgs("hello")
+	if rateLimited(first, registry, false) {
+		t.Fatal("expected the first completion to be allowed")
+	}
+
+	second := mocktest.NewMockContext().WithConfig(cfg).WithSystem(sys).WithArgs("hello", "again")
+	if !rateLimited(second, registry, false) {
+		t.Fatal("expected the second completion to be limited")
+	}
+	if !strings.Contains(second.LastReply(), "try again in") {
+		t.Errorf("expected a polite rejection, got: %s", second.LastReply())
+	}
+
+	command := mocktest.NewMockContext().WithConfig(cfg).WithSystem(sys).WithArgs("/version")
+	if rateLimited(command, registry, false) {
+		t.Error("expected commands to bypass the rate limit")
+	}
+}
+
+func TestRateLimited_NoticeOncePerWait(t *testing.T) {
+	registry := commands.NewRegistry()
+	sys := mocktest.NewMockSystem()
+	cfg := mocktest.DefaultTestConfig()
+	cfg.Bot.ChannelRPM = 1
+
+	rateLimited(mocktest.NewMockContext().WithConfig(cfg).WithSystem(sys).WithArgs("hello"), registry, false)
+
+	// Lines not addressed to the bot are dropped silently
+	passive := mocktest.NewMockContext().WithConfig(cfg).WithSystem(sys).WithArgs("chatter")
+	if !rateLimited(passive, registry, true) || passive.ReplyCount() != 0 {
+		t.Errorf("expected a quiet drop, got replies %v", passive.Replies)
+	}
+
+	// Addressed requests are told once, then dropped until the wait is over
+	replies := 0
+	for range 3 {
+		ctx := mocktest.NewMockContext().WithConfig(cfg).WithSystem(sys).WithArgs("hello")
+		if !rateLimited(ctx, registry, false) {
+			t.Fatal("expected the request to be limited")
+		}
+		replies += ctx.ReplyCount()
+	}
+	if replies != 1 {
+		t.Errorf("expected one notice, got %d", replies)
+	}
+}
+
+func TestRateLimited_Behaviors(t *testing.T) {
+	sys := mocktest.NewMockSystem()
+	sys.LLM = &mocktest.MockLLM{Responses: []string{"nice link"}}
+	cfg := mocktest.DefaultTestConfig()
+	cfg.Bot.URLWatcher = true
+	cfg.Bot.OpWatcher = true
+	cfg.Bot.ChannelRPM = 1
+
+	url := &girc.Event{Command: girc.PRIVMSG, Params: []string{"#test", "https://example.com"}}
+	first := mocktest.NewMockContext().WithConfig(cfg).WithSystem(sys).WithArgs("https://example.com")
+	(&URLBehavior{}).Execute(first, url)
+	if first.ReplyCount() == 0 {
+		t.Fatal("expected the first URL to be completed")
+	}
+
+	// Further URLs and op changes are over the channel's limit, and dropped
+	// without a word
+	second := mocktest.NewMockContext().WithConfig(cfg).WithSystem(sys).WithArgs("https://example.org")
+	(&URLBehavior{}).Execute(second, url)
+	op := &girc.Event{Command: girc.MODE, Source: &girc.Source{Name: "op"}, Params: []string{"#test", "+o", "bot"}}
+	opped := mocktest.NewMockContext().WithConfig(cfg).WithSystem(sys)
+	(&OpBehavior{BotNick: "bot"}).Execute(opped, op)
+	if second.ReplyCount() != 0 || opped.ReplyCount() != 0 {
+		t.Errorf("expected limited behaviors to stay quiet, got %v and %v", second.Replies, opped.Replies)
+	}
+}
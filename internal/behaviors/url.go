@@ -40,6 +40,11 @@ func (b *URLBehavior) Check(ctx irc.ChatContextInterface, event *girc.Event) boo
 }
 
 func (b *URLBehavior) Execute(ctx irc.ChatContextInterface, event *girc.Event) {
+	// Nobody asked for this completion, so being over the limit drops it
+	// quietly
+	if rateLimited(ctx, nil, true) {
+		return
+	}
 	core.WithRequestLock(ctx, ctx.GetLockKey(), "url", func() {
 		cfg := ctx.GetConfig()
 		prompt := fmt.Sprintf("(nick:%s) %s", ctx.GetSource(), event.Last())
@@ -60,7 +65,7 @@ func (b *URLBehavior) Execute(ctx irc.ChatContextInterface, event *girc.Event) {
 		if err != nil {
 			ctx.GetLogger().Error("url_behavior_error", "error", err)
 			if !silent {
-				ctx.Reply(err.Error())
+				ctx.Reply(llm.ErrorReply(err))
 			}
 			return
 		}
@@ -0,0 +1,180 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/lrstanley/girc"
+
+	"pkdindustries/soulshack/internal/config"
+	"pkdindustries/soulshack/internal/core"
+)
+
+// reloader re-reads the config file and applies it to the running bot
+type reloader struct {
+	mu     sync.Mutex
+	cfg    *config.Configuration
+	sys    *SystemImpl
+	client *girc.Client
+}
+
+// Reload applies the config file to the running bot. It returns the changes
+// applied and the keys that only take effect after a restart.
+func (r *reloader) Reload() ([]config.Change, []string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg := r.cfg.Global()
+	if cfg.Path == "" {
+		return nil, nil, errors.New("no config file loaded, start with --config")
+	}
+	next, err := config.Load(cfg.Path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", cfg.Path, err)
+	}
+
+	var changes []config.Change
+	var restart []string
+	var oldTools []string
+	var oldChannels []config.ChannelConfig
+	var oldAPI config.APIConfig
+	var logChanged bool
+
+	// Requests read snapshots of the config, so it is swapped under its lock
+	cfg.Update(func(cfg *config.Configuration) {
+		changes = config.Compare(cfg, next)
+		restart = restartKeys(cfg, next)
+
+		// Settings that need a restart keep their running values
+		next.Bot.Sandbox = cfg.Bot.Sandbox
+		next.Session.Store, next.Session.Dir = cfg.Session.Store, cfg.Session.Dir
+		next.Bot.UsageLog = cfg.Bot.UsageLog
+		next.Bot.AuditLog = cfg.Bot.AuditLog
+		next.Bot.TranscriptDir = cfg.Bot.TranscriptDir
+		next.Bot.MemoryFile = cfg.Bot.MemoryFile
+		next.Bot.MetricsAddr, next.Bot.AdminAddr = cfg.Bot.MetricsAddr, cfg.Bot.AdminAddr
+		next.Bot.OTLPEndpoint = cfg.Bot.OTLPEndpoint
+		next.Bot.PasteAddr, next.Bot.PasteURL = cfg.Bot.PasteAddr, cfg.Bot.PasteURL
+		next.Bot.PasteEndpoint, next.Bot.PasteField = cfg.Bot.PasteEndpoint, cfg.Bot.PasteField
+
+		oldTools, oldChannels, oldAPI = cfg.Bot.Tools, cfg.Server.Channels, *cfg.API
+		logChanged = cfg.Bot.LogLevel != next.Bot.LogLevel || cfg.Bot.LogFormat != next.Bot.LogFormat || cfg.Bot.Verbose != next.Bot.Verbose
+
+		*cfg.Bot = *next.Bot
+		*cfg.Model = *next.Model
+		*cfg.Session = *next.Session
+		*cfg.API = *next.API
+		cfg.Server.Channels = next.Server.Channels
+		cfg.Server.SendBurst, cfg.Server.SendDelay, cfg.Server.SendTargetDelay = next.Server.SendBurst, next.Server.SendDelay, next.Server.SendTargetDelay
+		cfg.Overrides.Replace(next.Overrides)
+		cfg.Permissions = next.Permissions
+		cfg.Prices = next.Prices
+	})
+	cfg = cfg.Snapshot()
+
+	if logChanged {
+		level := cfg.Bot.LogLevel
+		if cfg.Bot.Verbose {
+			level = "debug"
+		}
+		core.InitLogger(level, cfg.Bot.LogFormat)
+	}
+	if *cfg.API != oldAPI {
+		if err := r.sys.UpdateLLM(*cfg.API); err != nil {
+			slog.Error("llm_update_failed", "error", err)
+		}
+	}
+	r.reloadTools(oldTools, cfg.Bot.Tools)
+	r.reloadChannels(oldChannels, cfg.Server.Channels)
+
+	for _, change := range changes {
+		slog.Info("config_reloaded", "change", change.String())
+	}
+	if len(restart) > 0 {
+		slog.Warn("config_restart_required", "keys", restart)
+	}
+	return changes, restart, nil
+}
+
+// reloadTools loads tool specs that were added and unloads removed ones
+func (r *reloader) reloadTools(old, next []string) {
+	for _, spec := range old {
+		if !slices.Contains(next, spec) {
+			r.sys.UnloadTool(spec)
+			slog.Info("tool_unloaded", "tool", spec)
+		}
+	}
+	for _, spec := range next {
+		if slices.Contains(old, spec) {
+			continue
+		}
+		if _, err := r.sys.LoadTool(spec); err != nil {
+			slog.Warn("tool_load_failed", "tool", spec, "error", err)
+			continue
+		}
+		slog.Info("tool_loaded", "tool", spec)
+	}
+}
+
+// reloadChannels joins added channels and parts removed ones
+func (r *reloader) reloadChannels(old, next []config.ChannelConfig) {
+	if r.client == nil || !r.client.IsConnected() {
+		return // the connected behavior joins everything on connect
+	}
+	has := func(list []config.ChannelConfig, name string) bool {
+		return slices.ContainsFunc(list, func(ch config.ChannelConfig) bool { return strings.EqualFold(ch.Name, name) })
+	}
+	for _, ch := range old {
+		if !has(next, ch.Name) {
+			slog.Info("channel_parting", "channel", ch.Name)
+			r.client.Cmd.Part(ch.Name)
+		}
+	}
+	for _, ch := range next {
+		if has(old, ch.Name) {
+			continue
+		}
+		slog.Info("channel_joining", "channel", ch.Name)
+		if ch.Key != "" {
+			r.client.Cmd.JoinKey(ch.Name, ch.Key)
+		} else {
+			r.client.Cmd.Join(ch.Name)
+		}
+	}
+}
+
+// restartKeys lists settings that changed but cannot be applied live
+func restartKeys(cur, next *config.Configuration) []string {
+	var keys []string
+	check := func(key string, changed bool) {
+		if changed {
+			keys = append(keys, key)
+		}
+	}
+	check("nick", cur.Server.Nick != next.Server.Nick)
+	check("server", cur.Server.Server != next.Server.Server)
+	check("port", cur.Server.Port != next.Server.Port)
+	check("tls", cur.Server.SSL != next.Server.SSL)
+	check("tlsinsecure", cur.Server.TLSInsecure != next.Server.TLSInsecure)
+	check("saslnick", cur.Server.SASLNick != next.Server.SASLNick)
+	check("saslpass", cur.Server.SASLPass != next.Server.SASLPass)
+	check("sandbox", cur.Bot.Sandbox != next.Bot.Sandbox)
+	check("sessionstore", cur.Session.Store != next.Session.Store)
+	check("sessiondir", cur.Session.Dir != next.Session.Dir)
+	check("usagelog", cur.Bot.UsageLog != next.Bot.UsageLog)
+	check("auditlog", cur.Bot.AuditLog != next.Bot.AuditLog)
+	check("transcriptdir", cur.Bot.TranscriptDir != next.Bot.TranscriptDir)
+	check("memoryfile", cur.Bot.MemoryFile != next.Bot.MemoryFile)
+	check("metricsaddr", cur.Bot.MetricsAddr != next.Bot.MetricsAddr)
+	check("adminaddr", cur.Bot.AdminAddr != next.Bot.AdminAddr)
+	check("otlpendpoint", cur.Bot.OTLPEndpoint != next.Bot.OTLPEndpoint)
+	check("pasteaddr", cur.Bot.PasteAddr != next.Bot.PasteAddr)
+	check("pasteurl", cur.Bot.PasteURL != next.Bot.PasteURL)
+	check("pasteendpoint", cur.Bot.PasteEndpoint != next.Bot.PasteEndpoint)
+	check("pastefield", cur.Bot.PasteField != next.Bot.PasteField)
+	return keys
+}
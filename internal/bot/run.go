@@ -4,16 +4,24 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"io"
 	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/lrstanley/girc"
 
+	"pkdindustries/soulshack/internal/api"
 	"pkdindustries/soulshack/internal/behaviors"
 	"pkdindustries/soulshack/internal/commands"
 	"pkdindustries/soulshack/internal/config"
 	"pkdindustries/soulshack/internal/core"
 	"pkdindustries/soulshack/internal/irc"
+	"pkdindustries/soulshack/internal/metrics"
+	"pkdindustries/soulshack/internal/paste"
+	"pkdindustries/soulshack/internal/store"
 )
 
 // Run starts the IRC bot with the given configuration
@@ -24,21 +32,49 @@ func Run(ctx context.Context, cfg *config.Configuration) error {
 	}
 	core.InitLogger(level, cfg.Bot.LogFormat)
 
+	if cfg.Bot.OTLPEndpoint != "" {
+		shutdown, err := core.InitTracing(ctx, cfg.Bot.OTLPEndpoint, Version)
+		if err != nil {
+			slog.Error("tracing_failed", "endpoint", cfg.Bot.OTLPEndpoint, "error", err)
+		} else {
+			slog.Info("tracing_enabled", "endpoint", cfg.Bot.OTLPEndpoint)
+			defer func() {
+				flushctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				shutdown(flushctx)
+			}()
+		}
+	}
+
 	sys := NewSystem(cfg)
+	if closer, ok := sys.GetSessionStore().(io.Closer); ok {
+		defer closer.Close()
+	}
 
 	// Initialize command registry
 	cmdRegistry := commands.NewRegistry()
 	cmdRegistry.Register(&commands.SetCommand{})
 	cmdRegistry.Register(&commands.GetCommand{})
+	cmdRegistry.Register(&commands.UnsetCommand{})
+	cmdRegistry.Register(&commands.ConfigCommand{})
+	reload := &reloader{cfg: cfg, sys: sys}
+	cmdRegistry.Register(&commands.ReloadCommand{Reload: reload.Reload})
 	cmdRegistry.Register(commands.NewHelpCommand(cmdRegistry))
 	cmdRegistry.Register(&commands.VersionCommand{Version: "v" + Version})
 	cmdRegistry.Register(&commands.CompletionCommand{})
 	cmdRegistry.Register(&commands.ToolsCommand{})
 	cmdRegistry.Register(&commands.AdminCommand{})
 	cmdRegistry.Register(&commands.StatsCommand{})
+	cmdRegistry.Register(&commands.UsageCommand{})
+	cmdRegistry.Register(&commands.BudgetCommand{})
+	cmdRegistry.Register(&commands.AuditCommand{})
+	cmdRegistry.Register(&commands.MemoryCommand{})
 
 	// Initialize behavior registry (order matters: passive watchers first, addressed last as fallback)
 	behaviorRegistry := behaviors.NewRegistry()
+	// Passive behaviors
+	behaviorRegistry.Register(&behaviors.LoggerBehavior{})
+	behaviorRegistry.Register(&behaviors.ContextBehavior{})
 	// Lifecycle behaviors
 	behaviorRegistry.Register(&behaviors.ConnectedBehavior{})
 	behaviorRegistry.Register(&behaviors.NickErrorBehavior{})
@@ -73,18 +109,68 @@ func Run(ctx context.Context, cfg *config.Configuration) error {
 		}
 	}
 
+	// Pace outbound lines to stay under the server's flood limits
+	sendQueue := irc.NewSendQueue(ircClient, cfg)
+	if transcript := sys.GetTranscript(); transcript != nil {
+		// The server does not echo the bot's lines, so log them as sent
+		sendQueue.OnSent(func(target, text string, action bool) {
+			if !girc.IsValidChannel(target) {
+				return
+			}
+			kind := store.TranscriptMessage
+			if action {
+				kind = store.TranscriptAction
+			}
+			line := store.TranscriptLine{Channel: target, Kind: kind, Nick: ircClient.GetNick(), Text: text}
+			if err := transcript.Write(line); err != nil {
+				slog.Error("transcript_write_failed", "error", err)
+			}
+		})
+		defer transcript.Close()
+	}
+	go sendQueue.Run(ctx)
+
+	if cfg.Bot.MetricsAddr != "" {
+		go metrics.Serve(ctx, cfg.Bot.MetricsAddr, sys.GetSessionStore())
+	}
+	if cfg.Bot.AdminAddr != "" {
+		go api.New(cfg, sys, sendQueue).Serve(ctx, cfg.Bot.AdminAddr)
+	}
+	if server, ok := sys.GetPaster().(*paste.Server); ok {
+		go server.Serve(ctx, cfg.Bot.PasteAddr)
+	}
+
 	go func() {
 		<-ctx.Done()
 		ircClient.Quit("Shutting down...")
 		slog.Info("irc_client_closed")
 	}()
 
+	// Reload the config file on SIGHUP
+	reload.client = ircClient
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(hup)
+		for {
+			select {
+			case <-hup:
+				slog.Info("config_reloading", "signal", "SIGHUP")
+				if _, _, err := reload.Reload(); err != nil {
+					slog.Error("config_reload_failed", "error", err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
 	// Single global handler routes all events through the behavior registry
 	ircClient.Handlers.AddBg(girc.ALL_EVENTS, func(client *girc.Client, e girc.Event) {
 		if !behaviorRegistry.Handles(e.Command) {
 			return
 		}
-		chatCtx, cancel := irc.NewChatContext(ctx, cfg, sys, client, &e, fatalErr)
+		chatCtx, cancel := irc.NewChatContext(ctx, cfg, sys, client, sendQueue, &e, fatalErr)
 		defer cancel()
 		behaviorRegistry.Process(chatCtx, &e)
 	})
@@ -95,6 +181,9 @@ func Run(ctx context.Context, cfg *config.Configuration) error {
 		if ctx.Err() != nil {
 			return nil
 		}
+		if i > 0 {
+			metrics.Reconnects.Inc()
+		}
 
 		slog.Info("server_connecting",
 			"server", ircClient.Config.Server,
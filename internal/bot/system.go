@@ -2,6 +2,8 @@ package bot
 
 import (
 	"log/slog"
+	"slices"
+	"sync"
 	"sync/atomic"
 
 	"github.com/alexschlessinger/pollytool/sessions"
@@ -12,12 +14,26 @@ import (
 	"pkdindustries/soulshack/internal/core"
 	"pkdindustries/soulshack/internal/irc"
 	"pkdindustries/soulshack/internal/llm"
+	"pkdindustries/soulshack/internal/paste"
+	"pkdindustries/soulshack/internal/store"
 )
 
 type SystemImpl struct {
-	Store sessions.SessionStore
-	Tools *tools.ToolRegistry
-	llm   atomic.Value // stores core.LLM
+	Store   sessions.SessionStore
+	Tools   *tools.ToolRegistry
+	llm     atomic.Value // stores core.LLM
+	Limiter *core.RateLimiter
+	Backlog *core.Backlog
+	Usage   *store.UsageLedger
+	Audit   *store.AuditLog
+	// Transcript archives channel conversations, nil when disabled
+	Transcript *store.Transcript
+	Memory     *store.MemoryStore
+	// Paster takes long responses, nil when disabled
+	Paster paste.Paster
+
+	toolMu    sync.Mutex
+	toolSpecs map[string][]string // tool spec -> names of the tools it loaded
 }
 
 func (s *SystemImpl) GetToolRegistry() *tools.ToolRegistry {
@@ -32,14 +48,84 @@ func (s *SystemImpl) GetLLM() core.LLM {
 	return s.llm.Load().(core.LLM)
 }
 
+func (s *SystemImpl) GetRateLimiter() *core.RateLimiter {
+	return s.Limiter
+}
+
+func (s *SystemImpl) GetBacklog() *core.Backlog {
+	return s.Backlog
+}
+
+func (s *SystemImpl) GetUsage() *store.UsageLedger {
+	return s.Usage
+}
+
+func (s *SystemImpl) GetAudit() *store.AuditLog {
+	return s.Audit
+}
+
+func (s *SystemImpl) GetTranscript() *store.Transcript {
+	return s.Transcript
+}
+
+func (s *SystemImpl) GetMemory() *store.MemoryStore {
+	return s.Memory
+}
+
+func (s *SystemImpl) GetPaster() paste.Paster {
+	return s.Paster
+}
+
 func (s *SystemImpl) UpdateLLM(cfg config.APIConfig) error {
 	slog.Info("llm_updating")
 	s.llm.Store(llm.NewPollyLLM(cfg))
 	return nil
 }
 
-func NewSystem(c *config.Configuration) core.System {
-	s := &SystemImpl{}
+// LoadTool loads a tool spec from the configuration and remembers which
+// tools it provided, so it can be unloaded on reload
+func (s *SystemImpl) LoadTool(spec string) ([]string, error) {
+	result, err := s.Tools.LoadToolAuto(spec)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, server := range result.Servers {
+		names = append(names, server.ToolNames...)
+	}
+	s.toolMu.Lock()
+	s.toolSpecs[spec] = names
+	s.toolMu.Unlock()
+	return names, nil
+}
+
+// UnloadTool removes the tools loaded from a spec
+func (s *SystemImpl) UnloadTool(spec string) {
+	s.toolMu.Lock()
+	names := s.toolSpecs[spec]
+	delete(s.toolSpecs, spec)
+	s.toolMu.Unlock()
+	for _, name := range names {
+		s.Tools.Remove(name)
+	}
+}
+
+// RemoveTool removes one tool, forgetting it from the spec that loaded it
+func (s *SystemImpl) RemoveTool(name string) {
+	s.toolMu.Lock()
+	for spec, names := range s.toolSpecs {
+		s.toolSpecs[spec] = slices.DeleteFunc(names, func(n string) bool { return n == name })
+	}
+	s.toolMu.Unlock()
+	s.Tools.Remove(name)
+}
+
+func NewSystem(c *config.Configuration) *SystemImpl {
+	s := &SystemImpl{
+		Limiter:   core.NewRateLimiter(),
+		Backlog:   core.NewBacklog(),
+		toolSpecs: make(map[string][]string),
+	}
 
 	// Optionally enable platform sandboxing for shell/bash/MCP tools.
 	var regOpts []tools.RegistryOption
@@ -56,12 +142,13 @@ func NewSystem(c *config.Configuration) core.System {
 
 	// Register native IRC tools with polly's registry
 	irc.RegisterIRCTools(s.Tools)
+	irc.RegisterMemoryTools(s.Tools)
 
 	// Load all tools from configuration (polly now handles native, shell, and MCP tools)
 	toolErrors := 0
 	if len(c.Bot.Tools) > 0 {
 		for _, toolSpec := range c.Bot.Tools {
-			if _, err := s.Tools.LoadToolAuto(toolSpec); err != nil {
+			if _, err := s.LoadTool(toolSpec); err != nil {
 				slog.Warn("tool_load_failed", "tool", toolSpec, "error", err)
 				toolErrors++
 				continue
@@ -69,13 +156,26 @@ func NewSystem(c *config.Configuration) core.System {
 		}
 	}
 
-	// initialize sessions with pollytool's SyncMapSessionStore
-	s.Store = sessions.NewSyncMapSessionStore(&sessions.Metadata{
+	s.Store = newSessionStore(c.Session, &sessions.Metadata{
 		MaxHistoryTokens: c.Session.MaxContext,
 		TTL:              c.Session.TTL,
 		SystemPrompt:     c.Bot.Prompt,
 	})
 
+	s.Usage = openStore("usage_ledger", c.Bot.UsageLog, store.NewUsageLedger)
+	s.Audit = openStore("audit_log", c.Bot.AuditLog, store.NewAuditLog)
+	s.Memory = openStore("memory_store", c.Bot.MemoryFile, store.NewMemoryStore)
+	s.Paster = newPaster(c.Bot)
+
+	if c.Bot.TranscriptDir != "" {
+		transcript, err := store.NewTranscript(c.Bot.TranscriptDir)
+		if err != nil {
+			slog.Error("transcript_failed", "dir", c.Bot.TranscriptDir, "error", err)
+		} else {
+			s.Transcript = transcript
+		}
+	}
+
 	// Initialize LLM
 	s.UpdateLLM(*c.API)
 
@@ -92,3 +192,50 @@ func NewSystem(c *config.Configuration) core.System {
 
 	return s
 }
+
+// openStore opens a store at path, falling back to one kept in memory when
+// the file cannot be used
+func openStore[T any](name, path string, open func(string) (T, error)) T {
+	s, err := open(path)
+	if err != nil {
+		slog.Error(name+"_failed", "path", path, "error", err)
+		s, _ = open("") // in memory, cannot fail
+	}
+	return s
+}
+
+// newPaster returns the configured paste endpoint, or else the built-in
+// paste server, or nil when neither is set
+func newPaster(c *config.BotConfig) paste.Paster {
+	switch {
+	case c.PasteEndpoint != "":
+		return paste.NewEndpoint(c.PasteEndpoint, c.PasteField)
+	case c.PasteAddr != "":
+		baseURL := c.PasteURL
+		if baseURL == "" {
+			baseURL = "http://" + c.PasteAddr
+		}
+		return paste.NewServer(baseURL)
+	}
+	return nil
+}
+
+// newSessionStore creates the configured session store, falling back to
+// pollytool's in-memory SyncMapSessionStore
+func newSessionStore(c *config.SessionConfig, defaults *sessions.Metadata) sessions.SessionStore {
+	switch c.Store {
+	case "file":
+		fileStore, err := store.NewFileSessionStore(c.Dir, defaults)
+		if err != nil {
+			slog.Error("session_store_failed", "store", c.Store, "dir", c.Dir, "error", err)
+			break
+		}
+		names, _ := fileStore.List()
+		slog.Info("sessions_restored", "dir", c.Dir, "sessions", len(names))
+		return fileStore
+	case "memory", "":
+	default:
+		slog.Warn("session_store_unknown", "store", c.Store)
+	}
+	return sessions.NewSyncMapSessionStore(defaults)
+}
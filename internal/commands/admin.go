@@ -5,6 +5,7 @@ import (
 	"slices"
 	"strings"
 
+	"pkdindustries/soulshack/internal/config"
 	"pkdindustries/soulshack/internal/irc"
 )
 
@@ -54,25 +55,28 @@ func (c *AdminCommand) listAdmins(ctx irc.ChatContextInterface) {
 
 func (c *AdminCommand) addAdmin(ctx irc.ChatContextInterface, hostmask string) {
 	if hostmask == "" {
-		ctx.Reply("Usage: /admins add <hostmask>")
+		ctx.Reply("Usage: /admins add <hostmask|$a:account>")
 		return
 	}
 
-	if err := irc.ValidateHostmask(hostmask); err != nil {
-		ctx.Reply(fmt.Sprintf("Invalid hostmask: %s", err))
+	if err := irc.ValidateAdminMask(hostmask); err != nil {
+		replyError(ctx, fmt.Sprintf("Invalid hostmask: %s", err))
 		return
 	}
 
-	cfg := ctx.GetConfig()
-
-	// Check if already exists
-	if slices.Contains(cfg.Bot.Admins, hostmask) {
+	added := false
+	ctx.GetConfig().Update(func(cfg *config.Configuration) {
+		if !slices.Contains(cfg.Bot.Admins, hostmask) {
+			cfg.Bot.Admins = append(slices.Clip(cfg.Bot.Admins), hostmask)
+			added = true
+		}
+	})
+	if !added {
 		ctx.Reply(fmt.Sprintf("Already an admin: %s", hostmask))
 		return
 	}
-
-	cfg.Bot.Admins = append(cfg.Bot.Admins, hostmask)
 	ctx.Reply(fmt.Sprintf("Added admin: %s", hostmask))
+	autosave(ctx)
 	ctx.GetSession().Clear()
 }
 
@@ -82,16 +86,19 @@ func (c *AdminCommand) removeAdmin(ctx irc.ChatContextInterface, hostmask string
 		return
 	}
 
-	cfg := ctx.GetConfig()
-
-	// Find and remove
-	idx := slices.Index(cfg.Bot.Admins, hostmask)
-	if idx == -1 {
+	removed := false
+	ctx.GetConfig().Update(func(cfg *config.Configuration) {
+		if idx := slices.Index(cfg.Bot.Admins, hostmask); idx != -1 {
+			// Snapshots share the old slice, so it isn't changed in place
+			cfg.Bot.Admins = slices.Delete(slices.Clone(cfg.Bot.Admins), idx, idx+1)
+			removed = true
+		}
+	})
+	if !removed {
 		ctx.Reply(fmt.Sprintf("Not an admin: %s", hostmask))
 		return
 	}
-
-	cfg.Bot.Admins = slices.Delete(cfg.Bot.Admins, idx, idx+1)
 	ctx.Reply(fmt.Sprintf("Removed admin: %s", hostmask))
+	autosave(ctx)
 	ctx.GetSession().Clear()
 }
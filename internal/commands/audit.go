@@ -0,0 +1,158 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"pkdindustries/soulshack/internal/config"
+	"pkdindustries/soulshack/internal/core"
+	"pkdindustries/soulshack/internal/irc"
+	"pkdindustries/soulshack/internal/store"
+)
+
+// permissionDenied is the reply for commands the sender may not run
+const permissionDenied = "You don't have permission to perform this action."
+
+// auditDefault and auditMax bound how many entries /audit lists
+const (
+	auditDefault = 5
+	auditMax     = 20
+)
+
+// Audited is implemented by commands that decide for themselves whether a
+// run is recorded in the audit log, e.g. one with privileged subcommands
+type Audited interface {
+	Audited(ctx irc.ChatContextInterface) bool
+}
+
+// audited reports whether running cmd should be recorded in the audit log.
+// By default that is any command needing more than the everyone role.
+func (r *Registry) audited(ctx irc.ChatContextInterface, cmd Command) bool {
+	if a, ok := cmd.(Audited); ok {
+		return a.Audited(ctx)
+	}
+	fallback := config.RoleEveryone
+	if cmd.AdminOnly() {
+		fallback = config.RoleOwner
+	}
+	return ctx.GetConfig().Permissions.Command(cmd.Name(), fallback) > config.RoleEveryone
+}
+
+// Outcomes of an audited command
+const (
+	outcomeOK     = "ok"
+	outcomeDenied = "denied"
+	outcomeError  = "error"
+)
+
+// auditReplies records the replies a command sends, and the outcome it
+// reports, so they can be audited
+type auditReplies struct {
+	irc.ChatContextInterface
+	replies []string
+	outcome string
+}
+
+func (a *auditReplies) Reply(message string) {
+	a.replies = append(a.replies, message)
+	a.ChatContextInterface.Reply(message)
+}
+
+// reportOutcome sets the outcome audited for the running command, which is
+// ok unless the command reports otherwise
+func reportOutcome(ctx irc.ChatContextInterface, outcome string) {
+	if a, ok := ctx.(*auditReplies); ok {
+		a.outcome = outcome
+	}
+}
+
+// replyError replies with why the command failed, auditing it as an error
+func replyError(ctx irc.ChatContextInterface, message string) {
+	reportOutcome(ctx, outcomeError)
+	ctx.Reply(message)
+}
+
+// replyDenied refuses the sender, auditing the command as denied
+func replyDenied(ctx irc.ChatContextInterface, message string) {
+	reportOutcome(ctx, outcomeDenied)
+	ctx.Reply(message)
+}
+
+// auditCommand records a command run in the audit log
+func auditCommand(ctx irc.ChatContextInterface, cmd Command, outcome, detail string) {
+	entry := core.NewAuditEntry(ctx, "command", cmd.Name())
+	entry.Args = auditArgs(ctx.GetArgs()[1:])
+	entry.Outcome = outcome
+	entry.Detail = detail
+	core.Audit(ctx, entry)
+}
+
+// auditArgs joins command arguments, masking anything after the name of a
+// secret config field so API keys never reach the audit log
+func auditArgs(args []string) string {
+	for i, arg := range args {
+		if field, ok := config.Fields[arg]; ok && field.Secret && i+1 < len(args) {
+			masked := append(args[:i+1:i+1], config.MaskAPIKey(strings.Join(args[i+1:], " ")))
+			return strings.Join(masked, " ")
+		}
+	}
+	return strings.Join(args, " ")
+}
+
+// AuditCommand handles the /audit command for reviewing privileged actions
+type AuditCommand struct{}
+
+func (c *AuditCommand) Name() string    { return "/audit" }
+func (c *AuditCommand) AdminOnly() bool { return true }
+
+// Audited is false: reading the audit log is not itself audited
+func (c *AuditCommand) Audited(ctx irc.ChatContextInterface) bool { return false }
+
+func (c *AuditCommand) Execute(ctx irc.ChatContextInterface) {
+	count, filter := auditDefault, ""
+	for _, arg := range ctx.GetArgs()[1:] {
+		if n, err := strconv.Atoi(arg); err == nil && n > 0 {
+			count = min(n, auditMax)
+			continue
+		}
+		filter = arg
+	}
+
+	entries := ctx.GetSystem().GetAudit().Recent(count, func(e store.AuditEntry) bool {
+		return filter == "" ||
+			strings.EqualFold(e.Nick, filter) ||
+			strings.EqualFold(e.Account, filter) ||
+			strings.EqualFold(e.Channel, filter) ||
+			strings.EqualFold(e.Action, filter)
+	})
+	if len(entries) == 0 {
+		ctx.Reply("No audit entries")
+		return
+	}
+	for _, e := range entries {
+		ctx.Reply(truncateMessage(formatAuditEntry(e), ctx.GetConfig().Session.ChunkMax))
+	}
+}
+
+// formatAuditEntry shows an entry on one line, e.g.
+// "01-02 15:04 alice in #dev: /set model gpt-4o (ok)"
+func formatAuditEntry(e store.AuditEntry) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s", e.Time.Format("01-02 15:04"), e.Nick)
+	if e.Account != "" && !strings.EqualFold(e.Account, e.Nick) {
+		fmt.Fprintf(&b, " ($a:%s)", e.Account)
+	}
+	if e.Channel != "" {
+		fmt.Fprintf(&b, " in %s", e.Channel)
+	}
+	fmt.Fprintf(&b, ": %s", e.Action)
+	if e.Args != "" {
+		fmt.Fprintf(&b, " %s", e.Args)
+	}
+	fmt.Fprintf(&b, " (%s)", e.Outcome)
+	if e.Detail != "" {
+		fmt.Fprintf(&b, " %s", e.Detail)
+	}
+	return b.String()
+}
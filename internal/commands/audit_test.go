@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"pkdindustries/soulshack/internal/config"
+	"pkdindustries/soulshack/internal/store"
+	mocktest "pkdindustries/soulshack/internal/testing"
+)
+
+func TestDispatch_Audit(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(&SetCommand{})
+	registry.Register(&ToolsCommand{})
+	registry.Register(&mockCommand{name: "/get"})
+	sys := mocktest.NewMockSystem()
+
+	cfg := mocktest.DefaultTestConfig()
+	cfg.Overrides = config.NewChannelOverrides()
+	ctx := mocktest.NewMockContext().
+		WithConfig(cfg).
+		WithSystem(sys).
+		WithAdmin(true).
+		WithUser("alice", "al", "example.org").
+		WithSource("alice").
+		WithArgs("/set", "openaikey", "sk-1234567890abcdef")
+	registry.Dispatch(ctx)
+
+	registry.Dispatch(mocktest.NewMockContext().WithSystem(sys).WithSource("eve").WithArgs("/set", "model", "x"))
+	registry.Dispatch(mocktest.NewMockContext().WithSystem(sys).WithSource("eve").WithArgs("/tools", "load", "evil.json"))
+	registry.Dispatch(mocktest.NewMockContext().WithSystem(sys).WithArgs("/tools", "list"))
+	registry.Dispatch(mocktest.NewMockContext().WithSystem(sys).WithArgs("/get", "model"))
+	registry.Dispatch(mocktest.NewMockContext().WithSystem(sys).WithAdmin(true).WithArgs("/set", "temperature", "hot"))
+
+	entries := sys.Audit.Recent(10, nil)
+	if len(entries) != 4 {
+		t.Fatalf("expected /set three times and /tools load audited, got %+v", entries)
+	}
+
+	if failed := entries[0]; failed.Outcome != "error" || failed.Detail == "" {
+		t.Errorf("expected the invalid /set audited as an error, got %+v", failed)
+	}
+
+	set := entries[3]
+	if set.Nick != "alice" || set.Hostmask != "alice!al@example.org" || set.Channel != "#test" || set.Outcome != "ok" {
+		t.Errorf("unexpected entry: %+v", set)
+	}
+	if strings.Contains(set.Args, "1234567890") || strings.Contains(set.Detail, "1234567890") {
+		t.Errorf("expected the API key masked, got args %q detail %q", set.Args, set.Detail)
+	}
+
+	for _, e := range entries[1:3] {
+		if e.Nick != "eve" || e.Outcome != "denied" {
+			t.Errorf("expected eve's attempt denied, got %+v", e)
+		}
+	}
+}
+
+func TestAuditCommand(t *testing.T) {
+	sys := mocktest.NewMockSystem()
+	sys.Audit.Record(store.AuditEntry{Kind: "command", Action: "/set", Nick: "alice", Channel: "#dev", Outcome: "ok"})
+	sys.Audit.Record(store.AuditEntry{Kind: "tool", Action: "irc__kick", Nick: "bob", Channel: "#ops", Outcome: "ok"})
+	sys.Audit.Record(store.AuditEntry{Kind: "command", Action: "/admins", Nick: "alice", Channel: "#ops", Outcome: "ok"})
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected []string
+	}{
+		{"recent", []string{"/audit"}, []string{"/admins", "irc__kick", "/set"}},
+		{"count", []string{"/audit", "1"}, []string{"/admins"}},
+		{"by nick", []string{"/audit", "alice"}, []string{"/admins", "/set"}},
+		{"by channel", []string{"/audit", "#ops"}, []string{"/admins", "irc__kick"}},
+		{"by action", []string{"/audit", "irc__kick"}, []string{"irc__kick"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := mocktest.NewMockContext().WithSystem(sys).WithAdmin(true).WithArgs(tt.args...)
+			(&AuditCommand{}).Execute(ctx)
+			if len(ctx.Replies) != len(tt.expected) {
+				t.Fatalf("expected %d replies, got %v", len(tt.expected), ctx.Replies)
+			}
+			for i, action := range tt.expected {
+				if !strings.Contains(ctx.Replies[i], ": "+action) {
+					t.Errorf("reply %d: expected %s, got %q", i, action, ctx.Replies[i])
+				}
+			}
+		})
+	}
+
+	ctx := mocktest.NewMockContext().WithAdmin(true).WithArgs("/audit", "nobody")
+	(&AuditCommand{}).Execute(ctx)
+	if ctx.LastReply() != "No audit entries" {
+		t.Errorf("unexpected reply: %q", ctx.LastReply())
+	}
+}
@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lrstanley/girc"
+
+	"pkdindustries/soulshack/internal/irc"
+	"pkdindustries/soulshack/internal/llm"
+)
+
+// BudgetCommand handles the /budget command for viewing and resetting token budgets
+type BudgetCommand struct{}
+
+func (c *BudgetCommand) Name() string    { return "/budget" }
+func (c *BudgetCommand) AdminOnly() bool { return true }
+
+func (c *BudgetCommand) Execute(ctx irc.ChatContextInterface) {
+	args := ctx.GetArgs()[1:]
+	ledger := ctx.GetSystem().GetUsage()
+
+	if len(args) > 0 && args[0] == "reset" {
+		if len(args) < 2 {
+			ctx.Reply("Usage: /budget reset <global|#channel|user>")
+			return
+		}
+		scope := budgetScope(ctx, args[1])
+		if err := llm.ResetBudget(ledger, scope); err != nil {
+			ctx.GetLogger().Error("budget_reset_failed", "scope", scope, "error", err)
+			replyError(ctx, fmt.Sprintf("Failed to reset budgets for %s: %v", scope, err))
+			return
+		}
+		ctx.GetLogger().Info("budget_reset", "scope", scope)
+		ctx.Reply(fmt.Sprintf("Budgets for %s reset", scope))
+		return
+	}
+
+	// Without a scope, show the global budgets and the current channel's
+	cfg := ctx.GetConfig()
+	channel, user, scope := ctx.GetChannelName(), "", llm.GlobalBudget
+	if len(args) > 0 {
+		scope = budgetScope(ctx, args[0])
+		switch {
+		case scope == llm.GlobalBudget:
+			channel = ""
+		case girc.IsValidChannel(scope):
+			channel = scope
+			cfg = cfg.ForChannel(scope)
+		default:
+			channel, user = "", scope
+		}
+	}
+
+	var entries []string
+	for _, b := range llm.Budgets(cfg, ledger, channel, user, time.Now()) {
+		if len(args) > 0 && b.Scope != scope {
+			continue
+		}
+		entries = append(entries, fmt.Sprintf("%s: %s of %s tokens (%d%%)", b, formatTokenCount(b.Spent), formatTokenCount(b.Limit), b.Percentage()))
+	}
+	if len(entries) == 0 && len(args) == 0 {
+		ctx.Reply("No budgets set")
+		return
+	}
+	if len(entries) == 0 {
+		ctx.Reply(fmt.Sprintf("No budgets set for %s", scope))
+		return
+	}
+	ctx.Reply(truncateMessage(strings.Join(entries, " | "), cfg.Session.ChunkMax))
+}
+
+// budgetScope returns the scope an argument names. Users are kept under
+// their identity, so a nick the bot knows is resolved to it; $a:account and
+// ident@host are taken as they are.
+func budgetScope(ctx irc.ChatContextInterface, arg string) string {
+	if arg == llm.GlobalBudget || girc.IsValidChannel(arg) || strings.HasPrefix(arg, "$a:") || strings.Contains(arg, "@") {
+		return arg
+	}
+	if id := ctx.GetUser(arg).Identity(); id != "" {
+		return id
+	}
+	return arg
+}
@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"pkdindustries/soulshack/internal/core"
+	"pkdindustries/soulshack/internal/store"
+	mocktest "pkdindustries/soulshack/internal/testing"
+)
+
+func TestBudgetCommand(t *testing.T) {
+	sys := mocktest.NewMockSystem()
+	sys.Usage.Record(store.UsageRecord{Nick: "alice", Channel: "#dev", InputTokens: 1500, OutputTokens: 500})
+	sys.Usage.Record(store.UsageRecord{Nick: "bob", Channel: "#test", InputTokens: 500})
+	sys.Usage.Record(store.UsageRecord{Nick: "carol_", Identity: "$a:carol", Channel: "#test", InputTokens: 500})
+
+	cfg := mocktest.DefaultTestConfig()
+	cfg.Bot.DailyBudget = 10000
+	cfg.Bot.ChannelMonthlyBudget = 4000
+	cfg.Bot.UserDailyBudget = 2000
+	cfg.Session.ChunkMax = 400
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"current channel", []string{"/budget"}, []string{"daily token budget: 3.0k of 10.0k tokens (30%)", "monthly token budget for #test: 1.0k of 4.0k tokens (25%)"}},
+		{"other channel", []string{"/budget", "#dev"}, []string{"monthly token budget for #dev: 2.0k of 4.0k tokens (50%)"}},
+		{"user", []string{"/budget", "alice"}, []string{"daily token budget for alice: 2.0k of 2.0k tokens (100%)"}},
+		{"reset", []string{"/budget", "reset", "alice"}, []string{"Budgets for alice reset"}},
+		{"after reset", []string{"/budget", "alice"}, []string{"daily token budget for alice: 0 of 2.0k tokens (0%)"}},
+		{"known nick", []string{"/budget", "carol"}, []string{"daily token budget for $a:carol: 500 of 2.0k tokens (25%)"}},
+		{"reset needs a scope", []string{"/budget", "reset"}, []string{"Usage: /budget reset"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := mocktest.NewMockContext().
+				WithAdmin(true).
+				WithConfig(cfg).
+				WithSystem(sys).
+				WithArgs(tt.args...)
+			ctx.Users["carol"] = &core.UserInfo{Nick: "carol", Account: "carol"}
+
+			cmd := &BudgetCommand{}
+			cmd.Execute(ctx)
+
+			for _, want := range tt.want {
+				if !strings.Contains(ctx.LastReply(), want) {
+					t.Errorf("expected reply to contain %q, got: %s", want, ctx.LastReply())
+				}
+			}
+		})
+	}
+}
+
+func TestBudgetCommand_NoBudgets(t *testing.T) {
+	ctx := mocktest.NewMockContext().WithAdmin(true).WithSystem(mocktest.NewMockSystem()).WithArgs("/budget")
+	cmd := &BudgetCommand{}
+	cmd.Execute(ctx)
+	if ctx.LastReply() != "No budgets set" {
+		t.Errorf("unexpected reply: %s", ctx.LastReply())
+	}
+}
@@ -1,6 +1,9 @@
 package commands
 
 import (
+	"strings"
+
+	"pkdindustries/soulshack/internal/config"
 	"pkdindustries/soulshack/internal/irc"
 )
 
@@ -11,6 +14,15 @@ type Command interface {
 	AdminOnly() bool
 }
 
+// Subcommands is implemented by commands with subcommands that need more
+// than the command itself, e.g. /tools load. Subcommand returns the policy
+// entry for the one the args name, such as "/tools load", and the role it
+// needs without an entry for it or the command; an empty name means the
+// command's own role is enough.
+type Subcommands interface {
+	Subcommand(ctx irc.ChatContextInterface) (name string, fallback config.Role)
+}
+
 // Registry manages command registration and dispatch
 type Registry struct {
 	commands       map[string]Command
@@ -56,16 +68,49 @@ func (r *Registry) Dispatch(ctx irc.ChatContextInterface) bool {
 		return false
 	}
 
-	// Check admin permission
-	if cmd.AdminOnly() && !ctx.IsAdmin() {
-		ctx.Reply("You don't have permission to perform this action.")
+	if !r.Allowed(ctx, cmd) || !r.subcommandAllowed(ctx, cmd) {
+		ctx.Reply(permissionDenied)
+		if r.audited(ctx, cmd) {
+			auditCommand(ctx, cmd, outcomeDenied, "")
+		}
 		return true
 	}
 
-	cmd.Execute(ctx)
+	if !r.audited(ctx, cmd) {
+		cmd.Execute(ctx)
+		return true
+	}
+	replies := &auditReplies{ChatContextInterface: ctx, outcome: outcomeOK}
+	cmd.Execute(replies)
+	auditCommand(ctx, cmd, replies.outcome, strings.Join(replies.replies, " | "))
 	return true
 }
 
+// Allowed reports whether the sender's role may run cmd. The permissions
+// policy decides, falling back to owner for AdminOnly commands.
+func (r *Registry) Allowed(ctx irc.ChatContextInterface, cmd Command) bool {
+	fallback := config.RoleEveryone
+	if cmd.AdminOnly() {
+		fallback = config.RoleOwner
+	}
+	return ctx.GetRole() >= ctx.GetConfig().Permissions.Command(cmd.Name(), fallback)
+}
+
+// subcommandAllowed reports whether the sender's role may run the subcommand
+// the args name. Its policy entry decides, then the command's.
+func (r *Registry) subcommandAllowed(ctx irc.ChatContextInterface, cmd Command) bool {
+	s, ok := cmd.(Subcommands)
+	if !ok {
+		return true
+	}
+	name, fallback := s.Subcommand(ctx)
+	if name == "" {
+		return true
+	}
+	perms := ctx.GetConfig().Permissions
+	return ctx.GetRole() >= perms.Command(name, perms.Command(cmd.Name(), fallback))
+}
+
 // All returns all registered commands (excluding default)
 func (r *Registry) All() []Command {
 	cmds := make([]Command, 0, len(r.commands))
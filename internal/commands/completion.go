@@ -2,10 +2,17 @@ package commands
 
 import (
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
 
 	"pkdindustries/soulshack/internal/irc"
 	"pkdindustries/soulshack/internal/llm"
+	"pkdindustries/soulshack/internal/paste"
+
+	"github.com/alexschlessinger/pollytool/messages"
+	"github.com/alexschlessinger/pollytool/sessions"
+	"github.com/lrstanley/girc"
 )
 
 // CompletionCommand handles the default chat completion
@@ -15,17 +22,208 @@ func (c *CompletionCommand) Name() string    { return "" }
 func (c *CompletionCommand) AdminOnly() bool { return false }
 
 func (c *CompletionCommand) Execute(ctx irc.ChatContextInterface) {
-	msg := strings.Join(ctx.GetArgs(), " ")
+	msg := fmt.Sprintf("(nick:%s) %s", ctx.GetSource(), strings.Join(ctx.GetArgs(), " "))
+
+	// Lead with what was said in the channel since the bot was last addressed
+	channel, backlog := ctx.GetChannelName(), ctx.GetSystem().GetBacklog()
+	var lines []string
+	if channel != "" {
+		if lines = backlog.Take(channel, ctx.GetConfig().Bot.ContextTokens); len(lines) > 0 {
+			msg = fmt.Sprintf("Recent channel conversation:\n%s\n\n%s", strings.Join(lines, "\n"), msg)
+		}
+	}
 
-	outch, err := llm.Complete(ctx, fmt.Sprintf("(nick:%s) %s", ctx.GetSource(), msg))
+	// With multiline lines are held and sent as one message, either once the
+	// response is complete or as soon as they fill a batch, so a long
+	// response arrives a batch at a time. Otherwise each chunk is sent as it
+	// arrives.
+	send := ctx.Reply
+	var batch *batcher
+	if maxBytes, ok := ctx.Multiline(); ok {
+		batch = &batcher{ChatContextInterface: ctx, maxBytes: maxBytes}
+		ctx, send = batch, batch.add
+	}
+
+	outch, err := llm.Complete(ctx, msg)
 
 	if err != nil {
+		// The request was refused, so the channel lines are kept for the
+		// next one
+		backlog.Restore(channel, lines, ctx.GetConfig().Bot.ContextLines)
 		ctx.GetLogger().Error("completion_error", "error", err)
-		ctx.Reply(err.Error())
+		ctx.Reply(llm.ErrorReply(err))
 		return
 	}
 
+	over := newOverflow(ctx)
 	for res := range outch {
-		ctx.Reply(res)
+		if over == nil {
+			send(res)
+			continue
+		}
+		for _, line := range over.add(res) {
+			send(line)
+		}
+	}
+	if over != nil {
+		for _, line := range over.finish(ctx, responseText(ctx.GetSession(), msg)) {
+			send(line)
+		}
+	}
+	if batch != nil {
+		batch.flush()
+	}
+}
+
+// batcher holds the lines of a response to send as multiline messages. Any
+// other line sent to the same target during the request, such as a tool or
+// budget notice, sends the lines held first, so everything arrives in order.
+type batcher struct {
+	irc.ChatContextInterface
+	maxBytes int
+
+	mu    sync.Mutex
+	lines []string
+	size  int
+}
+
+// add holds a line, first sending those held if it would not fit with them
+func (b *batcher) add(line string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.lines) > 0 && b.size+len(line) > b.maxBytes {
+		b.send()
+	}
+	b.lines = append(b.lines, line)
+	b.size += len(line) + 1
+}
+
+// flush sends the lines held
+func (b *batcher) flush() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.send()
+}
+
+func (b *batcher) send() {
+	if len(b.lines) > 0 {
+		b.ChatContextInterface.ReplyMultiline(b.lines)
+		b.lines, b.size = nil, 0
+	}
+}
+
+func (b *batcher) Reply(message string) {
+	b.flush()
+	b.ChatContextInterface.Reply(message)
+}
+
+func (b *batcher) ReplyMultiline(lines []string) {
+	b.flush()
+	b.ChatContextInterface.ReplyMultiline(lines)
+}
+
+func (b *batcher) ReplyAction(message string) {
+	b.flush()
+	b.ChatContextInterface.ReplyAction(message)
+}
+
+func (b *batcher) SendAction(target, message string) {
+	if girc.ToRFC1459(target) == girc.ToRFC1459(b.GetLockKey()) {
+		b.flush()
+	}
+	b.ChatContextInterface.SendAction(target, message)
+}
+
+// overflow keeps long responses out of the channel: the first lines are sent
+// as they arrive, the rest held until the response is complete, then sent if
+// it stayed under the limits or pasted otherwise
+type overflow struct {
+	paster  paste.Paster
+	lines   int // limits, 0 = none
+	bytes   int
+	preview int
+	all     []string // every line of the response, as rendered for IRC
+	shown   int      // lines sent as the preview
+	sent    int      // bytes sent as the preview
+}
+
+// newOverflow returns the overflow for a request, or nil when there is no
+// paster or no limit
+func newOverflow(ctx irc.ChatContextInterface) *overflow {
+	cfg := ctx.GetConfig().Session
+	paster := ctx.GetSystem().GetPaster()
+	if paster == nil || cfg.PasteLines <= 0 && cfg.PasteBytes <= 0 {
+		return nil
+	}
+	preview := cfg.PastePreview
+	if cfg.PasteLines > 0 {
+		preview = min(preview, cfg.PasteLines)
+	}
+	return &overflow{paster: paster, lines: cfg.PasteLines, bytes: cfg.PasteBytes, preview: preview}
+}
+
+// add records a line, returning it if it is part of the preview. The preview
+// ends at its line count, or before it would pass the byte limit.
+func (o *overflow) add(line string) []string {
+	o.all = append(o.all, line)
+	if o.shown < len(o.all)-1 || o.shown >= o.preview || o.bytes > 0 && o.sent+len(line) > o.bytes {
+		return nil
+	}
+	o.shown++
+	o.sent += len(line) + 1
+	return []string{line}
+}
+
+// finish returns the lines held back, or a link to the whole response when
+// it is over a limit. The limits apply to the markdown the model wrote, which
+// is what gets pasted, so code keeps its fences and indentation; without it
+// the lines as sent are used. If pasting fails the held lines are sent after
+// all.
+func (o *overflow) finish(ctx irc.ChatContextInterface, raw string) []string {
+	held := o.all[o.shown:]
+	text := irc.StripFormatting(strings.Join(o.all, "\n"))
+	lines := len(o.all)
+	if raw != "" {
+		text = raw
+		lines = 0
+		for line := range strings.Lines(raw) {
+			if strings.TrimSpace(line) != "" {
+				lines++
+			}
+		}
+	}
+	over := o.lines > 0 && lines > o.lines || o.bytes > 0 && len(text) > o.bytes
+	if !over || len(held) == 0 {
+		return held
+	}
+
+	link, err := o.paster.Paste(ctx, text)
+	if err != nil {
+		ctx.GetLogger().Warn("paste_failed", "error", err)
+		return held
+	}
+	ctx.GetLogger().Info("response_pasted", "lines", lines, "bytes", len(text), "url", link)
+	return []string{"... full response: " + link}
+}
+
+// responseText returns the markdown the model answered request with, from
+// the messages added to the session once the response is complete. It is
+// empty if they were not added, as when the request failed.
+func responseText(session sessions.Session, request string) string {
+	history := session.GetHistory()
+	var parts []string
+	for i := len(history) - 1; i >= 0; i-- {
+		msg := history[i]
+		if msg.Role == messages.MessageRoleUser {
+			if msg.Content != request {
+				return ""
+			}
+			break
+		}
+		if msg.Role == messages.MessageRoleAssistant && strings.TrimSpace(msg.Content) != "" {
+			parts = append(parts, strings.TrimSpace(msg.Content))
+		}
 	}
+	slices.Reverse(parts)
+	return strings.Join(parts, "\n\n")
 }
@@ -1,10 +1,14 @@
 package commands
 
 import (
+	"context"
 	"errors"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	"pkdindustries/soulshack/internal/store"
 	mocktest "pkdindustries/soulshack/internal/testing"
 )
 
@@ -32,6 +36,52 @@ func TestCompletionCommand_BasicFlow(t *testing.T) {
 	}
 }
 
+func TestCompletionCommand_ChannelContext(t *testing.T) {
+	mockSys := mocktest.NewMockSystem()
+	mockSys.Backlog.Add("#test", "alice", "the build is red again", 10)
+
+	ctx := mocktest.NewMockContext().
+		WithSystem(mockSys).
+		WithSource("bob").
+		WithArgs("why?")
+	(&CompletionCommand{}).Execute(ctx)
+
+	history := ctx.GetSession().GetHistory()
+	if len(history) < 2 {
+		t.Fatal("expected the request in the session")
+	}
+	want := "Recent channel conversation:\n(nick:alice) the build is red again\n\n(nick:bob) why?"
+	if history[1].Content != want {
+		t.Errorf("expected channel context ahead of the request, got %q", history[1].Content)
+	}
+	if lines := mockSys.Backlog.Take("#test", 0); len(lines) != 0 {
+		t.Errorf("expected the backlog used up, got %v", lines)
+	}
+}
+
+func TestCompletionCommand_ChannelContextKeptWhenRefused(t *testing.T) {
+	mockSys := mocktest.NewMockSystem()
+	mockSys.Backlog.Add("#test", "alice", "the build is red again", 10)
+	mockSys.Usage.Record(store.UsageRecord{Nick: "other", InputTokens: 1000})
+	cfg := mocktest.DefaultTestConfig()
+	cfg.Bot.DailyBudget = 100
+	cfg.Bot.ContextLines = 10
+
+	ctx := mocktest.NewMockContext().
+		WithConfig(cfg).
+		WithSystem(mockSys).
+		WithSource("bob").
+		WithArgs("why?")
+	(&CompletionCommand{}).Execute(ctx)
+
+	if !strings.Contains(ctx.LastReply(), "budget") {
+		t.Fatalf("expected the request refused over budget, got %q", ctx.LastReply())
+	}
+	if lines := mockSys.Backlog.Take("#test", 0); len(lines) != 1 {
+		t.Errorf("expected the backlog kept for the next request, got %v", lines)
+	}
+}
+
 func TestCompletionCommand_MultiChunkResponse(t *testing.T) {
 	mockSys := mocktest.NewMockSystem()
 	mockSys.LLM = &mocktest.MockLLM{
@@ -60,6 +110,144 @@ func TestCompletionCommand_MultiChunkResponse(t *testing.T) {
 	}
 }
 
+func TestCompletionCommand_MultilineBatch(t *testing.T) {
+	mockSys := mocktest.NewMockSystem()
+	mockSys.LLM = &mocktest.MockLLM{
+		Responses: []string{"First chunk", "Second chunk", "Third chunk"},
+	}
+
+	ctx := mocktest.NewMockContext().
+		WithSystem(mockSys).
+		WithArgs("tell", "me", "a", "story")
+	ctx.MultilineEnabled = true
+
+	cmd := &CompletionCommand{}
+	cmd.Execute(ctx)
+
+	time.Sleep(50 * time.Millisecond)
+
+	if len(ctx.Batches) != 1 {
+		t.Fatalf("expected the response in one batch, got %v", ctx.Batches)
+	}
+	if got := strings.Join(ctx.Batches[0], ","); got != "First chunk,Second chunk,Third chunk" {
+		t.Errorf("unexpected batch: %q", got)
+	}
+}
+
+func TestCompletionCommand_MultilineSendsFullBatches(t *testing.T) {
+	mockSys := mocktest.NewMockSystem()
+	mockSys.LLM = &mocktest.MockLLM{
+		Responses: []string{"First chunk", "Second chunk", "Third chunk"},
+	}
+
+	ctx := mocktest.NewMockContext().
+		WithSystem(mockSys).
+		WithArgs("tell", "me", "a", "story")
+	ctx.MultilineEnabled = true
+	ctx.MultilineBytes = 24 // "First chunk\nSecond chunk" is 24 bytes
+
+	cmd := &CompletionCommand{}
+	cmd.Execute(ctx)
+
+	time.Sleep(50 * time.Millisecond)
+
+	if len(ctx.Batches) != 2 {
+		t.Fatalf("expected a batch sent once full, then the rest, got %v", ctx.Batches)
+	}
+	if got := strings.Join(ctx.Batches[0], ","); got != "First chunk,Second chunk" {
+		t.Errorf("unexpected first batch: %q", got)
+	}
+}
+
+func TestBatcher_SendsHeldLinesFirst(t *testing.T) {
+	ctx := mocktest.NewMockContext()
+	ctx.MultilineEnabled = true
+	b := &batcher{ChatContextInterface: ctx, maxBytes: 4096}
+
+	b.add("one")
+	b.add("two")
+	b.ReplyAction("calling web_search")
+	if len(ctx.Batches) != 1 || strings.Join(ctx.Batches[0], ",") != "one,two" {
+		t.Fatalf("expected the held lines sent before the action, got %v", ctx.Batches)
+	}
+
+	b.add("three")
+	b.Reply("done")
+	expected := []string{"one", "two", "three", "done"}
+	if !slices.Equal(ctx.Replies, expected) {
+		t.Errorf("expected replies %q, got %q", expected, ctx.Replies)
+	}
+
+	b.add("four")
+	b.SendAction("#elsewhere", "waves")
+	if len(ctx.Batches) != 2 {
+		t.Errorf("expected lines still held after an action to another target, got %v", ctx.Batches)
+	}
+}
+
+// fakePaster records pastes, failing with err when set
+type fakePaster struct {
+	pasted []string
+	err    error
+}
+
+func (p *fakePaster) Paste(ctx context.Context, text string) (string, error) {
+	if p.err != nil {
+		return "", p.err
+	}
+	p.pasted = append(p.pasted, text)
+	return "http://paste.test/1", nil
+}
+
+func TestCompletionCommand_Overflow(t *testing.T) {
+	long := []string{"one", "two", "**three**", "four", "five"}
+	code := []string{"```go", "func main() {", "    fmt.Println()", "}", "```"}
+	tests := []struct {
+		name      string
+		responses []string
+		lines     int
+		bytes     int
+		err       error
+		replies   []string
+		pasted    string
+	}{
+		{"under the limit", long[:3], 4, 0, nil, long[:3], ""},
+		{"over lines", long, 4, 0, nil, []string{"one", "two", "... full response: http://paste.test/1"}, "one\ntwo\n**three**\nfour\nfive"},
+		{"over bytes", long, 0, 20, nil, []string{"one", "two", "... full response: http://paste.test/1"}, "one\ntwo\n**three**\nfour\nfive"},
+		{"preview under bytes", long, 0, 6, nil, []string{"one", "... full response: http://paste.test/1"}, "one\ntwo\n**three**\nfour\nfive"},
+		{"code kept", code, 4, 0, nil, []string{"```go", "func main() {", "... full response: http://paste.test/1"}, strings.Join(code, "\n")},
+		{"paste fails", long, 4, 0, errors.New("unreachable"), long, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paster := &fakePaster{err: tt.err}
+			mockSys := mocktest.NewMockSystem()
+			mockSys.LLM = &mocktest.MockLLM{Responses: tt.responses}
+			mockSys.Paster = paster
+
+			ctx := mocktest.NewMockContext().
+				WithSystem(mockSys).
+				WithArgs("write", "me", "a", "lot")
+			ctx.GetConfig().Session.PasteLines = tt.lines
+			ctx.GetConfig().Session.PasteBytes = tt.bytes
+			ctx.GetConfig().Session.PastePreview = 2
+
+			cmd := &CompletionCommand{}
+			cmd.Execute(ctx)
+
+			if !slices.Equal(ctx.Replies, tt.replies) {
+				t.Errorf("expected replies %q, got %q", tt.replies, ctx.Replies)
+			}
+			if tt.pasted == "" && len(paster.pasted) > 0 {
+				t.Errorf("expected nothing pasted, got %q", paster.pasted)
+			}
+			if tt.pasted != "" && (len(paster.pasted) != 1 || paster.pasted[0] != tt.pasted) {
+				t.Errorf("expected %q pasted, got %q", tt.pasted, paster.pasted)
+			}
+		})
+	}
+}
+
 func TestCompletionCommand_ErrorHandling(t *testing.T) {
 	mockSys := mocktest.NewMockSystem()
 	mockSys.LLM = &mocktest.MockLLM{
@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"pkdindustries/soulshack/internal/irc"
+)
+
+// ConfigCommand handles the /config command for writing runtime changes
+// back to the config file
+type ConfigCommand struct{}
+
+func (c *ConfigCommand) Name() string    { return "/config" }
+func (c *ConfigCommand) AdminOnly() bool { return true }
+
+func (c *ConfigCommand) Execute(ctx irc.ChatContextInterface) {
+	args := ctx.GetArgs()
+	if len(args) < 2 {
+		ctx.Reply("Usage: /config <save|diff>")
+		return
+	}
+
+	cfg := ctx.GetConfig().Global()
+	switch args[1] {
+	case "diff":
+		changes, err := cfg.Diff()
+		if err != nil {
+			replyError(ctx, fmt.Sprintf("Failed: %v", err))
+			return
+		}
+		if len(changes) == 0 {
+			ctx.Reply(fmt.Sprintf("No changes from %s", cfg.Path))
+			return
+		}
+		lines := make([]string, 0, len(changes))
+		for _, change := range changes {
+			lines = append(lines, change.String())
+		}
+		ctx.Reply(fmt.Sprintf("Changes from %s: %s", cfg.Path, strings.Join(lines, "; ")))
+	case "save":
+		changes, err := cfg.Save()
+		if err != nil {
+			replyError(ctx, fmt.Sprintf("Failed to save: %v", err))
+			return
+		}
+		if len(changes) == 0 {
+			ctx.Reply(fmt.Sprintf("Nothing to save, %s is up to date", cfg.Path))
+			return
+		}
+		ctx.GetLogger().Info("config_saved", "path", cfg.Path, "changes", len(changes))
+		ctx.Reply(fmt.Sprintf("Saved %d changes to %s", len(changes), cfg.Path))
+	default:
+		ctx.Reply("Usage: /config <save|diff>")
+	}
+}
+
+// autosave writes runtime config changes to the config file when --autosave is on
+func autosave(ctx irc.ChatContextInterface) {
+	cfg := ctx.GetConfig().Snapshot()
+	if !cfg.Bot.AutoSave {
+		return
+	}
+	if _, err := cfg.Save(); err != nil {
+		ctx.GetLogger().Warn("config_autosave_failed", "path", cfg.Path, "error", err)
+		replyError(ctx, fmt.Sprintf("Failed to save config: %v", err))
+	}
+}
@@ -2,8 +2,11 @@ package commands
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 
+	"pkdindustries/soulshack/internal/config"
 	"pkdindustries/soulshack/internal/irc"
 )
 
@@ -14,14 +17,22 @@ func (c *GetCommand) Name() string    { return "/get" }
 func (c *GetCommand) AdminOnly() bool { return false }
 
 func (c *GetCommand) Execute(ctx irc.ChatContextInterface) {
-	keys := getConfigKeys()
-	if len(ctx.GetArgs()) < 2 {
-		ctx.Reply(fmt.Sprintf("Usage: /get <key>. Available keys: %s", strings.Join(keys, ", ")))
+	keys := config.Keys()
+	channel, args := splitChannelScope(ctx.GetArgs()[1:])
+	if len(args) < 1 {
+		if channel != "" {
+			c.listOverrides(ctx, channel)
+			return
+		}
+		ctx.Reply(fmt.Sprintf("Usage: /get [#channel] <key>. Available keys: %s", strings.Join(keys, ", ")))
 		return
 	}
 
-	param := ctx.GetArgs()[1]
+	param := args[0]
 	cfg := ctx.GetConfig()
+	if channel != "" {
+		cfg = cfg.ForChannel(channel)
+	}
 
 	// Handle special cases first
 	switch param {
@@ -36,11 +47,29 @@ func (c *GetCommand) Execute(ctx irc.ChatContextInterface) {
 	}
 
 	// Handle standard config fields
-	field, ok := configFields[param]
+	field, ok := config.Fields[param]
 	if !ok {
 		ctx.Reply(fmt.Sprintf("Unknown key %s. Available keys: %s", param, strings.Join(keys, ", ")))
 		return
 	}
 
-	ctx.Reply(fmt.Sprintf("%s: %s", param, field.getter(cfg)))
+	if channel != "" {
+		ctx.Reply(fmt.Sprintf("%s in %s: %s", param, channel, field.Display(cfg)))
+		return
+	}
+	ctx.Reply(fmt.Sprintf("%s: %s", param, field.Display(cfg)))
+}
+
+// listOverrides shows every override set for a channel
+func (c *GetCommand) listOverrides(ctx irc.ChatContextInterface, channel string) {
+	values := ctx.GetConfig().Global().Overrides.Get(channel)
+	if len(values) == 0 {
+		ctx.Reply(fmt.Sprintf("%s has no overrides", channel))
+		return
+	}
+	var pairs []string
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", key, values[key]))
+	}
+	ctx.Reply(fmt.Sprintf("%s overrides: %s", channel, strings.Join(pairs, ", ")))
 }
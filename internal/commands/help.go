@@ -22,10 +22,9 @@ func (c *HelpCommand) AdminOnly() bool { return false }
 func (c *HelpCommand) Execute(ctx irc.ChatContextInterface) {
 	cmds := c.registry.All()
 	var names []string
-	isAdmin := ctx.IsAdmin()
 
 	for _, cmd := range cmds {
-		if cmd.AdminOnly() && !isAdmin {
+		if !c.registry.Allowed(ctx, cmd) {
 			continue
 		}
 		if name := cmd.Name(); name != "" {
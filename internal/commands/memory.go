@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lrstanley/girc"
+
+	"pkdindustries/soulshack/internal/irc"
+	"pkdindustries/soulshack/internal/store"
+)
+
+// memoryListMax bounds how many memories /memory lists
+const memoryListMax = 10
+
+const memoryUsage = "Usage: /memory [list|search|forget|purge] <args>"
+
+// MemoryCommand handles the /memory command for inspecting and purging what
+// the memory__ tools remembered
+type MemoryCommand struct{}
+
+func (c *MemoryCommand) Name() string    { return "/memory" }
+func (c *MemoryCommand) AdminOnly() bool { return true }
+
+// Audited reports whether memories are being removed
+func (c *MemoryCommand) Audited(ctx irc.ChatContextInterface) bool {
+	args := ctx.GetArgs()
+	return len(args) > 1 && (args[1] == "forget" || args[1] == "purge")
+}
+
+func (c *MemoryCommand) Execute(ctx irc.ChatContextInterface) {
+	args := ctx.GetArgs()[1:]
+	memories := ctx.GetSystem().GetMemory()
+
+	subcommand := "list"
+	if len(args) > 0 {
+		subcommand, args = args[0], args[1:]
+	}
+
+	switch subcommand {
+	case "list":
+		target := ctx.GetChannelName()
+		if len(args) > 0 {
+			target = args[0]
+		}
+		var match func(store.Memory) bool
+		if target != "" {
+			match = memoryTarget(target)
+		}
+		c.reply(ctx, memories.Recent(memoryListMax, match), "No memories")
+	case "search":
+		if len(args) == 0 {
+			ctx.Reply("Usage: /memory search <query>")
+			return
+		}
+		query := strings.Join(args, " ")
+		c.reply(ctx, memories.Search(query, memoryListMax, nil), fmt.Sprintf("No memories match: %s", query))
+	case "forget":
+		if len(args) == 0 {
+			ctx.Reply("Usage: /memory forget <id>")
+			return
+		}
+		id, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+		if err != nil {
+			ctx.Reply("Usage: /memory forget <id>")
+			return
+		}
+		ok, err := memories.Forget(id, nil)
+		if err != nil {
+			ctx.GetLogger().Error("memory_forget_failed", "id", id, "error", err)
+			replyError(ctx, fmt.Sprintf("Failed to forget #%d: %v", id, err))
+			return
+		}
+		if !ok {
+			ctx.Reply(fmt.Sprintf("No memory #%d", id))
+			return
+		}
+		ctx.Reply(fmt.Sprintf("Forgot #%d", id))
+	case "purge":
+		if len(args) == 0 {
+			ctx.Reply("Usage: /memory purge <#channel|nick|$a:account>")
+			return
+		}
+		removed, err := memories.Purge(memoryTarget(args[0]))
+		if err != nil {
+			ctx.GetLogger().Error("memory_purge_failed", "target", args[0], "error", err)
+			replyError(ctx, fmt.Sprintf("Failed to purge memories of %s: %v", args[0], err))
+			return
+		}
+		ctx.GetLogger().Info("memory_purged", "target", args[0], "removed", removed)
+		ctx.Reply(fmt.Sprintf("Purged %d memories of %s", removed, args[0]))
+	default:
+		ctx.Reply(memoryUsage)
+	}
+}
+
+// reply lists memories one per line, or says empty when there are none
+func (c *MemoryCommand) reply(ctx irc.ChatContextInterface, found []store.Memory, empty string) {
+	if len(found) == 0 {
+		ctx.Reply(empty)
+		return
+	}
+	for _, m := range found {
+		ctx.Reply(truncateMessage(irc.FormatMemory(m), ctx.GetConfig().Session.ChunkMax))
+	}
+}
+
+// memoryTarget matches the memories of a channel, or of a user given by nick
+// or $a:account
+func memoryTarget(target string) func(store.Memory) bool {
+	if girc.IsValidChannel(target) {
+		channel := strings.ToLower(target)
+		return func(m store.Memory) bool {
+			return m.Scope == store.MemoryChannel && m.Owner == channel
+		}
+	}
+	owner := strings.ToLower(target)
+	return func(m store.Memory) bool {
+		return m.Scope == store.MemoryUser && (m.Owner == owner || strings.EqualFold(m.Nick, target))
+	}
+}
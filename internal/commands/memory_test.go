@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"pkdindustries/soulshack/internal/store"
+	mocktest "pkdindustries/soulshack/internal/testing"
+)
+
+func TestMemoryCommand(t *testing.T) {
+	sys := mocktest.NewMockSystem()
+	sys.Memory.Add(store.Memory{Scope: store.MemoryUser, Owner: "$a:alice", Nick: "alice", Text: "prefers tabs"})
+	sys.Memory.Add(store.Memory{Scope: store.MemoryChannel, Owner: "#test", Nick: "bob", Text: "releases ship on fridays"})
+	sys.Memory.Add(store.Memory{Scope: store.MemoryChannel, Owner: "#ops", Nick: "bob", Text: "pager rotation is weekly"})
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected []string
+	}{
+		{"current channel", []string{"/memory"}, []string{"#2 [#test] releases"}},
+		{"channel", []string{"/memory", "list", "#ops"}, []string{"#3 [#ops] pager"}},
+		{"user by nick", []string{"/memory", "list", "alice"}, []string{"#1 [alice] prefers tabs"}},
+		{"user by account", []string{"/memory", "list", "$a:alice"}, []string{"#1 [alice] prefers tabs"}},
+		{"search", []string{"/memory", "search", "weekly", "pager"}, []string{"#3 [#ops] pager"}},
+		{"no match", []string{"/memory", "search", "kubernetes"}, []string{"No memories match: kubernetes"}},
+		{"usage", []string{"/memory", "forget"}, []string{"Usage: /memory forget <id>"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := mocktest.NewMockContext().WithSystem(sys).WithAdmin(true).WithArgs(tt.args...)
+			(&MemoryCommand{}).Execute(ctx)
+			if len(ctx.Replies) != len(tt.expected) {
+				t.Fatalf("expected %d replies, got %v", len(tt.expected), ctx.Replies)
+			}
+			for i, want := range tt.expected {
+				if !strings.HasPrefix(ctx.Replies[i], want) {
+					t.Errorf("expected reply starting %q, got %q", want, ctx.Replies[i])
+				}
+			}
+		})
+	}
+
+	ctx := mocktest.NewMockContext().WithSystem(sys).WithAdmin(true).WithArgs("/memory", "forget", "#2")
+	(&MemoryCommand{}).Execute(ctx)
+	ctx = mocktest.NewMockContext().WithSystem(sys).WithAdmin(true).WithArgs("/memory", "purge", "alice")
+	(&MemoryCommand{}).Execute(ctx)
+	if ctx.Replies[0] != "Purged 1 memories of alice" {
+		t.Errorf("unexpected purge reply: %v", ctx.Replies)
+	}
+	if left := sys.Memory.Recent(10, nil); len(left) != 1 || left[0].ID != 3 {
+		t.Errorf("expected only #3 left, got %+v", left)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"pkdindustries/soulshack/internal/config"
 	"pkdindustries/soulshack/internal/irc"
 	mocktest "pkdindustries/soulshack/internal/testing"
 )
@@ -97,6 +98,52 @@ func TestRegistry_AdminOnlyEnforcement(t *testing.T) {
 	}
 }
 
+func TestRegistry_RolePolicy(t *testing.T) {
+	registry := NewRegistry()
+
+	setCmd := &mockCommand{name: "/set", adminOnly: true}
+	statsCmd := &mockCommand{name: "/stats"}
+	registry.Register(setCmd)
+	registry.Register(statsCmd)
+
+	cfg := mocktest.DefaultTestConfig()
+	cfg.Permissions = &config.Permissions{Commands: map[string]config.Role{
+		"/set":   config.RoleOperator,
+		"/stats": config.RoleTrusted,
+	}}
+
+	tests := []struct {
+		name string
+		cmd  *mockCommand
+		role config.Role
+		want bool
+	}{
+		{"operator runs lowered admin command", setCmd, config.RoleOperator, true},
+		{"trusted below operator", setCmd, config.RoleTrusted, false},
+		{"everyone below trusted", statsCmd, config.RoleEveryone, false},
+		{"owner runs everything", statsCmd, config.RoleOwner, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cmd.executed = false
+			ctx := mocktest.NewMockContext().
+				WithConfig(cfg).
+				WithRole(tt.role).
+				WithArgs(tt.cmd.name)
+
+			registry.Dispatch(ctx)
+
+			if tt.cmd.executed != tt.want {
+				t.Errorf("executed = %v, want %v", tt.cmd.executed, tt.want)
+			}
+			if !tt.want && !strings.Contains(ctx.LastReply(), "permission") {
+				t.Errorf("expected permission error, got: %s", ctx.LastReply())
+			}
+		})
+	}
+}
+
 func TestRegistry_DefaultCommand(t *testing.T) {
 	registry := NewRegistry()
 
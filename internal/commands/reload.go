@@ -0,0 +1,40 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"pkdindustries/soulshack/internal/config"
+	"pkdindustries/soulshack/internal/irc"
+)
+
+// ReloadCommand handles the /reload command, re-reading the config file
+type ReloadCommand struct {
+	// Reload applies the config file, returning the applied changes and
+	// the keys that need a restart
+	Reload func() ([]config.Change, []string, error)
+}
+
+func (c *ReloadCommand) Name() string    { return "/reload" }
+func (c *ReloadCommand) AdminOnly() bool { return true }
+
+func (c *ReloadCommand) Execute(ctx irc.ChatContextInterface) {
+	changes, restart, err := c.Reload()
+	if err != nil {
+		replyError(ctx, fmt.Sprintf("Reload failed: %v", err))
+		return
+	}
+
+	if len(changes) == 0 {
+		ctx.Reply("Reloaded, no changes")
+	} else {
+		lines := make([]string, 0, len(changes))
+		for _, change := range changes {
+			lines = append(lines, change.String())
+		}
+		ctx.Reply(fmt.Sprintf("Reloaded %d changes: %s", len(changes), strings.Join(lines, "; ")))
+	}
+	if len(restart) > 0 {
+		ctx.Reply(fmt.Sprintf("Restart needed to apply: %s", strings.Join(restart, ", ")))
+	}
+}
@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"pkdindustries/soulshack/internal/config"
+	mocktest "pkdindustries/soulshack/internal/testing"
+)
+
+func TestReloadCommand_ReportsChanges(t *testing.T) {
+	cmd := &ReloadCommand{Reload: func() ([]config.Change, []string, error) {
+		return []config.Change{
+			{Key: "model", Old: "ollama/a", New: "ollama/b"},
+			{Key: "openaikey", Old: "", New: "sk-abcdefgh", Secret: true},
+		}, []string{"server"}, nil
+	}}
+	ctx := mocktest.NewMockContext().WithAdmin(true).WithArgs("/reload")
+
+	cmd.Execute(ctx)
+
+	if ctx.ReplyCount() != 2 {
+		t.Fatalf("expected 2 replies, got %d: %v", ctx.ReplyCount(), ctx.Replies)
+	}
+	report := ctx.Replies[0]
+	if !strings.Contains(report, "model: ollama/a -> ollama/b") {
+		t.Errorf("expected model change in report, got: %s", report)
+	}
+	if strings.Contains(report, "sk-abcdefgh") {
+		t.Errorf("secret leaked in report: %s", report)
+	}
+	if !strings.Contains(ctx.LastReply(), "server") {
+		t.Errorf("expected restart notice, got: %s", ctx.LastReply())
+	}
+}
+
+func TestReloadCommand_Error(t *testing.T) {
+	cmd := &ReloadCommand{Reload: func() ([]config.Change, []string, error) {
+		return nil, nil, errors.New("no config file loaded")
+	}}
+	ctx := mocktest.NewMockContext().WithAdmin(true).WithArgs("/reload")
+
+	cmd.Execute(ctx)
+
+	if !strings.Contains(ctx.LastReply(), "Reload failed") {
+		t.Errorf("expected failure reply, got: %s", ctx.LastReply())
+	}
+}
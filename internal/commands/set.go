@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/lrstanley/girc"
+
+	"pkdindustries/soulshack/internal/config"
 	"pkdindustries/soulshack/internal/irc"
 )
 
@@ -14,39 +17,58 @@ func (c *SetCommand) Name() string    { return "/set" }
 func (c *SetCommand) AdminOnly() bool { return true }
 
 func (c *SetCommand) Execute(ctx irc.ChatContextInterface) {
-	keys := getConfigKeys()
-	if len(ctx.GetArgs()) < 3 {
-		ctx.Reply(fmt.Sprintf("Usage: /set <key> <value>. Available keys: %s", strings.Join(keys, ", ")))
+	keys := config.Keys()
+	channel, args := splitChannelScope(ctx.GetArgs()[1:])
+	if len(args) < 2 {
+		ctx.Reply(fmt.Sprintf("Usage: /set [#channel] <key> <value>. Available keys: %s", strings.Join(keys, ", ")))
 		return
 	}
 
-	param, v := ctx.GetArgs()[1], ctx.GetArgs()[2:]
-	value := strings.Join(v, " ")
-	cfg := ctx.GetConfig()
+	param, value := args[0], strings.Join(args[1:], " ")
+	cfg := ctx.GetConfig().Global()
 
-	ctx.GetLogger().Debug("config_change_requested", "param", param, "value", value)
+	ctx.GetLogger().Debug("config_change_requested", "param", param, "value", value, "channel", channel)
 
 	// Handle standard config fields
-	field, ok := configFields[param]
+	field, ok := config.Fields[param]
 	if !ok {
-		ctx.Reply(fmt.Sprintf("Unknown key. Available keys: %s", strings.Join(keys, ", ")))
+		replyError(ctx, fmt.Sprintf("Unknown key. Available keys: %s", strings.Join(keys, ", ")))
+		return
+	}
+
+	if !keyAllowed(ctx, param) {
+		return
+	}
+
+	if channel != "" {
+		c.setChannel(ctx, cfg, channel, param, value)
 		return
 	}
 
-	if err := field.setter(cfg, value); err != nil {
-		ctx.Reply(err.Error())
+	var err error
+	cfg.Update(func(cfg *config.Configuration) { err = field.Set(cfg, value) })
+	if err != nil {
+		replyError(ctx, err.Error())
 		return
 	}
+	cfg = cfg.Snapshot()
 
 	// If an API key or URL was set, update the LLM client
 	if strings.Contains(param, "key") || strings.Contains(param, "url") || strings.Contains(param, "model") {
 		if err := ctx.GetSystem().UpdateLLM(*cfg.API); err != nil {
 			ctx.GetLogger().Error("llm_update_failed", "error", err)
-			ctx.Reply("Configuration saved, but failed to update LLM client")
+			replyError(ctx, "Configuration saved, but failed to update LLM client")
 		}
 	}
 
-	ctx.Reply(fmt.Sprintf("%s set to: %s", param, field.getter(cfg)))
+	reply := fmt.Sprintf("%s set to: %s", param, field.Display(cfg))
+	if here := ctx.GetChannelName(); here != "" {
+		if override, ok := cfg.Overrides.Get(here)[param]; ok {
+			reply += fmt.Sprintf(" (%s overrides it with: %s)", here, override)
+		}
+	}
+	ctx.Reply(reply)
+	autosave(ctx)
 	ctx.GetSession().Clear()
 
 	// Update session store defaults if maxcontext was changed
@@ -56,3 +78,80 @@ func (c *SetCommand) Execute(ctx irc.ChatContextInterface) {
 		ctx.GetSession().SetMetadata(metadata)
 	}
 }
+
+// setChannel stores a per-channel override and resets that channel's session
+func (c *SetCommand) setChannel(ctx irc.ChatContextInterface, cfg *config.Configuration, channel, param, value string) {
+	if !config.Fields[param].Channel {
+		replyError(ctx, fmt.Sprintf("%s cannot be set per channel. Channel keys: %s", param, strings.Join(config.ChannelKeys(), ", ")))
+		return
+	}
+	if err := cfg.SetChannelOverride(channel, param, value); err != nil {
+		replyError(ctx, err.Error())
+		return
+	}
+
+	ctx.Reply(fmt.Sprintf("%s set to: %s in %s", param, config.Fields[param].Display(cfg.ForChannel(channel)), channel))
+	autosave(ctx)
+	clearChannelSession(ctx, channel)
+}
+
+// UnsetCommand handles the /unset command for removing per-channel overrides
+type UnsetCommand struct{}
+
+func (c *UnsetCommand) Name() string    { return "/unset" }
+func (c *UnsetCommand) AdminOnly() bool { return true }
+
+func (c *UnsetCommand) Execute(ctx irc.ChatContextInterface) {
+	channel, args := splitChannelScope(ctx.GetArgs()[1:])
+	if channel == "" {
+		channel = ctx.GetChannelName()
+	}
+	if len(args) < 1 || channel == "" {
+		ctx.Reply(fmt.Sprintf("Usage: /unset [#channel] <key>. Channel keys: %s", strings.Join(config.ChannelKeys(), ", ")))
+		return
+	}
+
+	param := args[0]
+	if !keyAllowed(ctx, param) {
+		return
+	}
+	cfg := ctx.GetConfig().Global()
+	if !cfg.Overrides.Unset(channel, param) {
+		ctx.Reply(fmt.Sprintf("%s has no override for %s", channel, param))
+		return
+	}
+
+	ctx.Reply(fmt.Sprintf("%s override removed in %s, now: %s", param, channel, config.Fields[param].Display(cfg.ForChannel(channel))))
+	autosave(ctx)
+	clearChannelSession(ctx, channel)
+}
+
+// keyAllowed checks the sender's role against the policy for a config key,
+// replying when it falls short
+func keyAllowed(ctx irc.ChatContextInterface, key string) bool {
+	if required := ctx.GetConfig().Permissions.Key(key); ctx.GetRole() < required {
+		replyDenied(ctx, fmt.Sprintf("Changing %s requires the %s role.", key, required))
+		return false
+	}
+	return true
+}
+
+// splitChannelScope strips a leading channel name from command arguments
+func splitChannelScope(args []string) (string, []string) {
+	if len(args) > 0 && girc.IsValidChannel(args[0]) {
+		return args[0], args[1:]
+	}
+	return "", args
+}
+
+// clearChannelSession resets the session of a channel after its config changed
+func clearChannelSession(ctx irc.ChatContextInterface, channel string) {
+	if strings.EqualFold(channel, ctx.GetChannelName()) {
+		ctx.GetSession().Clear()
+		return
+	}
+	store := ctx.GetSystem().GetSessionStore()
+	if store.Exists(channel) {
+		store.Delete(channel)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"pkdindustries/soulshack/internal/config"
 	mocktest "pkdindustries/soulshack/internal/testing"
 )
 
@@ -257,10 +258,11 @@ func TestSetCommand_ChunkMaxEdgeCases(t *testing.T) {
 		value string
 		want  int
 	}{
-		{"zero", "0", 0},
-		{"small", "10", 10},
-		{"normal", "350", 350},
-		{"negative", "-1", -1}, // Currently accepted (no validation)
+		{"zero", "0", 350}, // rejected, default kept
+		{"small", "10", 350},
+		{"minimum", "64", 64},
+		{"normal", "400", 400},
+		{"negative", "-1", 350},
 	}
 
 	for _, tt := range tests {
@@ -278,10 +280,116 @@ func TestSetCommand_ChunkMaxEdgeCases(t *testing.T) {
 				t.Fatalf("expected 1 reply, got %d", ctx.ReplyCount())
 			}
 
-			// Currently no bounds validation, so all values are accepted
 			if ctx.GetConfig().Session.ChunkMax != tt.want {
 				t.Errorf("expected chunkmax=%d, got=%d", tt.want, ctx.GetConfig().Session.ChunkMax)
 			}
 		})
 	}
 }
+
+func TestSetCommand_Format(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"strip", config.FormatStrip},
+		{"raw", config.FormatRaw},
+		{"html", config.FormatIRC}, // rejected
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			ctx := mocktest.NewMockContext().
+				WithAdmin(true).
+				WithSystem(mocktest.NewMockSystem()).
+				WithArgs("/set", "format", tt.value)
+
+			cmd := &SetCommand{}
+			cmd.Execute(ctx)
+
+			if got := ctx.GetConfig().Session.Format; got != tt.want {
+				t.Errorf("expected format=%s, got=%s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSetCommand_ChannelScope(t *testing.T) {
+	mockSys := mocktest.NewMockSystem()
+	ctx := mocktest.NewMockContext().
+		WithAdmin(true).
+		WithSystem(mockSys).
+		WithArgs("/set", "#dev", "model", "anthropic/terse")
+
+	cmd := &SetCommand{}
+	cmd.Execute(ctx)
+
+	if !strings.Contains(ctx.LastReply(), "in #dev") {
+		t.Errorf("expected channel confirmation, got: %s", ctx.LastReply())
+	}
+	cfg := ctx.GetConfig()
+	if cfg.Model.Model == "anthropic/terse" {
+		t.Error("channel override leaked into the global config")
+	}
+	if got := cfg.ForChannel("#DEV").Model.Model; got != "anthropic/terse" {
+		t.Errorf("expected #dev model anthropic/terse, got %s", got)
+	}
+}
+
+func TestSetCommand_ChannelScopeRejectsGlobalOnlyKey(t *testing.T) {
+	ctx := mocktest.NewMockContext().
+		WithAdmin(true).
+		WithArgs("/set", "#dev", "openaikey", "sk-secret")
+
+	cmd := &SetCommand{}
+	cmd.Execute(ctx)
+
+	if !strings.Contains(ctx.LastReply(), "cannot be set per channel") {
+		t.Errorf("expected rejection, got: %s", ctx.LastReply())
+	}
+	if len(ctx.GetConfig().Overrides.Channels()) != 0 {
+		t.Error("expected no overrides to be stored")
+	}
+}
+
+func TestSetCommand_KeyPolicy(t *testing.T) {
+	cfg := mocktest.DefaultTestConfig()
+	cfg.Permissions = &config.Permissions{Keys: map[string]config.Role{"openaikey": config.RoleOwner}}
+
+	ctx := mocktest.NewMockContext().
+		WithConfig(cfg).
+		WithRole(config.RoleOperator).
+		WithArgs("/set", "openaikey", "sk-secret")
+
+	cmd := &SetCommand{}
+	cmd.Execute(ctx)
+
+	if !strings.Contains(ctx.LastReply(), "requires the owner role") {
+		t.Errorf("expected key policy rejection, got: %s", ctx.LastReply())
+	}
+	if cfg.API.OpenAIKey != "" {
+		t.Error("expected openaikey to be unchanged")
+	}
+}
+
+func TestUnsetCommand_RemovesOverride(t *testing.T) {
+	mockSys := mocktest.NewMockSystem()
+	ctx := mocktest.NewMockContext().
+		WithAdmin(true).
+		WithSystem(mockSys).
+		WithArgs("/unset", "temperature")
+	cfg := ctx.GetConfig()
+	if err := cfg.SetChannelOverride("#test", "temperature", "0.1"); err != nil {
+		t.Fatalf("SetChannelOverride: %v", err)
+	}
+
+	cmd := &UnsetCommand{}
+	cmd.Execute(ctx)
+
+	if !strings.Contains(ctx.LastReply(), "override removed in #test") {
+		t.Errorf("expected removal confirmation, got: %s", ctx.LastReply())
+	}
+	if _, ok := cfg.Overrides.Get("#test")["temperature"]; ok || cfg.ForChannel("#test").Model.Temperature != cfg.Model.Temperature {
+		t.Error("expected #test to use the global config after unset")
+	}
+}
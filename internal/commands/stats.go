@@ -18,17 +18,79 @@ func (c *StatsCommand) Name() string    { return "/stats" }
 func (c *StatsCommand) AdminOnly() bool { return false }
 
 func (c *StatsCommand) Execute(ctx irc.ChatContextInterface) {
-	session := ctx.GetSession()
+	stats := GetSessionStats(ctx.GetSession())
+
+	// Calculate TTL information
+	ttlStr := "unlimited"
+	if stats.TTL > 0 {
+		if stats.ExpiresIn > 0 {
+			ttlStr = fmt.Sprintf("expires in %s", formatDuration(stats.ExpiresIn))
+		} else {
+			ttlStr = "expired"
+		}
+	}
+
+	// Format capacity
+	capacityStr := "unlimited"
+	if stats.MaxTokens > 0 {
+		capacityStr = fmt.Sprintf("%.1f%% of %d", stats.Capacity, stats.MaxTokens)
+	}
+
+	// Build response in simple format
+	response := fmt.Sprintf(
+		"token input: %d, "+
+			"token output: %d, "+
+			"context capacity: %s, "+
+			"messages: %d (user: %d, assistant: %d, tool: %d), "+
+			"participants: %d, "+
+			"ttl: %s",
+		stats.InputTokens,
+		stats.OutputTokens,
+		capacityStr,
+		stats.Messages,
+		stats.MessageCounts[string(messages.MessageRoleUser)],
+		stats.MessageCounts[string(messages.MessageRoleAssistant)],
+		stats.MessageCounts[string(messages.MessageRoleTool)],
+		stats.Participants,
+		ttlStr,
+	)
+
+	ctx.Reply(response)
+}
+
+// SessionStats summarizes a session's token use and history
+type SessionStats struct {
+	Name            string
+	InputTokens     int
+	OutputTokens    int
+	EstimatedTokens int     // tokens of messages without usage data
+	Capacity        float64 // percentage of MaxTokens used
+	MaxTokens       int     // 0 = unlimited
+	Messages        int
+	MessageCounts   map[string]int // by role
+	Participants    int
+	TTL             time.Duration // 0 = unlimited
+	ExpiresIn       time.Duration
+	LastUsed        time.Time
+}
+
+// GetSessionStats gathers the statistics shown by /stats
+func GetSessionStats(session sessions.Session) SessionStats {
 	history := session.GetHistory()
 	metadata := session.GetMetadata()
 
-	// Get capacity percentage using the interface method
-	percentage := session.GetCapacityPercentage()
-
-	// Calculate token breakdown
-	totalInputTokens := 0
-	totalOutputTokens := 0
-	totalEstimated := 0
+	stats := SessionStats{
+		Name:          session.GetName(),
+		Capacity:      session.GetCapacityPercentage(),
+		MaxTokens:     metadata.MaxHistoryTokens,
+		Messages:      len(history),
+		MessageCounts: session.GetMessageCounts(),
+		TTL:           metadata.TTL,
+		LastUsed:      session.GetLastUsed(),
+	}
+	if stats.TTL > 0 {
+		stats.ExpiresIn = session.GetTimeToExpiry()
+	}
 
 	// Track participants (IRC-specific)
 	participants := make(map[string]bool)
@@ -39,12 +101,11 @@ func (c *StatsCommand) Execute(ctx irc.ChatContextInterface) {
 		output := msg.GetOutputTokens()
 
 		if input > 0 || output > 0 {
-			totalInputTokens += input
-			totalOutputTokens += output
+			stats.InputTokens += input
+			stats.OutputTokens += output
 		} else {
 			// Using estimation fallback
-			estimated := sessions.EstimateTokens(msg)
-			totalEstimated += estimated
+			stats.EstimatedTokens += sessions.EstimateTokens(msg)
 		}
 
 		// Track participants from user messages
@@ -63,48 +124,9 @@ func (c *StatsCommand) Execute(ctx irc.ChatContextInterface) {
 			}
 		}
 	}
+	stats.Participants = len(participants)
 
-	// Get message counts and tool calls using new interface methods
-	messageCounts := session.GetMessageCounts()
-
-	// Calculate TTL information
-	ttlStr := "unlimited"
-	if metadata.TTL > 0 {
-		timeRemaining := session.GetTimeToExpiry()
-
-		if timeRemaining > 0 {
-			ttlStr = fmt.Sprintf("expires in %s", formatDuration(timeRemaining))
-		} else {
-			ttlStr = "expired"
-		}
-	}
-
-	// Format capacity
-	capacityStr := "unlimited"
-	if metadata.MaxHistoryTokens > 0 {
-		capacityStr = fmt.Sprintf("%.1f%% of %d", percentage, metadata.MaxHistoryTokens)
-	}
-
-	// Build response in simple format
-	response := fmt.Sprintf(
-		"token input: %d, "+
-			"token output: %d, "+
-			"context capacity: %s, "+
-			"messages: %d (user: %d, assistant: %d, tool: %d), "+
-			"participants: %d, "+
-			"ttl: %s",
-		totalInputTokens,
-		totalOutputTokens,
-		capacityStr,
-		len(history),
-		messageCounts[string(messages.MessageRoleUser)],
-		messageCounts[string(messages.MessageRoleAssistant)],
-		messageCounts[string(messages.MessageRoleTool)],
-		len(participants),
-		ttlStr,
-	)
-
-	ctx.Reply(response)
+	return stats
 }
 
 // formatDuration formats a duration into a human-readable string
@@ -5,16 +5,37 @@ import (
 	"path"
 	"strings"
 
+	"pkdindustries/soulshack/internal/config"
 	"pkdindustries/soulshack/internal/irc"
-
-	"github.com/alexschlessinger/pollytool/tools"
 )
 
 // ToolsCommand handles the /tools command for managing tools
 type ToolsCommand struct{}
 
 func (c *ToolsCommand) Name() string    { return "/tools" }
-func (c *ToolsCommand) AdminOnly() bool { return false } // We handle permissions internally
+func (c *ToolsCommand) AdminOnly() bool { return false }
+
+// Subcommand names the policy entries for load and remove, which need owner
+// without one
+func (c *ToolsCommand) Subcommand(ctx irc.ChatContextInterface) (string, config.Role) {
+	args := ctx.GetArgs()
+	if len(args) < 2 {
+		return "", config.RoleEveryone
+	}
+	switch args[1] {
+	case "load", "add":
+		return "/tools load", config.RoleOwner
+	case "rm", "remove":
+		return "/tools remove", config.RoleOwner
+	}
+	return "", config.RoleEveryone
+}
+
+// Audited reports whether a subcommand other than list was given
+func (c *ToolsCommand) Audited(ctx irc.ChatContextInterface) bool {
+	args := ctx.GetArgs()
+	return len(args) > 1 && args[1] != "list"
+}
 
 func (c *ToolsCommand) Execute(ctx irc.ChatContextInterface) {
 	args := ctx.GetArgs()
@@ -31,19 +52,9 @@ func (c *ToolsCommand) Execute(ctx irc.ChatContextInterface) {
 		rest = strings.Join(args[2:], " ")
 	}
 
-	// Handle non-admin subcommands first
-	if subcommand == "list" {
-		c.listNamespace(ctx, rest)
-		return
-	}
-
-	// Other subcommands require admin privileges
-	if !ctx.IsAdmin() {
-		ctx.Reply("You don't have permission to perform this action.")
-		return
-	}
-
 	switch subcommand {
+	case "list":
+		c.listNamespace(ctx, rest)
 	case "load":
 		fallthrough
 	case "add":
@@ -120,29 +131,19 @@ func (c *ToolsCommand) addTool(ctx irc.ChatContextInterface, toolPath string) {
 		return
 	}
 
-	registry := ctx.GetSystem().GetToolRegistry()
-	result, err := registry.LoadToolAuto(toolPath)
+	// Loading and removing go through the system, which keeps track of the
+	// tools each spec added for config reloads
+	names, err := ctx.GetSystem().LoadTool(toolPath)
 	if err != nil {
-		ctx.Reply(fmt.Sprintf("Failed: %v", err))
+		replyError(ctx, fmt.Sprintf("Failed: %v", err))
 		return
 	}
-
-	ctx.Reply(formatLoadResult(result))
-}
-
-// formatLoadResult creates a compact message for all loaded tools
-func formatLoadResult(result tools.LoadResult) string {
-	if len(result.Servers) == 0 {
-		return "No tools loaded"
+	if len(names) == 0 {
+		ctx.Reply("No tools loaded")
+		return
 	}
 
-	groups := make(map[string]int)
-	var order []string
-	for _, server := range result.Servers {
-		groups[server.Name] = len(server.ToolNames)
-		order = append(order, server.Name)
-	}
-	return fmt.Sprintf("Added: %s", formatGroupedSummary(groups, order))
+	ctx.Reply(fmt.Sprintf("Added: %s", formatToolList(names)))
 }
 
 func (c *ToolsCommand) removeTool(ctx irc.ChatContextInterface, pattern string) {
@@ -151,7 +152,8 @@ func (c *ToolsCommand) removeTool(ctx irc.ChatContextInterface, pattern string)
 		return
 	}
 
-	registry := ctx.GetSystem().GetToolRegistry()
+	sys := ctx.GetSystem()
+	registry := sys.GetToolRegistry()
 
 	// Check if this is a namespace removal (plain name or name__*)
 	isNamespaceRemoval := false
@@ -175,7 +177,7 @@ func (c *ToolsCommand) removeTool(ctx irc.ChatContextInterface, pattern string)
 			name := tool.GetName()
 			matched, _ := path.Match(pattern, name)
 			if matched {
-				registry.Remove(name)
+				sys.RemoveTool(name)
 				removed = append(removed, name)
 			}
 		}
@@ -194,7 +196,7 @@ func (c *ToolsCommand) removeTool(ctx irc.ChatContextInterface, pattern string)
 		if _, exists := registry.Get(pattern); !exists {
 			ctx.Reply(fmt.Sprintf("Not found: %s", pattern))
 		} else {
-			registry.Remove(pattern)
+			sys.RemoveTool(pattern)
 			_, bareName := parseToolName(pattern)
 			ctx.Reply(fmt.Sprintf("Removed: %s", bareName))
 		}
@@ -8,6 +8,7 @@ import (
 	"github.com/alexschlessinger/pollytool/schema"
 	"github.com/alexschlessinger/pollytool/tools"
 
+	"pkdindustries/soulshack/internal/config"
 	mocktest "pkdindustries/soulshack/internal/testing"
 )
 
@@ -58,14 +59,15 @@ func TestToolsCommand_ListTools(t *testing.T) {
 
 func TestToolsCommand_AddRequiresAdmin(t *testing.T) {
 	mockSys := mocktest.NewMockSystem()
+	registry := NewRegistry()
+	registry.Register(&ToolsCommand{})
 
 	ctx := mocktest.NewMockContext().
 		WithAdmin(false).
 		WithSystem(mockSys).
 		WithArgs("/tools", "add", "/some/path")
 
-	cmd := &ToolsCommand{}
-	cmd.Execute(ctx)
+	registry.Dispatch(ctx)
 
 	if ctx.ReplyCount() != 1 {
 		t.Fatalf("expected 1 reply, got %d", ctx.ReplyCount())
@@ -77,14 +79,15 @@ func TestToolsCommand_AddRequiresAdmin(t *testing.T) {
 
 func TestToolsCommand_RemoveRequiresAdmin(t *testing.T) {
 	mockSys := mocktest.NewMockSystem()
+	registry := NewRegistry()
+	registry.Register(&ToolsCommand{})
 
 	ctx := mocktest.NewMockContext().
 		WithAdmin(false).
 		WithSystem(mockSys).
 		WithArgs("/tools", "remove", "some_tool")
 
-	cmd := &ToolsCommand{}
-	cmd.Execute(ctx)
+	registry.Dispatch(ctx)
 
 	if ctx.ReplyCount() != 1 {
 		t.Fatalf("expected 1 reply, got %d", ctx.ReplyCount())
@@ -94,6 +97,69 @@ func TestToolsCommand_RemoveRequiresAdmin(t *testing.T) {
 	}
 }
 
+func TestToolsCommand_Permissions(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands map[string]config.Role
+		role     config.Role
+		args     []string
+		want     bool
+	}{
+		{"list open to everyone", nil, config.RoleEveryone, []string{"/tools", "list"}, true},
+		{"remove needs owner", nil, config.RoleOperator, []string{"/tools", "remove", "x"}, false},
+		{"owner removes", nil, config.RoleOwner, []string{"/tools", "rm", "x"}, true},
+		{"command entry covers subcommands", map[string]config.Role{"/tools": config.RoleOperator}, config.RoleOperator, []string{"/tools", "remove", "x"}, true},
+		{"command entry gates list", map[string]config.Role{"/tools": config.RoleOperator}, config.RoleTrusted, []string{"/tools", "list"}, false},
+		{"subcommand entry", map[string]config.Role{"/tools load": config.RoleTrusted}, config.RoleTrusted, []string{"/tools", "add", "x"}, true},
+		{"subcommand entry wins", map[string]config.Role{"/tools": config.RoleTrusted, "/tools remove": config.RoleOwner}, config.RoleOperator, []string{"/tools", "remove", "x"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry()
+			registry.Register(&ToolsCommand{})
+			cfg := mocktest.DefaultTestConfig()
+			cfg.Permissions = &config.Permissions{Commands: tt.commands}
+
+			ctx := mocktest.NewMockContext().
+				WithConfig(cfg).
+				WithRole(tt.role).
+				WithSystem(mocktest.NewMockSystem()).
+				WithArgs(tt.args...)
+			registry.Dispatch(ctx)
+
+			denied := strings.Contains(ctx.LastReply(), "permission")
+			if denied == tt.want {
+				t.Errorf("allowed = %v, want %v (reply %q)", !denied, tt.want, ctx.LastReply())
+			}
+		})
+	}
+}
+
+func TestToolsCommand_RemoveThroughSystem(t *testing.T) {
+	mockSys := mocktest.NewMockSystem()
+	for _, name := range []string{"native__one", "native__two"} {
+		mockSys.ToolRegistry.RegisterNative(name, func() tools.Tool {
+			return &mockTool{name: name}
+		})
+		mockSys.ToolRegistry.LoadToolAuto(name)
+	}
+
+	ctx := mocktest.NewMockContext().
+		WithAdmin(true).
+		WithSystem(mockSys).
+		WithArgs("/tools", "remove", "native")
+
+	cmd := &ToolsCommand{}
+	cmd.Execute(ctx)
+
+	if len(mockSys.RemovedTools) != 2 {
+		t.Errorf("expected both tools removed through the system, got %v", mockSys.RemovedTools)
+	}
+	if len(mockSys.ToolRegistry.All()) != 0 {
+		t.Errorf("expected no tools left, got %d", len(mockSys.ToolRegistry.All()))
+	}
+}
+
 // mockTool implements tools.Tool for testing
 type mockTool struct {
 	name string
@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+	"time"
+
+	"pkdindustries/soulshack/internal/irc"
+	"pkdindustries/soulshack/internal/store"
+)
+
+// usageTop is how many entries /usage lists per breakdown
+const usageTop = 5
+
+// UsageCommand handles the /usage command for reporting token usage and cost
+type UsageCommand struct{}
+
+func (c *UsageCommand) Name() string    { return "/usage" }
+func (c *UsageCommand) AdminOnly() bool { return true }
+
+func (c *UsageCommand) Execute(ctx irc.ChatContextInterface) {
+	args := ctx.GetArgs()[1:]
+	ledger := ctx.GetSystem().GetUsage()
+	now := time.Now()
+
+	if len(args) == 0 {
+		total := func(r store.UsageRecord) string { return "" }
+		today := ledger.Totals(store.DayStart(now), total)
+		month := ledger.Totals(store.MonthStart(now), total)
+		ctx.Reply(fmt.Sprintf("today: %s | this month: %s", formatUsage(today), formatUsage(month)))
+		return
+	}
+
+	keys := map[string]func(store.UsageRecord) string{
+		"users":    store.UsageRecord.User,
+		"channels": func(r store.UsageRecord) string { return cmp.Or(r.Channel, "(private)") },
+		"models":   func(r store.UsageRecord) string { return r.Model },
+	}
+	key, ok := keys[args[0]]
+	if !ok {
+		ctx.Reply("Usage: /usage [users|channels|models] [today|month]")
+		return
+	}
+
+	since, period := store.MonthStart(now), "this month"
+	if len(args) > 1 && args[1] == "today" {
+		since, period = store.DayStart(now), "today"
+	}
+	totals := ledger.Totals(since, key)
+	if len(totals) == 0 {
+		ctx.Reply(fmt.Sprintf("No usage %s", period))
+		return
+	}
+
+	var entries []string
+	for _, t := range totals[:min(len(totals), usageTop)] {
+		entries = append(entries, fmt.Sprintf("%s: %s", t.Key, formatUsage([]store.UsageTotal{t})))
+	}
+	message := fmt.Sprintf("top %s %s: %s", args[0], period, strings.Join(entries, " | "))
+	ctx.Reply(truncateMessage(message, ctx.GetConfig().Session.ChunkMax))
+}
+
+// formatUsage summarizes totals as "N requests, 12.3k tokens, $0.05"
+func formatUsage(totals []store.UsageTotal) string {
+	var sum store.UsageTotal
+	for _, t := range totals {
+		sum.Requests += t.Requests
+		sum.InputTokens += t.InputTokens
+		sum.OutputTokens += t.OutputTokens
+		sum.Cost += t.Cost
+	}
+	requests := "requests"
+	if sum.Requests == 1 {
+		requests = "request"
+	}
+	return fmt.Sprintf("%d %s, %s tokens, $%.2f", sum.Requests, requests, formatTokenCount(sum.Tokens()), sum.Cost)
+}
+
+// formatTokenCount shortens large token counts, e.g. 12345 -> 12.3k
+func formatTokenCount(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1e3)
+	}
+	return fmt.Sprintf("%d", n)
+}
@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"pkdindustries/soulshack/internal/store"
+	mocktest "pkdindustries/soulshack/internal/testing"
+)
+
+func TestUsageCommand(t *testing.T) {
+	sys := mocktest.NewMockSystem()
+	sys.Usage.Record(store.UsageRecord{Nick: "alice", Channel: "#dev", Model: "a/big", InputTokens: 1500, OutputTokens: 500, Cost: 0.25})
+	sys.Usage.Record(store.UsageRecord{Nick: "bob", Model: "a/small", InputTokens: 10, OutputTokens: 5})
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"summary", []string{"/usage"}, []string{"today: 2 requests, 2.0k tokens, $0.25", "this month: 2 requests"}},
+		{"top users", []string{"/usage", "users"}, []string{"top users this month: alice: 1 request, 2.0k tokens, $0.25 | bob: 1 request, 15 tokens"}},
+		{"channels today", []string{"/usage", "channels", "today"}, []string{"top channels today: #dev", "(private)"}},
+		{"unknown breakdown", []string{"/usage", "teams"}, []string{"Usage: /usage"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := mocktest.NewMockContext().
+				WithAdmin(true).
+				WithSystem(sys).
+				WithArgs(tt.args...)
+			ctx.GetConfig().Session.ChunkMax = 400
+
+			cmd := &UsageCommand{}
+			cmd.Execute(ctx)
+
+			for _, want := range tt.want {
+				if !strings.Contains(ctx.LastReply(), want) {
+					t.Errorf("expected reply to contain %q, got: %s", want, ctx.LastReply())
+				}
+			}
+		})
+	}
+}
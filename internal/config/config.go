@@ -3,8 +3,11 @@ package config
 import (
 	"fmt"
 	"log/slog"
+	"maps"
 	"os"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/urfave/cli/v3"
@@ -17,18 +20,42 @@ type Configuration struct {
 	Model   *ModelConfig
 	Session *SessionConfig
 	API     *APIConfig
+
+	// Overrides holds per-channel values layered over the global config
+	Overrides *ChannelOverrides
+	// Permissions holds role members and the role each action requires
+	Permissions *Permissions
+	// Prices maps model names or globs to their price per million tokens
+	Prices map[string]Price
+	// Path is the YAML file the config was loaded from, if any
+	Path   string
+	global *Configuration // set on effective configs returned by ForChannel
+	// mu guards the values of the global config, which requests read
+	// through snapshots while /set and reloads change them
+	mu sync.RWMutex
 }
 
 type ServerConfig struct {
 	Nick        string
 	Server      string
 	Port        int
-	Channel     string
-	ChannelKey  string
+	Channels    []ChannelConfig
 	SSL         bool
 	TLSInsecure bool
 	SASLNick    string
 	SASLPass    string
+	// Outbound flood control: SendBurst lines may go out at once, then one
+	// line per SendDelay, and at most one line per SendTargetDelay to each
+	// channel or nick
+	SendBurst       int
+	SendDelay       time.Duration
+	SendTargetDelay time.Duration
+}
+
+// ChannelConfig is a channel to join, with an optional key
+type ChannelConfig struct {
+	Name string
+	Key  string
 }
 
 type BotConfig struct {
@@ -42,11 +69,48 @@ type BotConfig struct {
 	OpWatcher          bool
 	OpWatcherTemplate  string
 	Tools              []string
+	AllowedTools       []string // tool name patterns offered to the model, empty = all
 	ShowThinkingAction bool
 	ShowToolActions    bool
 	URLWatcher       bool
 	URLWatcherSilent bool
 	Sandbox          bool
+	AutoSave           bool
+	// Rate limits, 0 = unlimited. RPM is requests per minute, TPH is
+	// tokens per hour.
+	UserRPM       int
+	UserTPH       int
+	ChannelRPM    int
+	ChannelTPH    int
+	UsageLog      string // JSONL file recording token usage, empty = memory only
+	AuditLog      string // JSONL file recording privileged actions, empty = memory only
+	TranscriptDir string // directory for channel transcripts, empty = disabled
+	MemoryFile    string // JSON file holding long-term memories, empty = memory only
+	// Token budgets, 0 = unlimited. Once one is used up, requests go to
+	// BudgetModel, or are refused when it is empty.
+	DailyBudget          int
+	MonthlyBudget        int
+	ChannelDailyBudget   int
+	ChannelMonthlyBudget int
+	UserDailyBudget      int
+	UserMonthlyBudget    int
+	BudgetModel          string
+	MetricsAddr          string // listen address for Prometheus /metrics, empty = disabled
+	AdminAddr            string // listen address for the HTTP admin API, empty = disabled
+	AdminToken           string // bearer token required by the admin API
+	OTLPEndpoint         string // OTLP/HTTP collector for traces, empty = disabled
+	// Where long responses are pasted: a pastebin-compatible PasteEndpoint
+	// taking uploads in PasteField, or else the built-in server on
+	// PasteAddr, linked under PasteURL. Both empty = disabled.
+	PasteAddr     string
+	PasteURL      string // default http://PasteAddr
+	PasteEndpoint string
+	PasteField    string
+	// Channel context: recent lines not addressed to the bot, added to the
+	// next request that is. 0 lines = disabled, 0 tokens = no token cap.
+	ContextLines  int
+	ContextTokens int
+	ContextIgnore []string // nicks, hostmasks or $a:accounts whose lines are left out
 }
 
 type ModelConfig struct {
@@ -58,10 +122,33 @@ type ModelConfig struct {
 	Stream         bool   // true = streaming (default), false = non-streaming
 }
 
+// ChunkMin is the smallest chunkmax that can be set, leaving room for a few
+// words per line
+const ChunkMin = 64
+
+// Response formats: how the markdown models write is sent to IRC
+const (
+	FormatIRC   = "irc"   // converted to mIRC formatting codes
+	FormatStrip = "strip" // converted to plain text
+	FormatRaw   = "raw"   // sent unchanged
+)
+
 type SessionConfig struct {
 	ChunkMax   int
+	Format     string // irc, strip, raw
+	// Responses over PasteLines lines or PasteBytes bytes are pasted, with
+	// only the first PastePreview lines sent. 0 = no limit.
+	PasteLines   int
+	PasteBytes   int
+	PastePreview int
 	MaxContext int
 	TTL        time.Duration
+	Store      string // memory, file
+	Dir        string // directory for the file store
+	// Compaction: at this capacity percentage the oldest history is
+	// summarized instead of trimmed, 0 = disabled
+	CompactAt    int
+	CompactModel string // model writing the summary, empty = the request's model
 }
 
 type APIConfig struct {
@@ -100,7 +187,11 @@ func (y *YamlSource) GoString() string { return "yaml" }
 
 func GetFlags() []cli.Flag {
 	// Pre-parse config path
-	configPath := getConfigPath()
+	return flagsFor(getConfigPath())
+}
+
+// flagsFor returns the CLI flags with YAML sources read from configPath
+func flagsFor(configPath string) []cli.Flag {
 	var configData map[string]any
 	if configPath != "" {
 		data, err := os.ReadFile(configPath)
@@ -133,10 +224,13 @@ func GetFlags() []cli.Flag {
 		&cli.BoolFlag{Name: "tls", Aliases: []string{"e"}, Usage: "enable TLS for the IRC connection", Sources: src("tls", "SOULSHACK_TLS")},
 		&cli.BoolFlag{Name: "tlsinsecure", Usage: "skip TLS certificate verification", Sources: src("tlsinsecure", "SOULSHACK_TLSINSECURE")},
 		&cli.IntFlag{Name: "port", Aliases: []string{"p"}, Value: 6667, Usage: "irc server port", Sources: src("port", "SOULSHACK_PORT")},
-		&cli.StringFlag{Name: "channel", Aliases: []string{"c"}, Usage: "irc channel to join", Sources: src("channel", "SOULSHACK_CHANNEL")},
-		&cli.StringFlag{Name: "channelkey", Usage: "channel key (password) for joining", Sources: src("channelkey", "SOULSHACK_CHANNELKEY")},
+		&cli.StringSliceFlag{Name: "channel", Aliases: []string{"c"}, Usage: "irc channels to join (repeatable or comma-separated, use '#chan key' for keyed channels)", Sources: src("channel", "SOULSHACK_CHANNEL")},
+		&cli.StringFlag{Name: "channelkey", Usage: "default channel key (password) for channels listed without one", Sources: src("channelkey", "SOULSHACK_CHANNELKEY")},
 		&cli.StringFlag{Name: "saslnick", Usage: "nick used for SASL", Sources: src("saslnick", "SOULSHACK_SASLNICK")},
 		&cli.StringFlag{Name: "saslpass", Usage: "password for SASL plain", Sources: src("saslpass", "SOULSHACK_SASLPASS")},
+		&cli.IntFlag{Name: "sendburst", Value: 5, Usage: "lines sent at once before outbound pacing starts", Sources: src("sendburst", "SOULSHACK_SENDBURST")},
+		&cli.DurationFlag{Name: "senddelay", Value: time.Second, Usage: "delay between outbound lines once the burst is used up (0 = no pacing)", Sources: src("senddelay", "SOULSHACK_SENDDELAY")},
+		&cli.DurationFlag{Name: "sendtargetdelay", Value: 250 * time.Millisecond, Usage: "minimum delay between outbound lines to the same channel or nick", Sources: src("sendtargetdelay", "SOULSHACK_SENDTARGETDELAY")},
 
 		// Bot Configuration
 		&cli.StringSliceFlag{Name: "admins", Aliases: []string{"A"}, Usage: "comma-separated list of allowed hostmasks to administrate the bot", Sources: src("admins", "SOULSHACK_ADMINS")},
@@ -159,17 +253,53 @@ func GetFlags() []cli.Flag {
 		&cli.StringFlag{Name: "thinkingeffort", Value: "off", Usage: "thinking effort level: off, low, medium, high", Sources: src("thinkingeffort", "SOULSHACK_THINKINGEFFORT")},
 		&cli.BoolFlag{Name: "stream", Value: true, Usage: "enable streaming responses", Sources: src("stream", "SOULSHACK_STREAM")},
 		&cli.StringSliceFlag{Name: "tool", Usage: "tools to load (shell scripts, MCP server JSON files, or native tools like irc__op)", Sources: src("tool", "SOULSHACK_TOOL")},
+		&cli.StringSliceFlag{Name: "allowedtools", Usage: "tool name patterns offered to the model, e.g. 'irc__*' (default: all loaded tools)", Sources: src("allowedtools", "SOULSHACK_ALLOWEDTOOLS")},
 		&cli.BoolFlag{Name: "showthinkingaction", Value: true, Usage: "show '[thinking]' IRC action when bot is reasoning", Sources: src("showthinkingaction", "SOULSHACK_SHOWTHINKINGACTION")},
 		&cli.BoolFlag{Name: "showtoolactions", Value: true, Usage: "show '[calling toolname]' IRC actions when executing tools", Sources: src("showtoolactions", "SOULSHACK_SHOWTOOLACTIONS")},
 		&cli.BoolFlag{Name: "urlwatcher", Usage: "enable passive URL watching and analysis", Sources: src("urlwatcher", "SOULSHACK_URLWATCHER")},
 		&cli.BoolFlag{Name: "urlwatchersilent", Usage: "run URL watcher without sending a reply in chat; response is discarded", Sources: src("urlwatchersilent", "SOULSHACK_URLWATCHERSILENT")},
+		&cli.BoolFlag{Name: "autosave", Usage: "write runtime /set changes back to the --config file", Sources: src("autosave", "SOULSHACK_AUTOSAVE")},
 		&cli.BoolFlag{Name: "sandbox", Usage: "run shell/bash/MCP tools inside a platform sandbox (macOS sandbox-exec, Linux bubblewrap)", Sources: src("sandbox", "SOULSHACK_SANDBOX")},
 
 		// Timeouts and Behavior
 		&cli.BoolFlag{Name: "addressed", Aliases: []string{"a"}, Value: true, Usage: "require bot be addressed by nick for response", Sources: src("addressed", "SOULSHACK_ADDRESSED")},
 		&cli.DurationFlag{Name: "sessionduration", Aliases: []string{"S"}, Value: time.Minute * 10, Usage: "message context will be cleared after it is unused for this duration", Sources: src("sessionduration", "SOULSHACK_SESSIONDURATION")},
 		&cli.IntFlag{Name: "maxcontext", Value: 0, Usage: "maximum token count for session history (0 = unlimited)", Sources: src("maxcontext", "SOULSHACK_MAXCONTEXT")},
+		&cli.IntFlag{Name: "compactat", Value: 0, Usage: "session capacity percentage at which older history is summarized instead of trimmed (0 = disabled)", Sources: src("compactat", "SOULSHACK_COMPACTAT")},
+		&cli.StringFlag{Name: "compactmodel", Usage: "model that writes compaction summaries (default: the model answering)", Sources: src("compactmodel", "SOULSHACK_COMPACTMODEL")},
+		&cli.StringFlag{Name: "sessionstore", Value: "memory", Usage: "session storage: memory (lost on restart), file (saved to --sessiondir)", Sources: src("sessionstore", "SOULSHACK_SESSIONSTORE")},
+		&cli.StringFlag{Name: "sessiondir", Value: "sessions", Usage: "directory for saved sessions when --sessionstore=file", Sources: src("sessiondir", "SOULSHACK_SESSIONDIR")},
+		&cli.IntFlag{Name: "userrpm", Usage: "LLM requests per minute allowed per user (0 = unlimited, admins are exempt)", Sources: src("userrpm", "SOULSHACK_USERRPM")},
+		&cli.IntFlag{Name: "usertph", Usage: "LLM tokens per hour allowed per user (0 = unlimited, admins are exempt)", Sources: src("usertph", "SOULSHACK_USERTPH")},
+		&cli.IntFlag{Name: "channelrpm", Usage: "LLM requests per minute allowed per channel (0 = unlimited)", Sources: src("channelrpm", "SOULSHACK_CHANNELRPM")},
+		&cli.IntFlag{Name: "channeltph", Usage: "LLM tokens per hour allowed per channel (0 = unlimited)", Sources: src("channeltph", "SOULSHACK_CHANNELTPH")},
+		&cli.StringFlag{Name: "usagelog", Usage: "JSONL file where token usage is recorded for /usage (default: memory only)", Sources: src("usagelog", "SOULSHACK_USAGELOG")},
+		&cli.StringFlag{Name: "auditlog", Usage: "JSONL file where admin commands and privileged tool calls are recorded for /audit (default: memory only)", Sources: src("auditlog", "SOULSHACK_AUDITLOG")},
+		&cli.StringFlag{Name: "transcriptdir", Usage: "Directory where channel transcripts are written, one file per channel and day (default: disabled)", Sources: src("transcriptdir", "SOULSHACK_TRANSCRIPTDIR")},
+		&cli.StringFlag{Name: "memoryfile", Usage: "JSON file where the memory__ tools keep what they remember (default: memory only)", Sources: src("memoryfile", "SOULSHACK_MEMORYFILE")},
+		&cli.IntFlag{Name: "dailybudget", Usage: "LLM tokens allowed per day across all channels (0 = unlimited)", Sources: src("dailybudget", "SOULSHACK_DAILYBUDGET")},
+		&cli.IntFlag{Name: "monthlybudget", Usage: "LLM tokens allowed per month across all channels (0 = unlimited)", Sources: src("monthlybudget", "SOULSHACK_MONTHLYBUDGET")},
+		&cli.IntFlag{Name: "channeldailybudget", Usage: "LLM tokens allowed per day in each channel (0 = unlimited)", Sources: src("channeldailybudget", "SOULSHACK_CHANNELDAILYBUDGET")},
+		&cli.IntFlag{Name: "channelmonthlybudget", Usage: "LLM tokens allowed per month in each channel (0 = unlimited)", Sources: src("channelmonthlybudget", "SOULSHACK_CHANNELMONTHLYBUDGET")},
+		&cli.IntFlag{Name: "userdailybudget", Usage: "LLM tokens allowed per day for each user (0 = unlimited)", Sources: src("userdailybudget", "SOULSHACK_USERDAILYBUDGET")},
+		&cli.IntFlag{Name: "usermonthlybudget", Usage: "LLM tokens allowed per month for each user (0 = unlimited)", Sources: src("usermonthlybudget", "SOULSHACK_USERMONTHLYBUDGET")},
+		&cli.StringFlag{Name: "adminaddr", Usage: "listen address for the HTTP admin API, e.g. 127.0.0.1:8080 (default: disabled)", Sources: src("adminaddr", "SOULSHACK_ADMINADDR")},
+		&cli.StringFlag{Name: "admintoken", Usage: "bearer token required by the HTTP admin API", Sources: src("admintoken", "SOULSHACK_ADMINTOKEN")},
+		&cli.StringFlag{Name: "otlpendpoint", Usage: "OTLP/HTTP collector to export traces to, e.g. http://localhost:4318 (default: disabled)", Sources: src("otlpendpoint", "SOULSHACK_OTLPENDPOINT")},
+		&cli.StringFlag{Name: "metricsaddr", Usage: "listen address for Prometheus metrics at /metrics, e.g. 127.0.0.1:9090 (default: disabled)", Sources: src("metricsaddr", "SOULSHACK_METRICSADDR")},
+		&cli.IntFlag{Name: "contextlines", Usage: "recent channel lines not addressed to the bot to include when it is addressed (0 = disabled)", Sources: src("contextlines", "SOULSHACK_CONTEXTLINES")},
+		&cli.IntFlag{Name: "contexttokens", Usage: "cap on the tokens of channel context included (0 = no cap)", Sources: src("contexttokens", "SOULSHACK_CONTEXTTOKENS")},
+		&cli.StringSliceFlag{Name: "contextignore", Usage: "nicks, hostmasks or $a:accounts left out of channel context, e.g. other bots", Sources: src("contextignore", "SOULSHACK_CONTEXTIGNORE")},
+		&cli.StringFlag{Name: "budgetmodel", Usage: "cheaper model to fall back to once a budget is used up (default: refuse requests)", Sources: src("budgetmodel", "SOULSHACK_BUDGETMODEL")},
 		&cli.IntFlag{Name: "chunkmax", Aliases: []string{"m"}, Value: 350, Usage: "maximum number of characters to send as a single message", Sources: src("chunkmax", "SOULSHACK_CHUNKMAX")},
+		&cli.StringFlag{Name: "pasteaddr", Usage: "listen address for the built-in paste server for long responses, e.g. 127.0.0.1:8081 (default: disabled)", Sources: src("pasteaddr", "SOULSHACK_PASTEADDR")},
+		&cli.StringFlag{Name: "pasteurl", Usage: "public base URL of the built-in paste server (default: http://<pasteaddr>)", Sources: src("pasteurl", "SOULSHACK_PASTEURL")},
+		&cli.StringFlag{Name: "pasteendpoint", Usage: "pastebin-compatible URL to upload long responses to instead, e.g. https://0x0.st", Sources: src("pasteendpoint", "SOULSHACK_PASTEENDPOINT")},
+		&cli.StringFlag{Name: "pastefield", Value: "file", Usage: "form field the paste endpoint takes the upload in", Sources: src("pastefield", "SOULSHACK_PASTEFIELD")},
+		&cli.IntFlag{Name: "pastelines", Value: 10, Usage: "paste responses longer than this many lines (0 = no limit)", Sources: src("pastelines", "SOULSHACK_PASTELINES")},
+		&cli.IntFlag{Name: "pastebytes", Usage: "paste responses longer than this many bytes (0 = no limit)", Sources: src("pastebytes", "SOULSHACK_PASTEBYTES")},
+		&cli.IntFlag{Name: "pastepreview", Value: 3, Usage: "lines of a pasted response sent to the channel before the link", Sources: src("pastepreview", "SOULSHACK_PASTEPREVIEW")},
+		&cli.StringFlag{Name: "format", Value: FormatIRC, Usage: "how markdown in responses is sent: irc (mIRC formatting codes), strip (plain text), raw (unchanged)", Sources: src("format", "SOULSHACK_FORMAT")},
 
 		// Personality / Prompting
 		&cli.StringFlag{Name: "greeting", Value: "hello.", Usage: "prompt to be used when the bot joins the channel", Sources: src("greeting", "SOULSHACK_GREETING")},
@@ -199,6 +329,40 @@ func getConfigPath() string {
 	return ""
 }
 
+// ParseChannels turns channel specs of the form "#chan" or "#chan key" into
+// channel configs. Channels without an inline key get defaultKey.
+// Duplicate channels (compared case-insensitively) are dropped.
+func ParseChannels(specs []string, defaultKey string) []ChannelConfig {
+	var channels []ChannelConfig
+	seen := make(map[string]bool)
+	for _, spec := range specs {
+		parts := strings.Fields(spec)
+		if len(parts) == 0 {
+			continue
+		}
+		ch := ChannelConfig{Name: parts[0], Key: defaultKey}
+		if len(parts) > 1 {
+			ch.Key = parts[1]
+		}
+		folded := strings.ToLower(ch.Name)
+		if seen[folded] {
+			continue
+		}
+		seen[folded] = true
+		channels = append(channels, ch)
+	}
+	return channels
+}
+
+// ChannelNames returns the names of all configured channels
+func (s *ServerConfig) ChannelNames() []string {
+	names := make([]string, 0, len(s.Channels))
+	for _, ch := range s.Channels {
+		names = append(names, ch.Name)
+	}
+	return names
+}
+
 func (c *Configuration) PrintConfig() {
 	mask := func(key string) string {
 		if key == "" || len(key) <= 3 {
@@ -211,8 +375,7 @@ func (c *Configuration) PrintConfig() {
 		{"nick", c.Server.Nick},
 		{"server", c.Server.Server},
 		{"port", fmt.Sprintf("%d", c.Server.Port)},
-		{"channel", c.Server.Channel},
-		{"channelkey", mask(c.Server.ChannelKey)},
+		{"channel", strings.Join(c.Server.ChannelNames(), ",")},
 		{"tls", fmt.Sprintf("%t", c.Server.SSL)},
 		{"tlsinsecure", fmt.Sprintf("%t", c.Server.TLSInsecure)},
 		{"saslnick", c.Server.SASLNick},
@@ -221,16 +384,24 @@ func (c *Configuration) PrintConfig() {
 		{"verbose", fmt.Sprintf("%t", c.Bot.Verbose)},
 		{"addressed", fmt.Sprintf("%t", c.Bot.Addressed)},
 		{"chunkmax", fmt.Sprintf("%d", c.Session.ChunkMax)},
+		{"format", c.Session.Format},
+		{"pastelines", fmt.Sprintf("%d", c.Session.PasteLines)},
+		{"pastebytes", fmt.Sprintf("%d", c.Session.PasteBytes)},
+		{"pastepreview", fmt.Sprintf("%d", c.Session.PastePreview)},
 		{"clienttimeout", c.API.Timeout.String()},
 		{"maxcontext", fmt.Sprintf("%d", c.Session.MaxContext)},
 		{"maxtokens", fmt.Sprintf("%d", c.Model.MaxTokens)},
 		{"tool", fmt.Sprintf("%v", c.Bot.Tools)},
+		{"allowedtools", fmt.Sprintf("%v", c.Bot.AllowedTools)},
 		{"showthinkingaction", fmt.Sprintf("%t", c.Bot.ShowThinkingAction)},
 		{"showtoolactions", fmt.Sprintf("%t", c.Bot.ShowToolActions)},
 		{"urlwatcher", fmt.Sprintf("%t", c.Bot.URLWatcher)},
 		{"urlwatchersilent", fmt.Sprintf("%t", c.Bot.URLWatcherSilent)},
 		{"sandbox", fmt.Sprintf("%t", c.Bot.Sandbox)},
+		{"autosave", fmt.Sprintf("%t", c.Bot.AutoSave)},
 		{"sessionduration", c.Session.TTL.String()},
+		{"sessionstore", c.Session.Store},
+		{"sessiondir", c.Session.Dir},
 		{"openaikey", mask(c.API.OpenAIKey)},
 		{"anthropickey", mask(c.API.AnthropicKey)},
 		{"geminikey", mask(c.API.GeminiKey)},
@@ -250,6 +421,13 @@ func (c *Configuration) PrintConfig() {
 	for _, f := range fields {
 		fmt.Printf("%s: %s\n", f.name, f.value)
 	}
+
+	for _, channel := range c.Overrides.Channels() {
+		values := c.Overrides.Get(channel)
+		for _, key := range slices.Sorted(maps.Keys(values)) {
+			fmt.Printf("%s %s: %s\n", channel, key, values[key])
+		}
+	}
 }
 
 func NewConfiguration(c *cli.Command) *Configuration {
@@ -262,12 +440,15 @@ func NewConfiguration(c *cli.Command) *Configuration {
 			Nick:        c.String("nick"),
 			Server:      c.String("server"),
 			Port:        c.Int("port"),
-			Channel:     c.String("channel"),
-			ChannelKey:  c.String("channelkey"),
+			Channels:    ParseChannels(c.StringSlice("channel"), c.String("channelkey")),
 			SSL:         c.Bool("tls"),
 			TLSInsecure: c.Bool("tlsinsecure"),
 			SASLNick:    c.String("saslnick"),
 			SASLPass:    c.String("saslpass"),
+
+			SendBurst:       c.Int("sendburst"),
+			SendDelay:       c.Duration("senddelay"),
+			SendTargetDelay: c.Duration("sendtargetdelay"),
 		},
 		Bot: &BotConfig{
 			Admins:             c.StringSlice("admins"),
@@ -280,11 +461,39 @@ func NewConfiguration(c *cli.Command) *Configuration {
 			OpWatcher:          c.Bool("opwatcher"),
 			OpWatcherTemplate:  c.String("opwatchertemplate"),
 			Tools:              c.StringSlice("tool"),
+			AllowedTools:       c.StringSlice("allowedtools"),
 			ShowThinkingAction: c.Bool("showthinkingaction"),
 			ShowToolActions:    c.Bool("showtoolactions"),
 			URLWatcher:       c.Bool("urlwatcher"),
 			URLWatcherSilent: c.Bool("urlwatchersilent"),
 			Sandbox:          c.Bool("sandbox"),
+			AutoSave:           c.Bool("autosave"),
+			UserRPM:            c.Int("userrpm"),
+			UserTPH:            c.Int("usertph"),
+			ChannelRPM:         c.Int("channelrpm"),
+			ChannelTPH:         c.Int("channeltph"),
+			UsageLog:           c.String("usagelog"),
+			AuditLog:           c.String("auditlog"),
+			TranscriptDir:      c.String("transcriptdir"),
+			MemoryFile:         c.String("memoryfile"),
+			DailyBudget:          c.Int("dailybudget"),
+			MonthlyBudget:        c.Int("monthlybudget"),
+			ChannelDailyBudget:   c.Int("channeldailybudget"),
+			ChannelMonthlyBudget: c.Int("channelmonthlybudget"),
+			UserDailyBudget:      c.Int("userdailybudget"),
+			UserMonthlyBudget:    c.Int("usermonthlybudget"),
+			BudgetModel:          c.String("budgetmodel"),
+			ContextLines:         c.Int("contextlines"),
+			ContextTokens:        c.Int("contexttokens"),
+			ContextIgnore:        c.StringSlice("contextignore"),
+			MetricsAddr:          c.String("metricsaddr"),
+			AdminAddr:            c.String("adminaddr"),
+			AdminToken:           c.String("admintoken"),
+			OTLPEndpoint:         c.String("otlpendpoint"),
+			PasteAddr:            c.String("pasteaddr"),
+			PasteURL:             c.String("pasteurl"),
+			PasteEndpoint:        c.String("pasteendpoint"),
+			PasteField:           c.String("pastefield"),
 		},
 		Model: &ModelConfig{
 			Model:          c.String("model"),
@@ -296,9 +505,17 @@ func NewConfiguration(c *cli.Command) *Configuration {
 		},
 
 		Session: &SessionConfig{
-			ChunkMax:   c.Int("chunkmax"),
-			MaxContext: c.Int("maxcontext"),
-			TTL:        c.Duration("sessionduration"),
+			ChunkMax:     c.Int("chunkmax"),
+			Format:       c.String("format"),
+			PasteLines:   c.Int("pastelines"),
+			PasteBytes:   c.Int("pastebytes"),
+			PastePreview: c.Int("pastepreview"),
+			MaxContext:   c.Int("maxcontext"),
+			TTL:          c.Duration("sessionduration"),
+			Store:        c.String("sessionstore"),
+			Dir:          c.String("sessiondir"),
+			CompactAt:    c.Int("compactat"),
+			CompactModel: c.String("compactmodel"),
 		},
 
 		API: &APIConfig{
@@ -310,7 +527,11 @@ func NewConfiguration(c *cli.Command) *Configuration {
 			OllamaURL:    c.String("ollamaurl"),
 			OllamaKey:    c.String("ollamakey"),
 		},
+		Path: c.String("config"),
 	}
+	config.loadOverrides(config.Path)
+	config.loadPermissions(config.Path)
+	config.loadPrices(config.Path)
 
 	return config
 }
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseChannels(t *testing.T) {
+	tests := []struct {
+		name       string
+		specs      []string
+		defaultKey string
+		want       []ChannelConfig
+	}{
+		{"single channel", []string{"#one"}, "", []ChannelConfig{{Name: "#one"}}},
+		{"inline key", []string{"#one secret"}, "", []ChannelConfig{{Name: "#one", Key: "secret"}}},
+		{
+			"default key only for channels without one",
+			[]string{"#one", "#two own"},
+			"shared",
+			[]ChannelConfig{{Name: "#one", Key: "shared"}, {Name: "#two", Key: "own"}},
+		},
+		{"skips blanks", []string{"", "  ", "#one"}, "", []ChannelConfig{{Name: "#one"}}},
+		{"drops duplicates", []string{"#One", "#one"}, "", []ChannelConfig{{Name: "#One"}}},
+		{"none", nil, "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseChannels(tt.specs, tt.defaultKey)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseChannels(%q, %q) = %+v, want %+v", tt.specs, tt.defaultKey, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChannelNames(t *testing.T) {
+	s := &ServerConfig{Channels: []ChannelConfig{{Name: "#one"}, {Name: "#two", Key: "k"}}}
+	got := s.ChannelNames()
+	want := []string{"#one", "#two"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ChannelNames() = %v, want %v", got, want)
+	}
+}
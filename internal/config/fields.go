@@ -0,0 +1,441 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexschlessinger/pollytool/llm"
+)
+
+// Field defines how to get and set a configuration value
+type Field struct {
+	Set func(*Configuration, string) error
+	Get func(*Configuration) string
+	// Channel marks fields that can be overridden per channel
+	Channel bool
+	// Secret marks fields whose values are masked when displayed
+	Secret bool
+	// Empty is shown in chat in place of an empty value
+	Empty string
+}
+
+// Masked returns the field's value with secrets masked
+func (f Field) Masked(c *Configuration) string {
+	if f.Secret {
+		return MaskAPIKey(f.Get(c))
+	}
+	return f.Get(c)
+}
+
+// Display returns the field's value for showing in chat, masking secrets
+func (f Field) Display(c *Configuration) string {
+	if v := f.Masked(c); v != "" || f.Empty == "" {
+		return v
+	}
+	return f.Empty
+}
+
+// Fields maps parameter names to their handlers
+var Fields = map[string]Field{
+	"addressed": {
+		Set: func(c *Configuration, v string) error {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("invalid value for addressed. Please provide 'true' or 'false'")
+			}
+			c.Bot.Addressed = b
+			return nil
+		},
+		Get:     func(c *Configuration) string { return fmt.Sprintf("%t", c.Bot.Addressed) },
+		Channel: true,
+	},
+	"prompt": {
+		Set:     func(c *Configuration, v string) error { c.Bot.Prompt = v; return nil },
+		Get:     func(c *Configuration) string { return c.Bot.Prompt },
+		Channel: true,
+	},
+	"model": {
+		Set:     func(c *Configuration, v string) error { c.Model.Model = v; return nil },
+		Get:     func(c *Configuration) string { return c.Model.Model },
+		Channel: true,
+	},
+	"maxtokens": {
+		Set: func(c *Configuration, v string) error {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("invalid value for maxtokens. Please provide a valid integer")
+			}
+			c.Model.MaxTokens = n
+			return nil
+		},
+		Get:     func(c *Configuration) string { return fmt.Sprintf("%d", c.Model.MaxTokens) },
+		Channel: true,
+	},
+	"maxcontext": {
+		Set: func(c *Configuration, v string) error {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid value for maxcontext. Please provide a valid non-negative integer")
+			}
+			c.Session.MaxContext = n
+			return nil
+		},
+		Get: func(c *Configuration) string { return fmt.Sprintf("%d", c.Session.MaxContext) },
+	},
+	"compactat": {
+		Set: func(c *Configuration, v string) error {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 || n > 100 {
+				return fmt.Errorf("invalid value for compactat. Please provide a percentage between 0 and 100 (0 = disabled)")
+			}
+			c.Session.CompactAt = n
+			return nil
+		},
+		Get:     func(c *Configuration) string { return fmt.Sprintf("%d", c.Session.CompactAt) },
+		Channel: true,
+	},
+	"compactmodel": {
+		Set:     func(c *Configuration, v string) error { c.Session.CompactModel = v; return nil },
+		Get:     func(c *Configuration) string { return c.Session.CompactModel },
+		Channel: true,
+	},
+	"temperature": {
+		Set: func(c *Configuration, v string) error {
+			f, err := strconv.ParseFloat(v, 32)
+			if err != nil {
+				return fmt.Errorf("invalid value for temperature. Please provide a valid float")
+			}
+			c.Model.Temperature = float32(f)
+			return nil
+		},
+		Get:     func(c *Configuration) string { return fmt.Sprintf("%f", c.Model.Temperature) },
+		Channel: true,
+	},
+	"top_p": {
+		Set: func(c *Configuration, v string) error {
+			f, err := strconv.ParseFloat(v, 32)
+			if err != nil {
+				return fmt.Errorf("invalid value for top_p. Please provide a valid float")
+			}
+			if f < 0 || f > 1 {
+				return fmt.Errorf("invalid value for top_p. Please provide a float between 0 and 1")
+			}
+			c.Model.TopP = float32(f)
+			return nil
+		},
+		Get:     func(c *Configuration) string { return fmt.Sprintf("%f", c.Model.TopP) },
+		Channel: true,
+	},
+	"openaiurl": {
+		Set: func(c *Configuration, v string) error { c.API.OpenAIURL = v; return nil },
+		Get: func(c *Configuration) string { return c.API.OpenAIURL },
+	},
+	"ollamaurl": {
+		Set: func(c *Configuration, v string) error { c.API.OllamaURL = v; return nil },
+		Get: func(c *Configuration) string { return c.API.OllamaURL },
+	},
+	"ollamakey": {
+		Set:    func(c *Configuration, v string) error { c.API.OllamaKey = v; return nil },
+		Get:    func(c *Configuration) string { return c.API.OllamaKey },
+		Secret: true,
+	},
+	"openaikey": {
+		Set:    func(c *Configuration, v string) error { c.API.OpenAIKey = v; return nil },
+		Get:    func(c *Configuration) string { return c.API.OpenAIKey },
+		Secret: true,
+	},
+	"anthropickey": {
+		Set:    func(c *Configuration, v string) error { c.API.AnthropicKey = v; return nil },
+		Get:    func(c *Configuration) string { return c.API.AnthropicKey },
+		Secret: true,
+	},
+	"geminikey": {
+		Set:    func(c *Configuration, v string) error { c.API.GeminiKey = v; return nil },
+		Get:    func(c *Configuration) string { return c.API.GeminiKey },
+		Secret: true,
+	},
+	"thinkingeffort": {
+		Set: func(c *Configuration, v string) error {
+			if _, err := llm.ParseThinkingEffort(v); err != nil {
+				return err
+			}
+			c.Model.ThinkingEffort = v
+			return nil
+		},
+		Get:     func(c *Configuration) string { return c.Model.ThinkingEffort },
+		Channel: true,
+	},
+	"showthinkingaction": {
+		Set: func(c *Configuration, v string) error {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("invalid value for showthinkingaction. Please provide 'true' or 'false'")
+			}
+			c.Bot.ShowThinkingAction = b
+			return nil
+		},
+		Get:     func(c *Configuration) string { return fmt.Sprintf("%t", c.Bot.ShowThinkingAction) },
+		Channel: true,
+	},
+	"showtoolactions": {
+		Set: func(c *Configuration, v string) error {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("invalid value for showtoolactions. Please provide 'true' or 'false'")
+			}
+			c.Bot.ShowToolActions = b
+			return nil
+		},
+		Get:     func(c *Configuration) string { return fmt.Sprintf("%t", c.Bot.ShowToolActions) },
+		Channel: true,
+	},
+	"sessionduration": {
+		Set: func(c *Configuration, v string) error {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("invalid value for sessionduration. Please provide a valid duration (e.g. 10m, 1h)")
+			}
+			c.Session.TTL = d
+			return nil
+		},
+		Get: func(c *Configuration) string { return c.Session.TTL.String() },
+	},
+	"apitimeout": {
+		Set: func(c *Configuration, v string) error {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("invalid value for apitimeout. Please provide a valid duration (e.g. 30s, 5m)")
+			}
+			c.API.Timeout = d
+			return nil
+		},
+		Get: func(c *Configuration) string { return c.API.Timeout.String() },
+	},
+	"sendburst": {
+		Set: func(c *Configuration, v string) error {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid value for sendburst. Please provide a positive integer")
+			}
+			c.Server.SendBurst = n
+			return nil
+		},
+		Get: func(c *Configuration) string { return fmt.Sprintf("%d", c.Server.SendBurst) },
+	},
+	"senddelay": {
+		Set: func(c *Configuration, v string) error {
+			d, err := time.ParseDuration(v)
+			if err != nil || d < 0 {
+				return fmt.Errorf("invalid value for senddelay. Please provide a valid duration (e.g. 1s, 500ms)")
+			}
+			c.Server.SendDelay = d
+			return nil
+		},
+		Get: func(c *Configuration) string { return c.Server.SendDelay.String() },
+	},
+	"sendtargetdelay": {
+		Set: func(c *Configuration, v string) error {
+			d, err := time.ParseDuration(v)
+			if err != nil || d < 0 {
+				return fmt.Errorf("invalid value for sendtargetdelay. Please provide a valid duration (e.g. 1s, 500ms)")
+			}
+			c.Server.SendTargetDelay = d
+			return nil
+		},
+		Get: func(c *Configuration) string { return c.Server.SendTargetDelay.String() },
+	},
+	"chunkmax": {
+		Set: func(c *Configuration, v string) error {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < ChunkMin {
+				return fmt.Errorf("invalid value for chunkmax. Please provide an integer of at least %d", ChunkMin)
+			}
+			c.Session.ChunkMax = n
+			return nil
+		},
+		Get:     func(c *Configuration) string { return fmt.Sprintf("%d", c.Session.ChunkMax) },
+		Channel: true,
+	},
+	"format": {
+		Set: func(c *Configuration, v string) error {
+			switch v {
+			case FormatIRC, FormatStrip, FormatRaw:
+				c.Session.Format = v
+				return nil
+			}
+			return fmt.Errorf("invalid value for format. Please provide 'irc', 'strip' or 'raw'")
+		},
+		Get:     func(c *Configuration) string { return c.Session.Format },
+		Channel: true,
+	},
+	"pastelines": {
+		Set: func(c *Configuration, v string) error {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid value for pastelines. Please provide a valid non-negative integer (0 = no limit)")
+			}
+			c.Session.PasteLines = n
+			return nil
+		},
+		Get:     func(c *Configuration) string { return fmt.Sprintf("%d", c.Session.PasteLines) },
+		Channel: true,
+	},
+	"pastebytes": {
+		Set: func(c *Configuration, v string) error {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid value for pastebytes. Please provide a valid non-negative integer (0 = no limit)")
+			}
+			c.Session.PasteBytes = n
+			return nil
+		},
+		Get:     func(c *Configuration) string { return fmt.Sprintf("%d", c.Session.PasteBytes) },
+		Channel: true,
+	},
+	"pastepreview": {
+		Set: func(c *Configuration, v string) error {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid value for pastepreview. Please provide a valid non-negative integer")
+			}
+			c.Session.PastePreview = n
+			return nil
+		},
+		Get:     func(c *Configuration) string { return fmt.Sprintf("%d", c.Session.PastePreview) },
+		Channel: true,
+	},
+	"urlwatcher": {
+		Set: func(c *Configuration, v string) error {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("invalid value for urlwatcher. Please provide 'true' or 'false'")
+			}
+			c.Bot.URLWatcher = b
+			return nil
+		},
+		Get:     func(c *Configuration) string { return fmt.Sprintf("%t", c.Bot.URLWatcher) },
+		Channel: true,
+	},
+	"urlwatchersilent": {
+		Set: func(c *Configuration, v string) error {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("invalid value for urlwatchersilent. Please provide 'true' or 'false'")
+			}
+			c.Bot.URLWatcherSilent = b
+			return nil
+		},
+		Get:     func(c *Configuration) string { return fmt.Sprintf("%t", c.Bot.URLWatcherSilent) },
+		Channel: true,
+	},
+	"allowedtools": {
+		Set: func(c *Configuration, v string) error {
+			c.Bot.AllowedTools = strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+			return nil
+		},
+		Get:     func(c *Configuration) string { return strings.Join(c.Bot.AllowedTools, ",") },
+		Channel: true,
+		Empty:   "(all)",
+	},
+	"contextlines": {
+		Set: func(c *Configuration, v string) error {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid value for contextlines. Please provide a valid non-negative integer (0 = disabled)")
+			}
+			c.Bot.ContextLines = n
+			return nil
+		},
+		Get:     func(c *Configuration) string { return fmt.Sprintf("%d", c.Bot.ContextLines) },
+		Channel: true,
+	},
+	"contexttokens": limitField("contexttokens", true, func(c *Configuration) *int { return &c.Bot.ContextTokens }),
+	"contextignore": {
+		Set: func(c *Configuration, v string) error {
+			c.Bot.ContextIgnore = strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+			return nil
+		},
+		Get:     func(c *Configuration) string { return strings.Join(c.Bot.ContextIgnore, ",") },
+		Channel: true,
+	},
+	"opwatcher": {
+		Set: func(c *Configuration, v string) error {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("invalid value for opwatcher. Please provide 'true' or 'false'")
+			}
+			c.Bot.OpWatcher = b
+			return nil
+		},
+		Get:     func(c *Configuration) string { return fmt.Sprintf("%t", c.Bot.OpWatcher) },
+		Channel: true,
+	},
+	"opwatchertemplate": {
+		Set:     func(c *Configuration, v string) error { c.Bot.OpWatcherTemplate = v; return nil },
+		Get:     func(c *Configuration) string { return c.Bot.OpWatcherTemplate },
+		Channel: true,
+	},
+	"userrpm":    limitField("userrpm", true, func(c *Configuration) *int { return &c.Bot.UserRPM }),
+	"usertph":    limitField("usertph", true, func(c *Configuration) *int { return &c.Bot.UserTPH }),
+	"channelrpm": limitField("channelrpm", true, func(c *Configuration) *int { return &c.Bot.ChannelRPM }),
+	"channeltph": limitField("channeltph", true, func(c *Configuration) *int { return &c.Bot.ChannelTPH }),
+	// Global budgets are shared by every channel, so they can't be overridden
+	"dailybudget":          limitField("dailybudget", false, func(c *Configuration) *int { return &c.Bot.DailyBudget }),
+	"monthlybudget":        limitField("monthlybudget", false, func(c *Configuration) *int { return &c.Bot.MonthlyBudget }),
+	"channeldailybudget":   limitField("channeldailybudget", true, func(c *Configuration) *int { return &c.Bot.ChannelDailyBudget }),
+	"channelmonthlybudget": limitField("channelmonthlybudget", true, func(c *Configuration) *int { return &c.Bot.ChannelMonthlyBudget }),
+	"userdailybudget":      limitField("userdailybudget", true, func(c *Configuration) *int { return &c.Bot.UserDailyBudget }),
+	"usermonthlybudget":    limitField("usermonthlybudget", true, func(c *Configuration) *int { return &c.Bot.UserMonthlyBudget }),
+	"budgetmodel": {
+		Set:     func(c *Configuration, v string) error { c.Bot.BudgetModel = v; return nil },
+		Get:     func(c *Configuration) string { return c.Bot.BudgetModel },
+		Channel: true,
+	},
+}
+
+// limitField builds a rate limit or budget field, where 0 means unlimited
+func limitField(name string, channel bool, value func(*Configuration) *int) Field {
+	return Field{
+		Set: func(c *Configuration, v string) error {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid value for %s. Please provide a valid non-negative integer (0 = unlimited)", name)
+			}
+			*value(c) = n
+			return nil
+		},
+		Get:     func(c *Configuration) string { return fmt.Sprintf("%d", *value(c)) },
+		Channel: channel,
+	}
+}
+
+// Keys returns all available config keys
+func Keys() []string {
+	return slices.Sorted(maps.Keys(Fields))
+}
+
+// ChannelKeys returns the config keys that can be overridden per channel
+func ChannelKeys() []string {
+	var keys []string
+	for _, k := range Keys() {
+		if Fields[k].Channel {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// MaskAPIKey returns a masked version of an API key showing only first 4 chars
+func MaskAPIKey(key string) string {
+	if key == "" {
+		return "(not set)"
+	}
+	if len(key) <= 4 {
+		return strings.Repeat("*", len(key))
+	}
+	return key[:4] + strings.Repeat("*", len(key)-4)
+}
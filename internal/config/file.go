@@ -0,0 +1,293 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// listFields are config keys holding lists, which /set cannot change but
+// which are compared and saved alongside Fields
+var listFields = map[string]func(*Configuration) []string{
+	"admins": func(c *Configuration) []string { return c.Bot.Admins },
+	"tool":   func(c *Configuration) []string { return c.Bot.Tools },
+	"channel": func(c *Configuration) []string {
+		specs := make([]string, 0, len(c.Server.Channels))
+		for _, ch := range c.Server.Channels {
+			specs = append(specs, strings.TrimSpace(ch.Name+" "+ch.Key))
+		}
+		return specs
+	},
+}
+
+// Change is a config value that differs between two configurations
+type Change struct {
+	Key     string
+	Channel string // set for per-channel overrides
+	Old     string // empty when unset
+	New     string // empty when unset
+	Secret  bool
+}
+
+// String formats the change for chat, masking secrets
+func (c Change) String() string {
+	show := func(v string) string {
+		if v == "" {
+			return "(unset)"
+		}
+		if c.Secret {
+			return MaskAPIKey(v)
+		}
+		return v
+	}
+	key := c.Key
+	if c.Channel != "" {
+		key = c.Channel + " " + c.Key
+	}
+	return fmt.Sprintf("%s: %s -> %s", key, show(c.Old), show(c.New))
+}
+
+// Load builds a configuration from a YAML file the same way startup does,
+// with environment variables taking precedence and flag defaults filling gaps
+func Load(path string) (*Configuration, error) {
+	var cfg *Configuration
+	cmd := &cli.Command{
+		Name:  "soulshack",
+		Flags: flagsFor(path),
+		Action: func(_ context.Context, c *cli.Command) error {
+			cfg = NewConfiguration(c)
+			return nil
+		},
+	}
+	if err := cmd.Run(context.Background(), []string{"soulshack", "--config", path}); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Compare returns the values that differ from old to cur, including lists
+// and per-channel overrides, sorted by key. A live config must be passed as
+// a snapshot, or with its lock held.
+func Compare(old, cur *Configuration) []Change {
+	var changes []Change
+
+	for _, key := range Keys() {
+		field := Fields[key]
+		if o, n := field.Get(old), field.Get(cur); o != n {
+			changes = append(changes, Change{Key: key, Old: o, New: n, Secret: field.Secret})
+		}
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(listFields)) {
+		get := listFields[key]
+		if o, n := get(old), get(cur); !slices.Equal(o, n) {
+			changes = append(changes, Change{Key: key, Old: strings.Join(o, ","), New: strings.Join(n, ",")})
+		}
+	}
+
+	channels := append(old.Overrides.Channels(), cur.Overrides.Channels()...)
+	slices.Sort(channels)
+	for _, channel := range slices.Compact(channels) {
+		o, n := old.Overrides.Get(channel), cur.Overrides.Get(channel)
+		keys := append(slices.Collect(maps.Keys(o)), slices.Collect(maps.Keys(n))...)
+		slices.Sort(keys)
+		for _, key := range slices.Compact(keys) {
+			if o[key] != n[key] {
+				changes = append(changes, Change{Key: key, Channel: channel, Old: o[key], New: n[key]})
+			}
+		}
+	}
+
+	return changes
+}
+
+// Diff returns what differs between the config file on disk and the running
+// configuration
+func (c *Configuration) Diff() ([]Change, error) {
+	base := c.Global()
+	if base.Path == "" {
+		return nil, errors.New("no config file loaded, start with --config")
+	}
+	onDisk, err := Load(base.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", base.Path, err)
+	}
+	return Compare(onDisk, base.Snapshot()), nil
+}
+
+// Save writes values that differ from the config file back into it. Only
+// changed keys are touched, so comments and formatting elsewhere in the file
+// are kept. Secrets are written in full.
+func (c *Configuration) Save() ([]Change, error) {
+	changes, err := c.Diff()
+	if err != nil || len(changes) == 0 {
+		return changes, err
+	}
+	base := c.Global()
+
+	var doc yaml.Node
+	mode := fs.FileMode(0o600)
+	data, err := os.ReadFile(base.Path)
+	switch {
+	case err == nil:
+		if info, statErr := os.Stat(base.Path); statErr == nil {
+			mode = info.Mode().Perm()
+		}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", base.Path, err)
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s is not a YAML mapping", base.Path)
+	}
+
+	for _, change := range changes {
+		switch {
+		case change.Channel != "":
+			setOverrideNode(root, change.Channel, change.Key, change.New)
+		case listFields[change.Key] != nil:
+			setNode(root, change.Key, sequenceNode(listFields[change.Key](base)))
+		default:
+			setNode(root, change.Key, scalarNode(change.New))
+		}
+	}
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(base.Path, out.Bytes(), mode); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// setOverrideNode sets or removes one key under overrides.<channel>
+func setOverrideNode(root *yaml.Node, channel, key, value string) {
+	overrides := mappingValue(root, "overrides")
+	if overrides == nil {
+		if value == "" {
+			return
+		}
+		overrides = &yaml.Node{Kind: yaml.MappingNode}
+		setNode(root, "overrides", overrides)
+	}
+	values := mappingValue(overrides, channel)
+	if values == nil {
+		if value == "" {
+			return
+		}
+		values = &yaml.Node{Kind: yaml.MappingNode}
+		setNode(overrides, channel, values)
+	}
+
+	if value == "" {
+		deleteNode(values, key)
+	} else {
+		setNode(values, key, scalarNode(value))
+	}
+	if len(values.Content) == 0 {
+		deleteNode(overrides, channel)
+	}
+	if len(overrides.Content) == 0 {
+		deleteNode(root, "overrides")
+	}
+}
+
+// mappingValue returns the value node for key in a mapping node
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if strings.EqualFold(mapping.Content[i].Value, key) {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setNode replaces the value for key in a mapping node, keeping comments on
+// the existing entry, or appends the key if it is missing
+func setNode(mapping *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if strings.EqualFold(mapping.Content[i].Value, key) {
+			old := mapping.Content[i+1]
+			value.HeadComment, value.LineComment, value.FootComment = old.HeadComment, old.LineComment, old.FootComment
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+// deleteNode removes key from a mapping node
+func deleteNode(mapping *yaml.Node, key string) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if strings.EqualFold(mapping.Content[i].Value, key) {
+			mapping.Content = slices.Delete(mapping.Content, i, i+2)
+			return
+		}
+	}
+}
+
+// scalarNode builds a YAML scalar, writing booleans and numbers unquoted
+func scalarNode(value string) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+	if value == "true" || value == "false" {
+		node.Tag = "!!bool"
+	} else if _, err := strconv.Atoi(value); err == nil {
+		node.Tag = "!!int"
+	} else if f, err := strconv.ParseFloat(value, 32); err == nil {
+		node.Tag = "!!float"
+		node.Value = strconv.FormatFloat(f, 'f', -1, 32)
+	}
+	return node
+}
+
+// sequenceNode builds a YAML list of strings
+func sequenceNode(values []string) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, v := range values {
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v})
+	}
+	return node
+}
+
+// writeFileAtomic writes data to a temporary file and renames it over path
+func writeFileAtomic(path string, data []byte, mode fs.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
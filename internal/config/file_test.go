@@ -0,0 +1,140 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const testYAML = `# bot settings
+model: ollama/base # the default model
+temperature: 0.7
+overrides:
+  "#dev":
+    prompt: be terse
+`
+
+func writeTestConfig(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte(testYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDiff(t *testing.T) {
+	path := writeTestConfig(t)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if changes, _ := cfg.Diff(); len(changes) != 0 {
+		t.Fatalf("expected no changes right after load, got %v", changes)
+	}
+
+	cfg.Model.Model = "anthropic/big"
+	cfg.API.OpenAIKey = "sk-abcdefgh"
+	if err := cfg.SetChannelOverride("#dev", "temperature", "0.2"); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := cfg.Diff()
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	var lines []string
+	for _, c := range changes {
+		lines = append(lines, c.String())
+	}
+	got := strings.Join(lines, "\n")
+	for _, want := range []string{
+		"model: ollama/base -> anthropic/big",
+		"openaikey: (unset) -> sk-a*******",
+		"#dev temperature: (unset) -> 0.2",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("diff missing %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestSave(t *testing.T) {
+	path := writeTestConfig(t)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	cfg.Model.Model = "anthropic/big"
+	cfg.Model.Temperature = 0.3
+	cfg.API.OpenAIKey = "sk-abcdefgh"
+	cfg.Bot.Admins = []string{"alice!*@*"}
+	cfg.Overrides.Unset("#dev", "prompt")
+	if err := cfg.SetChannelOverride("#random", "addressed", "false"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cfg.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	saved := string(data)
+	for _, want := range []string{
+		"# bot settings",
+		"model: anthropic/big # the default model",
+		"temperature: 0.3",
+		"openaikey: sk-abcdefgh",
+		"- alice!*@*",
+		"addressed: false",
+	} {
+		if !strings.Contains(saved, want) {
+			t.Errorf("saved file missing %q:\n%s", want, saved)
+		}
+	}
+	if strings.Contains(saved, "#dev") {
+		t.Errorf("expected empty #dev overrides to be removed:\n%s", saved)
+	}
+
+	if changes, _ := cfg.Diff(); len(changes) != 0 {
+		t.Errorf("expected no changes after save, got %v", changes)
+	}
+}
+
+func TestSave_AllowedToolsRoundTrip(t *testing.T) {
+	path := writeTestConfig(t)
+	for _, tools := range [][]string{{"irc__*", "memory__*"}, nil} {
+		cfg, err := Load(path)
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		cfg.Bot.AllowedTools = tools
+		cfg.Model.Model = "anthropic/next" // something else changes too
+		if _, err := cfg.Save(); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+
+		loaded, err := Load(path)
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		if !slices.Equal(loaded.Bot.AllowedTools, tools) {
+			data, _ := os.ReadFile(path)
+			t.Errorf("saved %q, loaded %q from:\n%s", tools, loaded.Bot.AllowedTools, data)
+		}
+		if changes, _ := loaded.Diff(); len(changes) != 0 {
+			t.Errorf("expected no changes after reloading, got %v", changes)
+		}
+	}
+}
+
+func TestSave_NoConfigFile(t *testing.T) {
+	cfg := testConfig()
+	if _, err := cfg.Save(); err == nil {
+		t.Error("expected error without a config file")
+	}
+}
@@ -0,0 +1,223 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ChannelOverrides holds per-channel config values, keyed by channel and then
+// by config key. Values use the same string form as /set.
+type ChannelOverrides struct {
+	mu       sync.RWMutex
+	channels map[string]map[string]string
+}
+
+// NewChannelOverrides creates an empty override set
+func NewChannelOverrides() *ChannelOverrides {
+	return &ChannelOverrides{channels: make(map[string]map[string]string)}
+}
+
+// channelKey folds channel names so #Dev and #dev share overrides
+func channelKey(channel string) string {
+	return strings.ToLower(channel)
+}
+
+// Get returns a copy of the overrides for a channel
+func (o *ChannelOverrides) Get(channel string) map[string]string {
+	if o == nil || channel == "" {
+		return nil
+	}
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return maps.Clone(o.channels[channelKey(channel)])
+}
+
+// Set stores an override without validating it
+func (o *ChannelOverrides) Set(channel, key, value string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	ch := channelKey(channel)
+	if o.channels[ch] == nil {
+		o.channels[ch] = make(map[string]string)
+	}
+	o.channels[ch][key] = value
+}
+
+// Unset removes an override, reporting whether it existed
+func (o *ChannelOverrides) Unset(channel, key string) bool {
+	if o == nil {
+		return false
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	ch := channelKey(channel)
+	if _, ok := o.channels[ch][key]; !ok {
+		return false
+	}
+	delete(o.channels[ch], key)
+	if len(o.channels[ch]) == 0 {
+		delete(o.channels, ch)
+	}
+	return true
+}
+
+// Replace swaps in the overrides held by other
+func (o *ChannelOverrides) Replace(other *ChannelOverrides) {
+	channels := make(map[string]map[string]string)
+	for _, channel := range other.Channels() {
+		channels[channel] = other.Get(channel)
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.channels = channels
+}
+
+// Channels returns the channels that have overrides, sorted
+func (o *ChannelOverrides) Channels() []string {
+	if o == nil {
+		return nil
+	}
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return slices.Sorted(maps.Keys(o.channels))
+}
+
+// Global returns the configuration shared by all channels. Changes that
+// should outlive the current event must be made on the global config, since
+// ForChannel may return a copy.
+func (c *Configuration) Global() *Configuration {
+	if c.global != nil {
+		return c.global
+	}
+	return c
+}
+
+// ForChannel returns the effective configuration for a channel: a snapshot
+// of the global config with that channel's overrides applied. Later changes
+// to the global config don't affect it.
+func (c *Configuration) ForChannel(channel string) *Configuration {
+	base := c.Global()
+	values := base.Overrides.Get(channel)
+	base.mu.RLock()
+	defer base.mu.RUnlock()
+	return base.layered(channel, values)
+}
+
+// Snapshot returns a copy of the global config, safe to read while it
+// changes
+func (c *Configuration) Snapshot() *Configuration {
+	return c.ForChannel("")
+}
+
+// Update changes the global config, holding off snapshots until fn returns
+func (c *Configuration) Update(fn func(*Configuration)) {
+	base := c.Global()
+	base.mu.Lock()
+	defer base.mu.Unlock()
+	fn(base)
+}
+
+// layered returns a copy of c with values applied on top
+func (c *Configuration) layered(channel string, values map[string]string) *Configuration {
+	effective := &Configuration{
+		Server:      clone(c.Server),
+		Bot:         clone(c.Bot),
+		Model:       clone(c.Model),
+		Session:     clone(c.Session),
+		API:         clone(c.API),
+		Overrides:   c.Overrides,
+		Permissions: c.Permissions,
+		Prices:      c.Prices,
+		global:      c,
+	}
+	for key, value := range values {
+		field, ok := Fields[key]
+		if !ok {
+			continue
+		}
+		if err := field.Set(effective, value); err != nil {
+			slog.Warn("channel_override_invalid", "channel", channel, "key", key, "error", err)
+		}
+	}
+	return effective
+}
+
+// clone copies a config section, if there is one
+func clone[T any](section *T) *T {
+	if section == nil {
+		return nil
+	}
+	copied := *section
+	return &copied
+}
+
+// SetChannelOverride validates and stores an override for a channel
+func (c *Configuration) SetChannelOverride(channel, key, value string) error {
+	field, ok := Fields[key]
+	if !ok {
+		return fmt.Errorf("unknown key %s", key)
+	}
+	if !field.Channel {
+		return fmt.Errorf("%s cannot be set per channel", key)
+	}
+	base := c.Global()
+	if err := field.Set(base.Snapshot(), value); err != nil {
+		return err
+	}
+	if base.Overrides == nil {
+		base.Overrides = NewChannelOverrides()
+	}
+	base.Overrides.Set(channel, key, value)
+	return nil
+}
+
+// loadOverrides reads the "overrides" section of a YAML config file:
+//
+//	overrides:
+//	  "#dev":
+//	    model: anthropic/claude-sonnet-4
+//	    temperature: 0.2
+func (c *Configuration) loadOverrides(path string) {
+	c.Overrides = NewChannelOverrides()
+	if path == "" {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return // already reported while reading flags
+	}
+	var file struct {
+		Overrides map[string]map[string]any `yaml:"overrides"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		slog.Warn("channel_overrides_invalid", "path", path, "error", err)
+		return
+	}
+
+	for channel, values := range file.Overrides {
+		for key, v := range values {
+			if err := c.SetChannelOverride(channel, key, yamlString(v)); err != nil {
+				slog.Warn("channel_override_invalid", "channel", channel, "key", key, "error", err)
+			}
+		}
+	}
+}
+
+// yamlString converts a YAML scalar or list into its /set string form
+func yamlString(v any) string {
+	if list, ok := v.([]any); ok {
+		strs := make([]string, 0, len(list))
+		for _, item := range list {
+			strs = append(strs, fmt.Sprintf("%v", item))
+		}
+		return strings.Join(strs, ",")
+	}
+	return fmt.Sprintf("%v", v)
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testConfig() *Configuration {
+	return &Configuration{
+		Server:  &ServerConfig{},
+		Bot:     &BotConfig{Prompt: "global", Addressed: true},
+		Model:   &ModelConfig{Model: "ollama/base", Temperature: 0.7},
+		Session: &SessionConfig{},
+		API:     &APIConfig{},
+	}
+}
+
+func TestForChannel(t *testing.T) {
+	cfg := testConfig()
+	if err := cfg.SetChannelOverride("#Dev", "prompt", "be terse"); err != nil {
+		t.Fatalf("SetChannelOverride: %v", err)
+	}
+	if err := cfg.SetChannelOverride("#dev", "addressed", "false"); err != nil {
+		t.Fatalf("SetChannelOverride: %v", err)
+	}
+
+	dev := cfg.ForChannel("#dev")
+	if dev.Bot.Prompt != "be terse" || dev.Bot.Addressed {
+		t.Errorf("overrides not applied: prompt=%q addressed=%t", dev.Bot.Prompt, dev.Bot.Addressed)
+	}
+	if dev.Model.Model != "ollama/base" {
+		t.Errorf("expected inherited model, got %s", dev.Model.Model)
+	}
+	if dev.Global() != cfg {
+		t.Error("expected Global to return the shared config")
+	}
+	if cfg.Bot.Prompt != "global" || !cfg.Bot.Addressed {
+		t.Error("overrides leaked into the global config")
+	}
+	random := cfg.ForChannel("#random")
+	if random.Bot.Prompt != "global" || !random.Bot.Addressed {
+		t.Error("expected channels without overrides to use the global values")
+	}
+	if random.Bot == cfg.Bot || random.Global() != cfg {
+		t.Error("expected a snapshot of the global config")
+	}
+}
+
+func TestSetChannelOverride_Rejects(t *testing.T) {
+	tests := []struct {
+		name, key, value string
+	}{
+		{"unknown key", "nosuchkey", "x"},
+		{"global only key", "openaikey", "sk-test"},
+		{"invalid value", "temperature", "warm"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			if err := cfg.SetChannelOverride("#dev", tt.key, tt.value); err == nil {
+				t.Errorf("expected error for %s=%s", tt.key, tt.value)
+			}
+			if len(cfg.Overrides.Get("#dev")) != 0 {
+				t.Error("rejected override was stored")
+			}
+		})
+	}
+}
+
+func TestLoadOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	data := `
+model: ollama/base
+overrides:
+  "#dev":
+    model: anthropic/terse
+    temperature: 0.2
+    allowedtools: ["irc__*", "web_*"]
+    openaikey: ignored
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := testConfig()
+	cfg.loadOverrides(path)
+
+	dev := cfg.ForChannel("#dev")
+	if dev.Model.Model != "anthropic/terse" || dev.Model.Temperature != 0.2 {
+		t.Errorf("unexpected model settings: %s %f", dev.Model.Model, dev.Model.Temperature)
+	}
+	if len(dev.Bot.AllowedTools) != 2 || dev.Bot.AllowedTools[0] != "irc__*" {
+		t.Errorf("unexpected allowedtools: %v", dev.Bot.AllowedTools)
+	}
+	if _, ok := cfg.Overrides.Get("#dev")["openaikey"]; ok {
+		t.Error("global-only key should not be loaded as an override")
+	}
+}
+
+func TestForChannel_Concurrent(t *testing.T) {
+	cfg := testConfig()
+
+	// Run with -race: snapshots are taken while the config changes
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 100 {
+			cfg.Update(func(c *Configuration) { *c.Bot = BotConfig{Prompt: fmt.Sprint(i)} })
+		}
+	}()
+	for range 100 {
+		if cfg.ForChannel("#dev").Bot.Prompt == "" {
+			t.Fatal("expected a complete snapshot")
+		}
+	}
+	<-done
+	if cfg.Bot.Prompt != "99" {
+		t.Errorf("expected the last update, got %q", cfg.Bot.Prompt)
+	}
+}
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Role is a permission level. Each role includes the ones below it.
+type Role int
+
+const (
+	RoleEveryone Role = iota
+	RoleTrusted
+	RoleOperator
+	RoleOwner
+)
+
+var roleNames = []string{"everyone", "trusted", "operator", "owner"}
+
+func (r Role) String() string {
+	if r < RoleEveryone || r > RoleOwner {
+		return fmt.Sprintf("role(%d)", int(r))
+	}
+	return roleNames[r]
+}
+
+// ParseRole converts a role name into a Role
+func ParseRole(name string) (Role, error) {
+	for i, n := range roleNames {
+		if strings.EqualFold(name, n) {
+			return Role(i), nil
+		}
+	}
+	return RoleEveryone, fmt.Errorf("invalid role %s. Please provide one of: %s", name, strings.Join(roleNames, ", "))
+}
+
+// Permissions assigns roles to users and sets the role needed for each
+// command, /set key and tool. Anything not listed keeps its default.
+type Permissions struct {
+	// Members maps a role to hostmask globs, $a:account entries, or +o/+v
+	// for channel operators and voiced users
+	Members  map[Role][]string
+	Commands map[string]Role // command name, e.g. /set
+	Keys     map[string]Role // /set and /unset key
+	Tools    map[string]Role // tool name, may be a glob like irc__*
+}
+
+// HasMembers reports whether any role has members assigned
+func (p *Permissions) HasMembers() bool {
+	if p == nil {
+		return false
+	}
+	for _, members := range p.Members {
+		if len(members) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Command returns the role needed to run a command
+func (p *Permissions) Command(name string, fallback Role) Role {
+	if p == nil {
+		return fallback
+	}
+	if role, ok := p.Commands[name]; ok {
+		return role
+	}
+	return fallback
+}
+
+// Key returns the role needed to change a config key. Without a policy entry
+// the /set command's own requirement is all that applies.
+func (p *Permissions) Key(key string) Role {
+	if p == nil {
+		return RoleEveryone
+	}
+	return p.Keys[key]
+}
+
+// Tool returns the role needed to call a tool. An exact name wins over glob
+// patterns, and the longest matching pattern wins among globs.
+func (p *Permissions) Tool(name string, fallback Role) Role {
+	if p == nil {
+		return fallback
+	}
+	if role, ok := p.Tools[name]; ok {
+		return role
+	}
+	best, role := "", fallback
+	for pattern, r := range p.Tools {
+		if matched, _ := path.Match(pattern, name); matched && len(pattern) > len(best) {
+			best, role = pattern, r
+		}
+	}
+	return role
+}
+
+// loadPermissions reads the "roles" and "permissions" sections of a YAML
+// config file:
+//
+//	roles:
+//	  operator: ["+o", "$a:alice"]
+//	  trusted: ["+v"]
+//	permissions:
+//	  commands:
+//	    /set: operator
+//	  keys:
+//	    openaikey: owner
+//	  tools:
+//	    irc__*: operator
+func (c *Configuration) loadPermissions(path string) {
+	if path == "" {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return // already reported while reading flags
+	}
+	var file struct {
+		Roles       map[string][]string `yaml:"roles"`
+		Permissions struct {
+			Commands map[string]string `yaml:"commands"`
+			Keys     map[string]string `yaml:"keys"`
+			Tools    map[string]string `yaml:"tools"`
+		} `yaml:"permissions"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		slog.Warn("permissions_invalid", "path", path, "error", err)
+		return
+	}
+
+	perms := &Permissions{
+		Members:  make(map[Role][]string),
+		Commands: make(map[string]Role),
+		Keys:     make(map[string]Role),
+		Tools:    make(map[string]Role),
+	}
+	for name, members := range file.Roles {
+		role, err := ParseRole(name)
+		if err != nil {
+			slog.Warn("role_invalid", "role", name, "error", err)
+			continue
+		}
+		perms.Members[role] = members
+	}
+	policies := []struct {
+		section string
+		from    map[string]string
+		to      map[string]Role
+		fold    bool // command names are matched lowercased
+	}{
+		{"commands", file.Permissions.Commands, perms.Commands, true},
+		{"keys", file.Permissions.Keys, perms.Keys, false},
+		{"tools", file.Permissions.Tools, perms.Tools, false},
+	}
+	for _, policy := range policies {
+		for name, roleName := range policy.from {
+			role, err := ParseRole(roleName)
+			if err != nil {
+				slog.Warn("permission_invalid", "section", policy.section, "name", name, "error", err)
+				continue
+			}
+			if policy.fold {
+				name = strings.ToLower(name)
+			}
+			policy.to[name] = role
+		}
+	}
+	c.Permissions = perms
+}
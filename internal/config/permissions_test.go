@@ -0,0 +1,85 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadPermissions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	data := `
+roles:
+  operator: ["+o", "$a:alice"]
+  trusted: ["+v"]
+  wizard: ["*!*@magic"]
+permissions:
+  commands:
+    /SET: operator
+  keys:
+    openaikey: owner
+  tools:
+    irc__*: operator
+    irc__kick: trusted
+    web_*: nobody
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := testConfig()
+	cfg.loadPermissions(path)
+	perms := cfg.Permissions
+
+	if got := perms.Members[RoleOperator]; len(got) != 2 || got[1] != "$a:alice" {
+		t.Errorf("unexpected operator members: %v", got)
+	}
+	if len(perms.Members) != 2 {
+		t.Errorf("unknown role should be skipped, got %v", perms.Members)
+	}
+	if got := perms.Command("/set", RoleOwner); got != RoleOperator {
+		t.Errorf("expected /set to need operator, got %s", got)
+	}
+	if got := perms.Command("/get", RoleEveryone); got != RoleEveryone {
+		t.Errorf("expected /get to keep its default, got %s", got)
+	}
+	if got := perms.Key("openaikey"); got != RoleOwner {
+		t.Errorf("expected openaikey to need owner, got %s", got)
+	}
+	if got := perms.Key("prompt"); got != RoleEveryone {
+		t.Errorf("expected prompt to have no extra requirement, got %s", got)
+	}
+	if _, ok := perms.Tools["web_*"]; ok {
+		t.Error("invalid role should be skipped")
+	}
+}
+
+func TestPermissions_Tool(t *testing.T) {
+	perms := &Permissions{Tools: map[string]Role{
+		"*":         RoleTrusted,
+		"irc__*":    RoleOperator,
+		"irc__kick": RoleOwner,
+	}}
+
+	tests := []struct {
+		name string
+		want Role
+	}{
+		{"irc__kick", RoleOwner},
+		{"irc__topic", RoleOperator},
+		{"datetime", RoleTrusted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := perms.Tool(tt.name, RoleEveryone); got != tt.want {
+				t.Errorf("Tool(%q) = %s, want %s", tt.name, got, tt.want)
+			}
+		})
+	}
+
+	var none *Permissions
+	if got := none.Tool("irc__kick", RoleOwner); got != RoleOwner {
+		t.Errorf("expected fallback without a policy, got %s", got)
+	}
+}
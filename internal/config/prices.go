@@ -0,0 +1,54 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"path"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Price is what a model costs, in currency units per million tokens
+type Price struct {
+	Input  float64 `yaml:"input"`
+	Output float64 `yaml:"output"`
+}
+
+// Cost returns what a request to model cost, using the most specific entry
+// of the price table: an exact model name, else the longest matching glob.
+// Models without a price cost nothing.
+func (c *Configuration) Cost(model string, input, output int) float64 {
+	price, ok := c.Prices[model]
+	if !ok {
+		best := ""
+		for pattern, p := range c.Prices {
+			if matched, _ := path.Match(pattern, model); matched && len(pattern) > len(best) {
+				best, price = pattern, p
+			}
+		}
+	}
+	return (float64(input)*price.Input + float64(output)*price.Output) / 1e6
+}
+
+// loadPrices reads the "prices" section of a YAML config file:
+//
+//	prices:
+//	  anthropic/claude-sonnet-*: {input: 3, output: 15}
+//	  ollama/*: {input: 0, output: 0}
+func (c *Configuration) loadPrices(path string) {
+	if path == "" {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return // already reported while reading flags
+	}
+	var file struct {
+		Prices map[string]Price `yaml:"prices"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		slog.Warn("prices_invalid", "path", path, "error", err)
+		return
+	}
+	c.Prices = file.Prices
+}
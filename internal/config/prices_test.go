@@ -0,0 +1,30 @@
+package config
+
+import "testing"
+
+func TestCost(t *testing.T) {
+	cfg := testConfig()
+	cfg.Prices = map[string]Price{
+		"anthropic/*":             {Input: 3, Output: 15},
+		"anthropic/claude-haiku*": {Input: 1, Output: 5},
+		"openai/gpt-5":            {Input: 1.25, Output: 10},
+	}
+
+	tests := []struct {
+		model string
+		want  float64
+	}{
+		{"anthropic/claude-sonnet-4-5", 3 + 15},
+		{"anthropic/claude-haiku-4-5", 1 + 5},
+		{"openai/gpt-5", 1.25 + 10},
+		{"ollama/llama3.2", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			if got := cfg.Cost(tt.model, 1_000_000, 1_000_000); got != tt.want {
+				t.Errorf("Cost(%s) = %f, want %f", tt.model, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package core
+
+import (
+	"unicode/utf8"
+
+	"pkdindustries/soulshack/internal/store"
+)
+
+// auditDetailMax caps the reply or tool result kept with an audit entry
+const auditDetailMax = 500
+
+// NewAuditEntry starts an audit entry for an action taken by the sender of
+// the current event
+func NewAuditEntry(ctx ChatContextInterface, kind, action string) store.AuditEntry {
+	entry := store.AuditEntry{
+		Kind:    kind,
+		Action:  action,
+		Nick:    ctx.GetSource(),
+		Channel: ctx.GetChannelName(),
+	}
+	if user := ctx.GetUser(entry.Nick); user != nil {
+		entry.Hostmask = user.Nick + "!" + user.Ident + "@" + user.Host
+		if user.Account != "*" && user.Account != "0" {
+			entry.Account = user.Account
+		}
+	}
+	return entry
+}
+
+// Audit records an entry in the audit log
+func Audit(ctx ChatContextInterface, entry store.AuditEntry) {
+	if len(entry.Detail) > auditDetailMax {
+		// Cut on a rune boundary so the entry stays valid UTF-8
+		cut := auditDetailMax
+		for cut > 0 && !utf8.RuneStart(entry.Detail[cut]) {
+			cut--
+		}
+		entry.Detail = entry.Detail[:cut] + "..."
+	}
+	ctx.GetLogger().Info("audit", "kind", entry.Kind, "action", entry.Action, "nick", entry.Nick, "outcome", entry.Outcome)
+	if err := ctx.GetSystem().GetAudit().Record(entry); err != nil {
+		ctx.GetLogger().Error("audit_record_failed", "error", err)
+	}
+}
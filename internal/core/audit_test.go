@@ -0,0 +1,28 @@
+package core_test
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"pkdindustries/soulshack/internal/core"
+	mocktest "pkdindustries/soulshack/internal/testing"
+)
+
+func TestAudit_TruncatesOnRuneBoundary(t *testing.T) {
+	sys := mocktest.NewMockSystem()
+	ctx := mocktest.NewMockContext().WithSystem(sys)
+
+	entry := core.NewAuditEntry(ctx, "command", "/set")
+	entry.Detail = "a" + strings.Repeat("é", 300)
+	core.Audit(ctx, entry)
+
+	entries := sys.Audit.Recent(1, nil)
+	if len(entries) != 1 {
+		t.Fatalf("expected one entry, got %d", len(entries))
+	}
+	detail := entries[0].Detail
+	if !utf8.ValidString(detail) || !strings.HasSuffix(detail, "é...") || len(detail) > 503 {
+		t.Errorf("expected the detail cut between runes, got %d bytes: %q", len(detail), detail[len(detail)-8:])
+	}
+}
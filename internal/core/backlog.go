@@ -0,0 +1,77 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/alexschlessinger/pollytool/messages"
+	"github.com/alexschlessinger/pollytool/sessions"
+)
+
+// Backlog keeps the recent channel lines the bot was not addressed in, so
+// the next request that addresses it knows what was said before
+type Backlog struct {
+	mu    sync.Mutex
+	lines map[string][]string // by lowercased channel, oldest first
+}
+
+// NewBacklog creates an empty backlog
+func NewBacklog() *Backlog {
+	return &Backlog{lines: make(map[string][]string)}
+}
+
+// Add records a line from nick in channel, keeping at most keep lines
+func (b *Backlog) Add(channel, nick, text string, keep int) {
+	if keep <= 0 {
+		return
+	}
+	key := strings.ToLower(channel)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	lines := append(b.lines[key], fmt.Sprintf("(nick:%s) %s", nick, text))
+	if len(lines) > keep {
+		lines = slices.Delete(lines, 0, len(lines)-keep)
+	}
+	b.lines[key] = lines
+}
+
+// Restore puts lines taken from a channel back ahead of any added since, for
+// a request that was refused before the model saw them, keeping at most keep
+// lines
+func (b *Backlog) Restore(channel string, lines []string, keep int) {
+	if keep <= 0 || len(lines) == 0 {
+		return
+	}
+	key := strings.ToLower(channel)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	lines = append(slices.Clone(lines), b.lines[key]...)
+	if len(lines) > keep {
+		lines = slices.Delete(lines, 0, len(lines)-keep)
+	}
+	b.lines[key] = lines
+}
+
+// Take removes and returns a channel's lines, oldest first. With a token cap
+// above zero, only the newest lines that fit are returned.
+func (b *Backlog) Take(channel string, tokens int) []string {
+	key := strings.ToLower(channel)
+	b.mu.Lock()
+	lines := b.lines[key]
+	delete(b.lines, key)
+	b.mu.Unlock()
+
+	if tokens <= 0 {
+		return lines
+	}
+	used := 0
+	for i := len(lines) - 1; i >= 0; i-- {
+		used += sessions.EstimateTokens(messages.ChatMessage{Role: messages.MessageRoleUser, Content: lines[i]})
+		if used > tokens {
+			return lines[i+1:]
+		}
+	}
+	return lines
+}
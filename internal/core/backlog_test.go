@@ -0,0 +1,48 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBacklog(t *testing.T) {
+	b := NewBacklog()
+	for _, text := range []string{"one", "two", "three"} {
+		b.Add("#Dev", "alice", text, 2)
+	}
+	b.Add("#other", "bob", "elsewhere", 2)
+	b.Add("#dev", "bob", "ignored", 0)
+
+	lines := b.Take("#dev", 0)
+	if strings.Join(lines, "|") != "(nick:alice) two|(nick:alice) three" {
+		t.Errorf("expected the newest lines kept, oldest first, got %v", lines)
+	}
+	if lines := b.Take("#dev", 0); len(lines) != 0 {
+		t.Errorf("expected Take to drain the channel, got %v", lines)
+	}
+
+	b.Add("#dev", "alice", strings.Repeat("a", 400), 10)
+	b.Add("#dev", "bob", "short", 10)
+	if lines := b.Take("#dev", 50); len(lines) != 1 || lines[0] != "(nick:bob) short" {
+		t.Errorf("expected only the newest line within the token cap, got %v", lines)
+	}
+	if lines := b.Take("#other", 0); len(lines) != 1 {
+		t.Errorf("expected other channels untouched, got %v", lines)
+	}
+}
+
+func TestBacklog_Restore(t *testing.T) {
+	b := NewBacklog()
+	b.Add("#dev", "alice", "one", 3)
+	b.Add("#dev", "alice", "two", 3)
+	taken := b.Take("#dev", 0)
+
+	b.Add("#dev", "bob", "three", 3)
+	b.Add("#dev", "bob", "four", 3)
+	b.Restore("#dev", taken, 3)
+
+	lines := b.Take("#dev", 0)
+	if strings.Join(lines, "|") != "(nick:alice) two|(nick:bob) three|(nick:bob) four" {
+		t.Errorf("expected restored lines ahead of newer ones, within the limit, got %v", lines)
+	}
+}
@@ -5,10 +5,13 @@ import (
 	"log/slog"
 
 	"github.com/alexschlessinger/pollytool/llm"
+	"github.com/alexschlessinger/pollytool/messages"
 	"github.com/alexschlessinger/pollytool/sessions"
 	"github.com/alexschlessinger/pollytool/tools"
 
 	"pkdindustries/soulshack/internal/config"
+	"pkdindustries/soulshack/internal/paste"
+	"pkdindustries/soulshack/internal/store"
 )
 
 // ChatContextInterface provides all context needed for handling IRC messages
@@ -18,6 +21,7 @@ type ChatContextInterface interface {
 	// Event methods
 	IsAddressed() bool
 	IsAdmin() bool
+	GetRole() config.Role
 	IsPrivate() bool
 	GetCommand() string
 	GetSource() string
@@ -25,8 +29,11 @@ type ChatContextInterface interface {
 
 	// Responder methods
 	Reply(string)
+	ReplyMultiline(lines []string) // one message where the server supports draft/multiline
+	Multiline() (maxBytes int, ok bool)
 	ReplyAction(string)
 	SendAction(target, message string)
+	Typing(state string) // no-op without message-tags
 
 	// Controller methods
 	Join(string) bool
@@ -46,6 +53,7 @@ type ChatContextInterface interface {
 	GetChannel(name string) *ChannelInfo
 	GetChannelUsers(channel string) []ChannelUser
 	GetBotNick() string
+	GetChannelName() string
 	GetLockKey() string
 	IsOp(channel, nick string) bool
 
@@ -60,6 +68,9 @@ type ChatContextInterface interface {
 type LLM interface {
 	// ChatCompletionStream returns a channel of string chunks for IRC output
 	ChatCompletionStream(ChatContextInterface, *llm.CompletionRequest) <-chan string
+	// Complete runs one completion without tools and returns the reply as
+	// the model wrote it, for the bot's own use; nothing is sent to IRC
+	Complete(context.Context, *llm.CompletionRequest) (messages.ChatMessage, error)
 }
 
 type System interface {
@@ -67,4 +78,15 @@ type System interface {
 	GetSessionStore() sessions.SessionStore
 	GetLLM() LLM
 	UpdateLLM(config.APIConfig) error
+	GetRateLimiter() *RateLimiter
+	GetBacklog() *Backlog
+	GetUsage() *store.UsageLedger
+	GetAudit() *store.AuditLog
+	GetTranscript() *store.Transcript // nil when transcripts are disabled
+	GetMemory() *store.MemoryStore
+	GetPaster() paste.Paster // nil when pasting is disabled
+	// LoadTool loads a tool spec, returning the names of the tools it added
+	LoadTool(spec string) ([]string, error)
+	// RemoveTool removes one tool by name
+	RemoveTool(name string)
 }
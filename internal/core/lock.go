@@ -4,6 +4,13 @@ import (
 	"context"
 	"log/slog"
 	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"pkdindustries/soulshack/internal/metrics"
 )
 
 // RequestLock provides context-aware locking for serializing request processing
@@ -66,13 +73,23 @@ func WithRequestLock(ctx context.Context, key string, operation string, onSucces
 	}
 
 	logger.Debug("lock_acquiring", "lock_key", key, "operation", operation)
+	start := time.Now()
+	_, span := Tracer().Start(ctx, "lock.wait", trace.WithAttributes(
+		attribute.String("lock_key", key),
+		attribute.String("operation", operation),
+	))
 	if !lock.LockWithContext(ctx) {
 		logger.Warn("lock_timeout", "lock_key", key, "operation", operation)
+		metrics.LockTimeouts.WithLabelValues(operation).Inc()
+		span.SetStatus(codes.Error, "lock timeout")
+		span.End()
 		if onTimeout != nil {
 			onTimeout()
 		}
 		return
 	}
+	span.End()
+	metrics.LockWait.WithLabelValues(operation).Observe(time.Since(start).Seconds())
 	logger.Debug("lock_acquired", "lock_key", key, "operation", operation)
 	defer func() {
 		logger.Debug("lock_released", "lock_key", key, "operation", operation)
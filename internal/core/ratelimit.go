@@ -0,0 +1,172 @@
+package core
+
+import (
+	"sync"
+	"time"
+
+	"github.com/lrstanley/girc"
+
+	"pkdindustries/soulshack/internal/config"
+)
+
+// RateLimiter keeps token buckets for LLM requests per user and per channel.
+// Limits are read from the request's config on every check, so changes made
+// with /set apply immediately.
+type RateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	// notified holds when each sender in each channel may next be told they
+	// are limited
+	notified map[string]time.Time
+	swept    time.Time
+	now      func() time.Time
+}
+
+// bucket holds what is left of one limit; it refills continuously
+type bucket struct {
+	level float64
+	last  time.Time
+}
+
+// limit is a bucket size and the period over which it refills completely
+type limit struct {
+	key  string
+	size int
+	per  time.Duration
+}
+
+// NewRateLimiter creates an empty rate limiter
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{
+		buckets:  make(map[string]*bucket),
+		notified: make(map[string]time.Time),
+		now:      time.Now,
+	}
+}
+
+// Allow checks a request from the sender against the request and token
+// limits, taking one request from each request bucket when it fits. It
+// returns zero when allowed, or how long to wait otherwise. Admins are exempt.
+func (r *RateLimiter) Allow(ctx ChatContextInterface) time.Duration {
+	if exempt(ctx) {
+		return 0
+	}
+	requests, tokens := r.limits(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	r.sweep(now)
+
+	var wait time.Duration
+	for _, l := range append(requests, tokens...) {
+		if level := r.refill(l, now); level < 1 {
+			wait = max(wait, time.Duration((1-level)*float64(l.per)/float64(l.size)))
+		}
+	}
+	if wait > 0 {
+		return wait.Truncate(time.Second) + time.Second
+	}
+	for _, l := range requests {
+		r.buckets[l.key].level--
+	}
+	return 0
+}
+
+// Notify reports whether a limited sender should be told so. It returns true
+// once per wait for each sender in each channel, so notices don't add to the
+// traffic being limited.
+func (r *RateLimiter) Notify(ctx ChatContextInterface, wait time.Duration) bool {
+	key := SenderIdentity(ctx) + " " + girc.ToRFC1459(ctx.GetChannelName())
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	if now.Before(r.notified[key]) {
+		return false
+	}
+	r.notified[key] = now.Add(wait)
+	return true
+}
+
+// Spend takes the tokens a finished request used from the sender's token
+// buckets. Buckets may go negative, delaying the next request accordingly.
+func (r *RateLimiter) Spend(ctx ChatContextInterface, used int) {
+	if used <= 0 || exempt(ctx) {
+		return
+	}
+	_, tokens := r.limits(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	for _, l := range tokens {
+		r.refill(l, now)
+		r.buckets[l.key].level -= float64(used)
+	}
+}
+
+// limits returns the enabled request and token limits for the sender
+func (r *RateLimiter) limits(ctx ChatContextInterface) (requests, tokens []limit) {
+	cfg := ctx.GetConfig().Bot
+	user := "user:" + SenderIdentity(ctx)
+	add := func(list []limit, key string, size int, per time.Duration) []limit {
+		if size <= 0 {
+			return list
+		}
+		return append(list, limit{key: key, size: size, per: per})
+	}
+	requests = add(requests, "rpm:"+user, cfg.UserRPM, time.Minute)
+	tokens = add(tokens, "tph:"+user, cfg.UserTPH, time.Hour)
+	if channel := ctx.GetChannelName(); channel != "" {
+		channel = "channel:" + girc.ToRFC1459(channel)
+		requests = add(requests, "rpm:"+channel, cfg.ChannelRPM, time.Minute)
+		tokens = add(tokens, "tph:"+channel, cfg.ChannelTPH, time.Hour)
+	}
+	return requests, tokens
+}
+
+// refill tops up a bucket for the time passed since it was last used and
+// returns its level. New buckets start full.
+func (r *RateLimiter) refill(l limit, now time.Time) float64 {
+	size := float64(l.size)
+	b, ok := r.buckets[l.key]
+	if !ok {
+		b = &bucket{level: size, last: now}
+		r.buckets[l.key] = b
+		return b.level
+	}
+	b.level = min(size, b.level+now.Sub(b.last).Seconds()*size/l.per.Seconds())
+	b.last = now
+	return b.level
+}
+
+// sweep drops buckets that have been idle for longer than any limit period,
+// since they would be full again
+func (r *RateLimiter) sweep(now time.Time) {
+	if now.Sub(r.swept) < time.Hour {
+		return
+	}
+	r.swept = now
+	for key, b := range r.buckets {
+		if now.Sub(b.last) > time.Hour && b.level >= 0 {
+			delete(r.buckets, key)
+		}
+	}
+	for key, until := range r.notified {
+		if now.After(until) {
+			delete(r.notified, key)
+		}
+	}
+}
+
+// exempt reports whether the sender is a configured admin or owner. Without
+// any configured, everyone holds owner, and nobody is exempt.
+func exempt(ctx ChatContextInterface) bool {
+	cfg := ctx.GetConfig()
+	configured := len(cfg.Bot.Admins) > 0
+	if cfg.Permissions != nil {
+		configured = configured || len(cfg.Permissions.Members[config.RoleOwner]) > 0
+	}
+	return configured && ctx.GetRole() == config.RoleOwner
+}
@@ -0,0 +1,87 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"pkdindustries/soulshack/internal/config"
+	"pkdindustries/soulshack/internal/core"
+	mocktest "pkdindustries/soulshack/internal/testing"
+)
+
+func TestRateLimiter_Requests(t *testing.T) {
+	limiter := core.NewRateLimiter()
+	cfg := mocktest.DefaultTestConfig()
+	cfg.Bot.UserRPM = 2
+
+	alice := mocktest.NewMockContext().WithConfig(cfg).WithSource("alice")
+	for i := range 2 {
+		if wait := limiter.Allow(alice); wait != 0 {
+			t.Fatalf("request %d: expected to be allowed, got wait %s", i+1, wait)
+		}
+	}
+	wait := limiter.Allow(alice)
+	if wait <= 0 || wait > 31*time.Second {
+		t.Errorf("expected a wait of up to 31s, got %s", wait)
+	}
+
+	bob := mocktest.NewMockContext().WithConfig(cfg).WithSource("bob")
+	if wait := limiter.Allow(bob); wait != 0 {
+		t.Errorf("expected another user to have their own bucket, got wait %s", wait)
+	}
+
+	cfg.Bot.Admins = []string{"alice!*@*"}
+	admin := mocktest.NewMockContext().WithConfig(cfg).WithSource("alice").WithAdmin(true)
+	if wait := limiter.Allow(admin); wait != 0 {
+		t.Errorf("expected admins to be exempt, got wait %s", wait)
+	}
+}
+
+func TestRateLimiter_NoAdminsConfigured(t *testing.T) {
+	limiter := core.NewRateLimiter()
+	cfg := mocktest.DefaultTestConfig()
+	cfg.Bot.Admins = nil
+	cfg.Bot.UserRPM = 1
+
+	// With no admins or roles configured everyone holds owner, which must
+	// not exempt them
+	alice := mocktest.NewMockContext().WithConfig(cfg).WithSource("alice").WithRole(config.RoleOwner)
+	if wait := limiter.Allow(alice); wait != 0 {
+		t.Fatalf("expected first request to be allowed, got wait %s", wait)
+	}
+	if wait := limiter.Allow(alice); wait == 0 {
+		t.Error("expected a non-admin to be limited when no admins are configured")
+	}
+
+	cfg.Permissions = &config.Permissions{Members: map[config.Role][]string{config.RoleOwner: {"$a:alice"}}}
+	if wait := limiter.Allow(alice); wait != 0 {
+		t.Errorf("expected a configured owner to be exempt, got wait %s", wait)
+	}
+}
+
+func TestRateLimiter_ChannelTokens(t *testing.T) {
+	limiter := core.NewRateLimiter()
+	cfg := mocktest.DefaultTestConfig()
+	cfg.Bot.ChannelTPH = 1000
+
+	alice := mocktest.NewMockContext().WithConfig(cfg).WithSource("alice")
+	if wait := limiter.Allow(alice); wait != 0 {
+		t.Fatalf("expected first request to be allowed, got wait %s", wait)
+	}
+	limiter.Spend(alice, 1500)
+
+	bob := mocktest.NewMockContext().WithConfig(cfg).WithSource("bob")
+	if wait := limiter.Allow(bob); wait < 30*time.Minute {
+		t.Errorf("expected the channel to wait off its overspend, got %s", wait)
+	}
+
+	other := mocktest.NewMockContext().WithConfig(cfg).WithSource("carol").WithChannelName("#TEST")
+	if wait := limiter.Allow(other); wait < 30*time.Minute {
+		t.Errorf("expected the channel's bucket shared regardless of case, got %s", wait)
+	}
+
+	private := mocktest.NewMockContext().WithConfig(cfg).WithSource("bob").WithChannelName("")
+	if wait := limiter.Allow(private); wait != 0 {
+		t.Errorf("expected private messages to skip channel limits, got wait %s", wait)
+	}
+}
@@ -1,5 +1,11 @@
 package core
 
+// Typing states for IRCv3 +typing notifications
+const (
+	TypingActive = "active"
+	TypingPaused = "paused"
+)
+
 // ChannelInfo represents the state of an IRC-ish channel
 type ChannelInfo struct {
 	Name  string
@@ -18,6 +24,30 @@ type UserInfo struct {
 	Channels []string
 }
 
+// Identity names the user for per-user limits and accounting: their services
+// account as $a:name if logged in, else ident@host, so changing nick does not
+// start them over. It is empty when neither is known.
+func (u *UserInfo) Identity() string {
+	switch {
+	case u == nil:
+		return ""
+	case u.Account != "" && u.Account != "*":
+		return "$a:" + u.Account
+	case u.Host != "":
+		return u.Ident + "@" + u.Host
+	}
+	return ""
+}
+
+// SenderIdentity returns the Identity of the event's sender, or their nick
+// when the bot doesn't know them
+func SenderIdentity(ctx ChatContextInterface) string {
+	if id := ctx.GetUser(ctx.GetSource()).Identity(); id != "" {
+		return id
+	}
+	return ctx.GetSource()
+}
+
 // ChannelUser represents a user in the context of a channel
 type ChannelUser struct {
 	Nick    string
@@ -2,7 +2,9 @@ package irc
 
 import (
 	"bytes"
+	"slices"
 	"strings"
+	"unicode/utf8"
 )
 
 // Chunker handles chunking of content for IRC message limits.
@@ -23,8 +25,9 @@ func NewChunker(output chan<- string, maxChunkSize int) *Chunker {
 	}
 }
 
-// Write adds content to the buffer and emits complete lines immediately.
-// If the buffer grows too large, it forces a chunk to be emitted.
+// Write adds content to the buffer and emits complete lines immediately,
+// split if they are over the maximum chunk size. If the buffer grows too
+// large, it forces chunks to be emitted.
 func (c *Chunker) Write(content string) {
 	c.buffer.WriteString(content)
 
@@ -38,46 +41,112 @@ func (c *Chunker) Write(content string) {
 			}
 			break
 		}
-		// Remove the newline and send
-		if line = strings.TrimSuffix(line, "\n"); line != "" {
-			c.output <- line
+		c.emit(strings.TrimSuffix(line, "\n"))
+	}
+
+	// If buffer is getting too large, force chunks
+	for c.buffer.Len() >= c.maxChunkSize {
+		chunk, rest := splitChunk(c.buffer.String(), c.maxChunkSize)
+		c.buffer.Reset()
+		c.buffer.WriteString(rest)
+		if chunk != "" {
+			c.output <- chunk
 		}
 	}
+}
 
-	// If buffer is getting too large, force a chunk
-	if c.buffer.Len() >= c.maxChunkSize {
-		chunk := c.extractBestSplitChunk()
+// emit sends a line, in chunks if it is over the maximum chunk size
+func (c *Chunker) emit(line string) {
+	for len(line) > c.maxChunkSize {
+		var chunk string
+		chunk, line = splitChunk(line, c.maxChunkSize)
 		if chunk != "" {
 			c.output <- chunk
 		}
 	}
+	if line != "" {
+		c.output <- line
+	}
 }
 
-func (c *Chunker) extractBestSplitChunk() string {
-	if c.buffer.Len() == 0 {
-		return ""
+// splitChunk splits a chunk of at most max bytes off the front of s,
+// preferring a space with no formatting open around it, then any space,
+// then a hard break. Formatting open at the split is closed at the end of
+// the chunk and reopened at the start of the rest, so each message renders
+// on its own. Color codes and runes are never split.
+func splitChunk(s string, max int) (chunk, rest string) {
+	clean, spaced, hard := -1, -1, -1
+	var open, spacedOpen, hardOpen []string // formatting codes open, in the order opened
+	for i := 0; i < len(s) && i <= max; {
+		closing := len(closeCodes(open))
+		if i > 0 && i+closing <= max && utf8.RuneStart(s[i]) {
+			hard, hardOpen = i, open
+		}
+		if i > 0 && i < max && s[i] == ' ' {
+			if len(open) == 0 {
+				clean = i
+			}
+			if i+closing <= max {
+				spaced, spacedOpen = i, open
+			}
+		}
+
+		n := 1
+		if strings.IndexByte(formatBytes, s[i]) >= 0 {
+			code := ircCodes.FindString(s[i:min(len(s), i+len("\x0399,99"))])
+			open = toggleCode(slices.Clone(open), code)
+			n = len(code)
+		}
+		i += n
 	}
 
-	data := c.buffer.Bytes()
-	end := min(c.maxChunkSize, len(data))
+	switch {
+	case clean >= max/2 || clean > 0 && spaced < 0:
+		return s[:clean], s[clean+1:]
+	case spaced > 0:
+		return s[:spaced] + closeCodes(spacedOpen), strings.Join(spacedOpen, "") + s[spaced+1:]
+	case hard > 0:
+		return s[:hard] + closeCodes(hardOpen), strings.Join(hardOpen, "") + s[hard:]
+	}
+	return s[:max], s[max:]
+}
 
-	// Try to find a space within the allowed range to break cleanly
-	if idx := bytes.LastIndexByte(data[:end], ' '); idx > 0 {
-		chunk := string(data[:idx])
-		c.buffer.Next(idx + 1) // Skip the space itself
-		return chunk
+// toggleCode applies a formatting code to the codes open. A code opens
+// unless it is already open, colors replace any color open, and a bare
+// color code or reset closes them.
+func toggleCode(open []string, code string) []string {
+	switch {
+	case code == "\x0f":
+		return nil
+	case strings.HasPrefix(code, ircColor):
+		open = slices.DeleteFunc(open, func(c string) bool { return strings.HasPrefix(c, ircColor) })
+		if code != ircColor {
+			open = append(open, code)
+		}
+		return open
+	}
+	if i := slices.Index(open, code); i >= 0 {
+		return slices.Delete(open, i, i+1)
 	}
+	return append(open, code)
+}
 
-	// If no space is found, hard break at maxChunkSize
-	chunk := string(data[:end])
-	c.buffer.Next(end)
-	return chunk
+// closeCodes returns the codes that close those open, in reverse order
+func closeCodes(open []string) string {
+	var b strings.Builder
+	for _, code := range slices.Backward(open) {
+		if strings.HasPrefix(code, ircColor) {
+			code = ircColor
+		}
+		b.WriteString(code)
+	}
+	return b.String()
 }
 
 // Flush emits any remaining buffer content.
 func (c *Chunker) Flush() {
 	if c.buffer.Len() > 0 {
-		c.output <- c.buffer.String()
+		c.emit(c.buffer.String())
 		c.buffer.Reset()
 	}
 }
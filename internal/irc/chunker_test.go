@@ -1,6 +1,7 @@
 package irc
 
 import (
+	"slices"
 	"testing"
 )
 
@@ -107,3 +108,42 @@ func TestChunker_Flush(t *testing.T) {
 		t.Error("expected flush to emit remaining content")
 	}
 }
+
+func TestChunker_LongLine(t *testing.T) {
+	ch := make(chan string, 10)
+	chunker := NewChunker(ch, 20)
+
+	// A complete line over the limit is split, closing and reopening the
+	// formatting open across the split
+	chunker.Write("plain words \x02then bold words\x02\n")
+	close(ch)
+
+	var got []string
+	for msg := range ch {
+		got = append(got, msg)
+	}
+	want := []string{"plain words", "\x02then bold words\x02"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestChunker_SplitInsideFormatting(t *testing.T) {
+	ch := make(chan string, 10)
+	chunker := NewChunker(ch, 16)
+
+	chunker.Write("\x0312a long link text here\x03\n")
+	close(ch)
+
+	var got []string
+	for msg := range ch {
+		if len(msg) > 16 {
+			t.Errorf("chunk of %d bytes: %q", len(msg), msg)
+		}
+		got = append(got, msg)
+	}
+	want := []string{"\x0312a long link\x03", "\x0312text here\x03"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -10,6 +10,8 @@ import (
 
 	"github.com/alexschlessinger/pollytool/sessions"
 	"github.com/lrstanley/girc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"pkdindustries/soulshack/internal/config"
 	"pkdindustries/soulshack/internal/core"
@@ -24,16 +26,20 @@ type ChatContext struct {
 	Session   sessions.Session
 	Config    *config.Configuration
 	client    *girc.Client
+	queue     *SendQueue
 	event     *girc.Event
+	channel   string
 	args      []string
 	logger    *slog.Logger
 	requestID string
 	fatalCh   chan<- error
+	tags      bool // the server supports message-tags
+	thread    *replyThread
 }
 
 var _ ChatContextInterface = (*ChatContext)(nil)
 
-func NewChatContext(parentctx context.Context, config *config.Configuration, system core.System, ircclient *girc.Client, e *girc.Event, fatalCh chan<- error) (ChatContextInterface, context.CancelFunc) {
+func NewChatContext(parentctx context.Context, config *config.Configuration, system core.System, ircclient *girc.Client, queue *SendQueue, e *girc.Event, fatalCh chan<- error) (ChatContextInterface, context.CancelFunc) {
 	timedctx, cancel := context.WithTimeout(parentctx, config.API.Timeout)
 
 	// Generate a unique request ID for correlation
@@ -42,22 +48,30 @@ func NewChatContext(parentctx context.Context, config *config.Configuration, sys
 	// Ensure Source is not nil for events like CONNECTED
 	if e.Source == nil {
 		e.Source = &girc.Source{
-			Name: config.Server.Channel,
+			Name: config.Server.Server,
 		}
 	}
 
 	// Get channel safely
-	channel := config.Server.Channel
+	channel := ""
 	if len(e.Params) > 0 {
 		channel = e.Params[0]
 	}
 
+	// Root span for everything this event causes
+	spanctx, span := core.Tracer().Start(timedctx, "irc."+strings.ToLower(e.Command), trace.WithAttributes(
+		attribute.String("request_id", requestID),
+		attribute.String("irc.source", e.Source.Name),
+	))
+
 	ctx := ChatContext{
-		Context:   timedctx,
+		Context:   spanctx,
 		Config:    config,
 		Sys:       system,
 		client:    ircclient,
+		queue:     queue,
 		event:     e,
+		channel:   channel,
 		args:      strings.Fields(e.Last()),
 		requestID: requestID,
 		fatalCh:   fatalCh,
@@ -71,11 +85,18 @@ func NewChatContext(parentctx context.Context, config *config.Configuration, sys
 	if ctx.IsAddressed() {
 		ctx.args = ctx.args[1:]
 	}
+	ctx.tags = ircclient.HasCapability("message-tags")
+	ctx.thread = newReplyThread(e, ctx.tags)
 
-	key := channel
-	if !girc.IsValidChannel(key) {
-		key = e.Source.Name
+	if !girc.IsValidChannel(channel) {
+		ctx.channel = ""
 	}
+	span.SetAttributes(attribute.String("irc.channel", ctx.channel))
+
+	// Layer any per-channel overrides over the global config
+	ctx.Config = config.ForChannel(ctx.channel)
+
+	key := ctx.GetLockKey()
 
 	session, err := ctx.Sys.GetSessionStore().Get(key)
 	if err != nil {
@@ -83,13 +104,17 @@ func NewChatContext(parentctx context.Context, config *config.Configuration, sys
 		os.Exit(1)
 	}
 	ctx.Session = session
-	return &ctx, cancel
+	return &ctx, func() {
+		span.End()
+		cancel()
+	}
 }
 
 func (c ChatContext) GetSystem() core.System {
 	return c.Sys
 }
 
+// GetConfig returns the effective config for the event's channel
 func (c ChatContext) GetConfig() *config.Configuration {
 	return c.Config
 }
@@ -128,7 +153,7 @@ func (c ChatContext) Join(channel string) bool {
 }
 
 func (c ChatContext) JoinWithKey(channel, key string) bool {
-	c.client.Cmd.Join(channel, key)
+	c.client.Cmd.JoinKey(channel, key)
 	return true
 }
 
@@ -156,35 +181,161 @@ func (c ChatContext) GetSource() string {
 	return c.event.Source.Name
 }
 
+// IsAdmin reports whether the sender holds the owner role
 func (c ChatContext) IsAdmin() bool {
-	hostmask := c.event.Source.String()
-	c.logger.Debug("admin_check", "hostmask", hostmask)
-	isAdmin := CheckAdmin(hostmask, c.Config.Bot.Admins)
-	if isAdmin && len(c.Config.Bot.Admins) == 0 {
+	return c.GetRole() == config.RoleOwner
+}
+
+// GetRole returns the highest role the sender holds in the event's channel
+func (c ChatContext) GetRole() config.Role {
+	sender := Sender{
+		Hostmask: c.event.Source.String(),
+		Account:  c.account(),
+	}
+	if c.channel != "" && c.client != nil {
+		if user := c.client.LookupUser(c.event.Source.Name); user != nil {
+			if perms, ok := user.Perms.Lookup(c.channel); ok {
+				sender.Op, sender.Voice = perms.IsAdmin(), perms.IsTrusted()
+			}
+		}
+	}
+	role := MatchRole(sender, c.Config.Bot.Admins, c.Config.Permissions)
+	if role == config.RoleOwner && len(c.Config.Bot.Admins) == 0 && !c.Config.Permissions.HasMembers() {
 		c.logger.Debug("admin_check_warning")
-	} else if isAdmin {
-		c.logger.Debug("admin_verified", "hostmask", hostmask)
 	}
-	return isAdmin
+	c.logger.Debug("role_check", "hostmask", sender.Hostmask, "account", sender.Account, "role", role)
+	return role
 }
 
+// account returns the services account of the event's sender, from the
+// IRCv3 account tag or the client's user tracking
+func (c ChatContext) account() string {
+	account, ok := c.event.Tags.Get("account")
+	if !ok && c.client != nil {
+		if user := c.GetUser(c.event.Source.Name); user != nil {
+			account = user.Account
+		}
+	}
+	if account == "*" || account == "0" {
+		return "" // not logged in
+	}
+	return account
+}
+
+// Reply sends a line to the channel or nick the event came from, threaded to
+// the message that triggered it
 func (c ChatContext) Reply(message string) {
-	c.client.Cmd.Reply(*c.event, message)
+	target, channel := c.replyTarget()
+	tags, message := c.thread.apply(message, channel)
+	c.send(target, message, false, tags)
+}
 
+// Multiline reports whether ReplyMultiline sends lines as one message, and
+// the most bytes such a message can hold
+func (c ChatContext) Multiline() (int, bool) {
+	limits, ok := c.multiline()
+	return limits.MaxBytes, ok
+}
+
+// multiline returns the server's multiline limits when batches can be sent
+func (c ChatContext) multiline() (MultilineLimits, bool) {
+	if c.queue == nil || !c.tags {
+		return MultilineLimits{}, false // girc drops the batch tag without message-tags
+	}
+	return c.queue.Multiline()
+}
+
+// ReplyMultiline replies with lines grouped into as few draft/multiline
+// batches as the server's limits allow, so clients show them as one message.
+// Lines longer than chunkmax are split within the batch and rejoined by the
+// client. Without multiline each line is a separate Reply.
+func (c ChatContext) ReplyMultiline(lines []string) {
+	limits, ok := c.multiline()
+	if !ok {
+		for _, line := range lines {
+			c.Reply(line)
+		}
+		return
+	}
+
+	width := c.Config.Session.ChunkMax
+	target, channel := c.replyTarget()
+	for _, batch := range splitBatches(lines, limits, width) {
+		if len(batch) == 1 && len(splitLine(batch[0], width)) == 1 {
+			c.Reply(batch[0])
+			continue
+		}
+		var tags girc.Tags
+		tags, batch[0] = c.thread.apply(batch[0], channel)
+
+		_, span := core.Tracer().Start(c.Context, "irc.send", trace.WithAttributes(
+			attribute.String("irc.target", target),
+			attribute.Int("irc.lines", len(batch)),
+		))
+		c.queue.Batch(c.Context, target, batch, tags)
+		span.End()
+	}
+}
+
+// replyTarget returns where replies go: the event's channel, or the sender
+// for private messages
+func (c ChatContext) replyTarget() (target string, channel bool) {
+	if len(c.event.Params) > 0 && girc.IsValidChannel(c.event.Params[0]) {
+		return c.event.Params[0], true
+	}
+	return c.event.Source.Name, false
+}
+
+// Typing tells the reply target that the bot is typing (IRCv3 +typing). The
+// notification is paced with the replies, behind any still queued.
+func (c ChatContext) Typing(state string) {
+	if !c.tags {
+		return
+	}
+	target, _ := c.replyTarget()
+	if c.queue != nil {
+		c.queue.Typing(target, state)
+		return
+	}
+	tags := girc.Tags{}
+	if err := tags.Set("+typing", state); err != nil {
+		return
+	}
+	c.client.Send(&girc.Event{Command: girc.CAP_TAGMSG, Params: []string{target}, Tags: tags})
 }
 
 func (c ChatContext) SendAction(target, message string) {
-	c.client.Cmd.Action(target, message)
+	c.send(target, message, true, nil)
 }
 
 func (c ChatContext) ReplyAction(message string) {
 	target := c.event.Params[0]
 	if !girc.IsValidChannel(target) {
 		// For PMs, send a regular message instead of an action
-		c.client.Cmd.Message(c.event.Source.Name, message)
+		c.send(c.event.Source.Name, message, false, nil)
 		return
 	}
-	c.client.Cmd.Action(target, message)
+	c.send(target, message, true, nil)
+}
+
+// send writes a line through the outbound queue, waiting for its turn
+func (c ChatContext) send(target, message string, action bool, tags girc.Tags) {
+	_, span := core.Tracer().Start(c.Context, "irc.send", trace.WithAttributes(
+		attribute.String("irc.target", target),
+		attribute.Int("irc.bytes", len(message)),
+	))
+	defer span.End()
+
+	switch {
+	case c.queue != nil && action:
+		c.queue.Action(c.Context, target, message)
+	case c.queue != nil:
+		c.queue.MessageTags(c.Context, target, message, tags)
+	case action:
+		c.client.Cmd.Action(target, message)
+	default:
+		c.client.Send(&girc.Event{Command: girc.PRIVMSG, Params: []string{target, message}, Tags: tags})
+	}
 }
 
 func (c ChatContext) SetMode(target, flags string, args ...string) bool {
@@ -266,14 +417,23 @@ func (c ChatContext) GetChannelUsers(channel string) []core.ChannelUser {
 	return result
 }
 
+// GetChannelName returns the channel the event was received on,
+// or an empty string for private messages and server events
+func (c ChatContext) GetChannelName() string {
+	return c.channel
+}
+
+// GetLockKey returns the key used to serialize requests and look up the
+// session: the event's channel, or the sender's nick for private messages,
+// case-folded so #Dev and #dev share one
 func (c ChatContext) GetLockKey() string {
-	if len(c.event.Params) > 0 && girc.IsValidChannel(c.event.Params[0]) {
-		return c.Config.Server.Channel
+	if c.channel != "" {
+		return girc.ToRFC1459(c.channel)
 	}
 	if c.event.Source != nil {
-		return c.event.Source.Name
+		return girc.ToRFC1459(c.event.Source.Name)
 	}
-	return c.Config.Server.Channel
+	return ""
 }
 
 func (c ChatContext) IsOp(channel, nick string) bool {
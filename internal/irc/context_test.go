@@ -0,0 +1,25 @@
+package irc
+
+import (
+	"testing"
+
+	"github.com/lrstanley/girc"
+)
+
+func TestChatContext_GetLockKey(t *testing.T) {
+	tests := []struct {
+		channel string
+		nick    string
+		want    string
+	}{
+		{"#Dev", "alice", "#dev"},
+		{"#dev[1]", "alice", "#dev{1}"},
+		{"", "Alice", "alice"},
+	}
+	for _, tt := range tests {
+		c := ChatContext{channel: tt.channel, event: &girc.Event{Source: &girc.Source{Name: tt.nick}}}
+		if got := c.GetLockKey(); got != tt.want {
+			t.Errorf("GetLockKey(%q, %q) = %q, want %q", tt.channel, tt.nick, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package irc
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"pkdindustries/soulshack/internal/config"
+)
+
+// mIRC formatting codes
+const (
+	ircBold          = "\x02"
+	ircColor         = "\x03"
+	ircItalic        = "\x1d"
+	ircStrikethrough = "\x1e"
+	ircMonospace     = "\x11"
+)
+
+// Colors for links and quotes, two digits so text starting with a digit
+// isn't read as part of the code
+const (
+	ircLinkColor  = "12" // light blue
+	ircQuoteColor = "14" // grey
+)
+
+var (
+	mdHeading = regexp.MustCompile(`^\s{0,3}#{1,6}\s+(.*?)(\s+#+)?\s*$`)
+	mdRule    = regexp.MustCompile(`^\s{0,3}((-\s*){3,}|(\*\s*){3,}|(_\s*){3,})$`)
+	mdBullet  = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	mdQuote   = regexp.MustCompile(`^\s{0,3}>\s?(.*)$`)
+	mdTable   = regexp.MustCompile(`^\s*\|?(\s*:?-+:?\s*\|)+\s*(:?-+:?)?\s*$`)
+)
+
+// ircCodes matches mIRC formatting codes, including color numbers
+var ircCodes = regexp.MustCompile(`\x03(\d{1,2}(,\d{1,2})?)?|[\x02\x0f\x11\x16\x1d\x1e\x1f]`)
+
+// formatBytes are the bytes that start an mIRC formatting code
+const formatBytes = "\x02\x03\x0f\x11\x16\x1d\x1e\x1f"
+
+// mdInline are the characters that open inline markdown
+const mdInline = "*_~`["
+
+// mdEscapable are the characters a backslash escapes
+const mdEscapable = "\\`*_{}[]()#+-.!~|>"
+
+// Renderer converts the markdown models write into IRC formatting on its way
+// to the Chunker. Markdown is rendered a whole line at a time, so content is
+// held until its line is complete, or until a long line reaches a space
+// where what came before can't render differently.
+type Renderer struct {
+	chunker   *Chunker
+	mode      string
+	buffer    strings.Builder
+	code      bool // inside a fenced code block
+	continued bool // the buffer continues a line already partly passed on
+}
+
+// NewRenderer creates a renderer writing to the chunker. The mode is one of
+// config.FormatIRC, FormatStrip or FormatRaw.
+func NewRenderer(chunker *Chunker, mode string) *Renderer {
+	return &Renderer{chunker: chunker, mode: mode}
+}
+
+// Write adds content, rendering and passing on each complete line
+func (r *Renderer) Write(content string) {
+	if r.mode == config.FormatRaw {
+		r.chunker.Write(content)
+		return
+	}
+	r.buffer.WriteString(content)
+	text := r.buffer.String()
+	end := strings.LastIndexByte(text, '\n')
+	if end < 0 {
+		if len(text) >= r.chunker.maxChunkSize {
+			r.writePrefix(text)
+		}
+		return
+	}
+	r.buffer.Reset()
+	r.buffer.WriteString(text[end+1:])
+	for line := range strings.SplitSeq(text[:end], "\n") {
+		r.chunker.Write(r.render(line) + "\n")
+		r.continued = false
+	}
+	if r.buffer.Len() >= r.chunker.maxChunkSize {
+		r.writePrefix(r.buffer.String())
+	}
+}
+
+// Flush renders any partial line and flushes the chunker
+func (r *Renderer) Flush() {
+	if r.buffer.Len() > 0 {
+		r.chunker.Write(r.render(r.buffer.String()))
+		r.buffer.Reset()
+	}
+	r.continued = false
+	r.chunker.Flush()
+}
+
+// writePrefix passes on the start of a long partial line, up to the last
+// space where it renders the same on its own as within the line so far and
+// leaves no markdown open that later content could close. Only the last
+// chunk's worth of spaces is tried, as anything longer is split by the
+// Chunker anyway. Code blocks, headings and quotes are formatted as a whole,
+// so they wait for the line to complete and the Chunker splits them.
+func (r *Renderer) writePrefix(text string) {
+	trimmed := strings.TrimSpace(text)
+	if r.code || !r.continued && (strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")) {
+		return
+	}
+	whole := r.render(text)
+	from := len(text) - r.chunker.maxChunkSize
+	for i := strings.LastIndexByte(text, ' '); i > 0 && i >= from; i = strings.LastIndexByte(text[:i], ' ') {
+		head := r.render(text[:i])
+		if head == "" || strings.ContainsAny(head, mdInline) || !strings.HasPrefix(whole, head+" ") {
+			continue
+		}
+		r.chunker.Write(head + "\n")
+		r.buffer.Reset()
+		r.buffer.WriteString(text[i+1:])
+		r.continued = true
+		return
+	}
+}
+
+// render renders a line, or the rest of one already partly passed on
+func (r *Renderer) render(line string) string {
+	if r.continued {
+		return r.inline(line)
+	}
+	return r.renderLine(line)
+}
+
+// renderLine renders one line of markdown. Lines that only carry markdown
+// structure, such as code fences and rules, render empty and are dropped by
+// the Chunker.
+func (r *Renderer) renderLine(line string) string {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+		r.code = !r.code
+		return ""
+	}
+	if r.code {
+		if trimmed == "" {
+			return ""
+		}
+		return r.wrap(ircMonospace, line)
+	}
+
+	if m := mdHeading.FindStringSubmatch(line); m != nil {
+		return r.wrap(ircBold, r.inline(m[1]))
+	}
+	if mdRule.MatchString(line) || mdTable.MatchString(line) {
+		return ""
+	}
+	if m := mdBullet.FindStringSubmatch(line); m != nil {
+		return m[1] + "• " + r.inline(m[2])
+	}
+	if m := mdQuote.FindStringSubmatch(line); m != nil {
+		return r.color(ircQuoteColor, "> "+r.inline(m[1]))
+	}
+	return r.inline(line)
+}
+
+// inline renders emphasis, code spans, links and escapes within a line
+func (r *Renderer) inline(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		switch c := s[i]; c {
+		case '\\':
+			if i+1 < len(s) && strings.IndexByte(mdEscapable, s[i+1]) >= 0 {
+				b.WriteByte(s[i+1])
+				i += 2
+				continue
+			}
+		case '`':
+			n := runLength(s, i)
+			fence := s[i : i+n]
+			if end := strings.Index(s[i+n:], fence); end >= 0 {
+				b.WriteString(r.wrap(ircMonospace, s[i+n:i+n+end]))
+				i += n + end + n
+				continue
+			}
+			b.WriteString(fence)
+			i += n
+			continue
+		case '[':
+			if text, url, n, ok := parseLink(s[i:]); ok {
+				b.WriteString(r.link(r.inline(text), url))
+				i += n
+				continue
+			}
+		case '*', '_', '~':
+			if inner, codes, n, ok := emphasis(s, i); ok {
+				b.WriteString(r.wrap(codes, r.inline(inner)))
+				i += n
+				continue
+			}
+			// An unmatched run stays as it is, rather than opening a
+			// shorter one
+			n := runLength(s, i)
+			b.WriteString(s[i : i+n])
+			i += n
+			continue
+		}
+		b.WriteByte(s[i])
+		i++
+	}
+	return b.String()
+}
+
+// emphasis matches the delimiter run at s[i] with its closing run, returning
+// the text between them, the codes to wrap it in and the length consumed
+func emphasis(s string, i int) (inner, codes string, n int, ok bool) {
+	c := s[i]
+	d := runLength(s, i)
+	switch {
+	case c == '~' && d == 2:
+		codes = ircStrikethrough
+	case c != '~' && d == 1:
+		codes = ircItalic
+	case c != '~' && d == 2:
+		codes = ircBold
+	case c != '~' && d == 3:
+		codes = ircBold + ircItalic
+	default:
+		return "", "", 0, false
+	}
+
+	// Opening runs are followed by text, and underscores can't open inside a
+	// word, as in snake_case. Closing runs follow text and end a word; a
+	// run of the same length is preferred, then a longer one, which also
+	// closes emphasis nested inside, as in **bold *italic***.
+	start := i + d
+	if start >= len(s) || s[start] == ' ' || c == '_' && i > 0 && isWordByte(s[i-1]) {
+		return "", "", 0, false
+	}
+	for _, exact := range []bool{true, false} {
+		for j := start + 1; j < len(s); j++ {
+			if s[j] != c || s[j-1] == ' ' || s[j-1] == c {
+				continue
+			}
+			end := j + runLength(s, j)
+			if end-j < d || exact && end-j != d || end < len(s) && isWordByte(s[end]) {
+				continue
+			}
+			return s[start : end-d], codes, end - i, true
+		}
+	}
+	return "", "", 0, false
+}
+
+// parseLink reads a [text](url) link at the start of s
+func parseLink(s string) (text, url string, n int, ok bool) {
+	mid := strings.Index(s, "](")
+	if mid < 0 {
+		return "", "", 0, false
+	}
+	end := strings.IndexByte(s[mid+2:], ')')
+	if end < 0 {
+		return "", "", 0, false
+	}
+	text, url = s[1:mid], strings.TrimSpace(s[mid+2:mid+2+end])
+	if url == "" || strings.Contains(url, " ") {
+		return "", "", 0, false
+	}
+	return text, url, mid + 2 + end + 1, true
+}
+
+// link renders a link as its text followed by the URL. The URL is left
+// unformatted so clients can still detect it.
+func (r *Renderer) link(text, url string) string {
+	if text == "" || text == url {
+		return url
+	}
+	return r.color(ircLinkColor, text) + " (" + url + ")"
+}
+
+// wrap surrounds text with formatting codes, closing them in reverse order.
+// Stripping leaves the text alone.
+func (r *Renderer) wrap(codes, text string) string {
+	if r.mode == config.FormatStrip || text == "" {
+		return text
+	}
+	closing := []byte(codes)
+	slices.Reverse(closing)
+	return codes + text + string(closing)
+}
+
+// color renders text in an mIRC color
+func (r *Renderer) color(color, text string) string {
+	if r.mode == config.FormatStrip || text == "" {
+		return text
+	}
+	return ircColor + color + text + ircColor
+}
+
+// StripFormatting removes mIRC formatting codes from text
+func StripFormatting(text string) string {
+	return ircCodes.ReplaceAllString(text, "")
+}
+
+// runLength counts the repeats of the byte at s[i]
+func runLength(s string, i int) int {
+	n := 1
+	for i+n < len(s) && s[i+n] == s[i] {
+		n++
+	}
+	return n
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
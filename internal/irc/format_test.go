@@ -0,0 +1,144 @@
+package irc
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"pkdindustries/soulshack/internal/config"
+)
+
+func TestRenderer_RenderLine(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  string
+		irc   string
+		strip string
+	}{
+		{"plain", "just text", "just text", "just text"},
+		{"bold", "a **bold** word", "a \x02bold\x02 word", "a bold word"},
+		{"bold underscores", "a __bold__ word", "a \x02bold\x02 word", "a bold word"},
+		{"italic", "an *italic* word", "an \x1ditalic\x1d word", "an italic word"},
+		{"bold italic", "***both***", "\x02\x1dboth\x1d\x02", "both"},
+		{"nested", "**bold and *italic***", "\x02bold and \x1ditalic\x1d\x02", "bold and italic"},
+		{"nested inside", "*an **important** point*", "\x1dan \x02important\x02 point\x1d", "an important point"},
+		{"strikethrough", "~~gone~~", "\x1egone\x1e", "gone"},
+		{"code", "run `go test ./...` now", "run \x11go test ./...\x11 now", "run go test ./... now"},
+		{"code keeps markdown", "`**not bold**`", "\x11**not bold**\x11", "**not bold**"},
+		{"snake case", "set max_chunk_size here", "set max_chunk_size here", "set max_chunk_size here"},
+		{"arithmetic", "2 * 3 * 4", "2 * 3 * 4", "2 * 3 * 4"},
+		{"unmatched", "**open", "**open", "**open"},
+		{"escaped", `\*not italic\*`, "*not italic*", "*not italic*"},
+		{"heading", "## Setup ##", "\x02Setup\x02", "Setup"},
+		{"hashtag", "#soulshack is the channel", "#soulshack is the channel", "#soulshack is the channel"},
+		{"bullet", "- first *item*", "• first \x1ditem\x1d", "• first item"},
+		{"nested bullet", "  * second", "  • second", "  • second"},
+		{"numbered", "1. **step**", "1. \x02step\x02", "1. step"},
+		{"link", "see [the docs](https://example.com/docs)", "see \x0312the docs\x03 (https://example.com/docs)", "see the docs (https://example.com/docs)"},
+		{"bare link", "[https://example.com](https://example.com)", "https://example.com", "https://example.com"},
+		{"not a link", "[draft] (later)", "[draft] (later)", "[draft] (later)"},
+		{"quote", "> quoted", "\x0314> quoted\x03", "> quoted"},
+		{"rule", "---", "", ""},
+		{"table separator", "|---|:---:|", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewRenderer(nil, config.FormatIRC).renderLine(tt.line); got != tt.irc {
+				t.Errorf("irc: got %q, want %q", got, tt.irc)
+			}
+			if got := NewRenderer(nil, config.FormatStrip).renderLine(tt.line); got != tt.strip {
+				t.Errorf("strip: got %q, want %q", got, tt.strip)
+			}
+		})
+	}
+}
+
+func TestRenderer_Stream(t *testing.T) {
+	ch := make(chan string, 20)
+	r := NewRenderer(NewChunker(ch, 400), config.FormatIRC)
+
+	// Markdown split across writes is rendered once its line is complete
+	for _, content := range []string{"# Ti", "tle\nuse **b", "old** and:\n```go\n", "x := *p\n``", "`\nend *it", "alic*"} {
+		r.Write(content)
+	}
+	r.Flush()
+	close(ch)
+
+	var got []string
+	for line := range ch {
+		got = append(got, line)
+	}
+	want := []string{"\x02Title\x02", "use \x02bold\x02 and:", "\x11x := *p\x11", "end \x1ditalic\x1d"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRenderer_Raw(t *testing.T) {
+	ch := make(chan string, 10)
+	r := NewRenderer(NewChunker(ch, 400), config.FormatRaw)
+	r.Write("**as is**\n# kept")
+	r.Flush()
+	close(ch)
+
+	var got []string
+	for line := range ch {
+		got = append(got, line)
+	}
+	if want := []string{"**as is**", "# kept"}; !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestStripFormatting(t *testing.T) {
+	got := StripFormatting("\x02bold\x02 \x0312,01blue\x03 \x0314\x1dgrey\x1d\x03 \x11code\x11 10\x0f")
+	if want := "bold blue grey code 10"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRenderer_LongLine(t *testing.T) {
+	// A long paragraph streamed a word at a time, with bold spanning where
+	// it is split
+	words := strings.Fields(strings.Repeat("lorem ipsum dolor sit amet ", 40))
+	words[60] = "**" + words[60]
+	words[75] += "**"
+	plain := strings.ReplaceAll(strings.Join(words, " "), "**", "")
+
+	for _, mode := range []string{config.FormatIRC, config.FormatStrip, config.FormatRaw} {
+		t.Run(mode, func(t *testing.T) {
+			ch := make(chan string, 20)
+			r := NewRenderer(NewChunker(ch, 350), mode)
+			for _, word := range words {
+				r.Write(word + " ")
+			}
+			if len(ch) == 0 {
+				t.Error("nothing sent before the line completed")
+			}
+			r.Write("\n")
+			r.Flush()
+			close(ch)
+
+			var got []string
+			for line := range ch {
+				if len(line) > 350 {
+					t.Errorf("chunk of %d bytes: %q", len(line), line)
+				}
+				if strings.Count(line, ircBold)%2 != 0 {
+					t.Errorf("chunk splits a formatting pair: %q", line)
+				}
+				got = append(got, line)
+			}
+			if len(got) < 3 {
+				t.Errorf("got %d chunks, want the line split", len(got))
+			}
+			text := strings.Join(got, " ")
+			if mode == config.FormatRaw {
+				text = strings.ReplaceAll(text, "**", "")
+			}
+			if text = strings.TrimSpace(StripFormatting(text)); text != plain {
+				t.Errorf("got %q, want %q", text, plain)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package irc
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexschlessinger/pollytool/schema"
+	"github.com/alexschlessinger/pollytool/tools"
+
+	"pkdindustries/soulshack/internal/store"
+)
+
+// historyDefault and historyMax bound how many lines a history search returns
+const (
+	historyDefault = 10
+	historyMax     = 50
+)
+
+func newIrcHistorySearchTool() tools.Tool {
+	return &tools.Func{
+		Name: "irc__history_search",
+		Desc: "Search this channel's logged history for messages by text, nick and time range, newest first. Use it to find what someone said or pasted earlier",
+		Params: schema.Params{
+			"text":  schema.S("Words the message must contain; a word also matches longer words it starts, e.g. 'http' for links (optional)"),
+			"nick":  schema.S("Only messages from this nick (optional)"),
+			"since": schema.S("Only messages at or after this time: an age like '24h' or '7d', a date '2006-01-02' or '2006-01-02 15:04' (optional)"),
+			"until": schema.S("Only messages before this time, in the same forms; a date includes that whole day (optional)"),
+			"limit": schema.Int(fmt.Sprintf("How many lines to return (default %d, at most %d)", historyDefault, historyMax)),
+		},
+		Run: func(ctx context.Context, args tools.Args) (string, error) {
+			chatCtx, err := validateContext(ctx)
+			if err != nil {
+				return "", err
+			}
+
+			channel := chatCtx.GetChannelName()
+			if channel == "" {
+				return notInChannelMsg, nil
+			}
+			transcript := chatCtx.GetSystem().GetTranscript()
+			if transcript == nil {
+				return "Channel history is not being logged", nil
+			}
+
+			now := time.Now()
+			since, err := parseHistoryTime(args.String("since"), now, false)
+			if err != nil {
+				return "", err
+			}
+			until, err := parseHistoryTime(args.String("until"), now, true)
+			if err != nil {
+				return "", err
+			}
+			query := store.HistoryQuery{
+				Text:  args.String("text"),
+				Nick:  args.String("nick"),
+				Since: since,
+				Until: until,
+				Limit: min(max(args.Int("limit", historyDefault), 1), historyMax),
+			}
+
+			found, err := transcript.Search(channel, query)
+			if err != nil {
+				return "", err
+			}
+			chatCtx.GetLogger().Info("irc_history_search", "channel", channel, "text", query.Text, "nick", query.Nick, "found", len(found))
+			if len(found) == 0 {
+				return fmt.Sprintf("No messages found in %s", channel), nil
+			}
+
+			lines := make([]string, 0, len(found))
+			for _, line := range found {
+				lines = append(lines, line.Time.Format(time.DateOnly)+" "+line.Format())
+			}
+			return strings.Join(lines, "\n"), nil
+		},
+	}
+}
+
+// parseHistoryTime reads a time given as an age before now ("90m", "24h",
+// "7d") or a local date and time. A bare date is the start of that day, or
+// its end when endOfDay is set. Empty is the zero time.
+func parseHistoryTime(value string, now time.Time, endOfDay bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if age, err := time.ParseDuration(value); err == nil {
+		return now.Add(-age), nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, use an age like 24h or 7d, or a date like 2006-01-02", value)
+}
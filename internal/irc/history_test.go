@@ -0,0 +1,59 @@
+package irc
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"pkdindustries/soulshack/internal/store"
+	mocktest "pkdindustries/soulshack/internal/testing"
+)
+
+func TestParseHistoryTime(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.Local)
+	tests := []struct {
+		value    string
+		endOfDay bool
+		want     time.Time
+	}{
+		{"", false, time.Time{}},
+		{"90m", false, now.Add(-90 * time.Minute)},
+		{"7d", false, now.AddDate(0, 0, -7)},
+		{"2026-03-14", false, time.Date(2026, 3, 14, 0, 0, 0, 0, time.Local)},
+		{"2026-03-14", true, time.Date(2026, 3, 15, 0, 0, 0, 0, time.Local)},
+		{"2026-03-14 09:30", true, time.Date(2026, 3, 14, 9, 30, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		got, err := parseHistoryTime(tt.value, now, tt.endOfDay)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("%q: got %v (%v), want %v", tt.value, got, err, tt.want)
+		}
+	}
+	if _, err := parseHistoryTime("yesterday", now, false); err == nil {
+		t.Error("expected an error for an unknown time")
+	}
+}
+
+func TestHistorySearchTool(t *testing.T) {
+	sys := mocktest.NewMockSystem()
+	mock := mocktest.NewMockContext().WithSystem(sys).WithChannelName("#dev")
+
+	if result := runTool(t, newIrcHistorySearchTool(), mock, map[string]any{"text": "link"}); result != "Channel history is not being logged" {
+		t.Errorf("expected history unavailable without transcripts, got: %s", result)
+	}
+
+	sys.Transcript, _ = store.NewTranscript(t.TempDir())
+	defer sys.Transcript.Close()
+	at := time.Now().Add(-time.Hour)
+	sys.Transcript.Write(store.TranscriptLine{Time: at, Channel: "#dev", Kind: store.TranscriptMessage, Nick: "bob", Text: "the link is https://example.org"})
+	sys.Transcript.Write(store.TranscriptLine{Time: at, Channel: "#ops", Kind: store.TranscriptMessage, Nick: "bob", Text: "secret link https://ops.example.org"})
+
+	result := runTool(t, newIrcHistorySearchTool(), mock, map[string]any{"nick": "bob", "text": "https", "since": "24h"})
+	want := at.Format(time.DateOnly) + " " + at.Format("15:04") + " <bob> the link is https://example.org"
+	if result != want {
+		t.Errorf("expected only #dev's line %q, got: %s", want, result)
+	}
+	if result := runTool(t, newIrcHistorySearchTool(), mock, map[string]any{"text": "https", "until": "2h"}); !strings.HasPrefix(result, "No messages found") {
+		t.Errorf("expected nothing before the time range, got: %s", result)
+	}
+}
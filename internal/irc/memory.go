@@ -0,0 +1,189 @@
+package irc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/alexschlessinger/pollytool/schema"
+	"github.com/alexschlessinger/pollytool/tools"
+
+	"pkdindustries/soulshack/internal/store"
+)
+
+// memoryRecallDefault and memoryRecallMax bound how many memories a recall
+// returns
+const (
+	memoryRecallDefault = 5
+	memoryRecallMax     = 20
+)
+
+// memoryTextMax caps the length of a single memory
+const memoryTextMax = 500
+
+// RegisterMemoryTools registers the long-term memory tools as native tools
+// with polly's registry
+func RegisterMemoryTools(registry *tools.ToolRegistry) {
+	factories := map[string]func() tools.Tool{
+		"memory__remember": newMemoryRememberTool,
+		"memory__recall":   newMemoryRecallTool,
+		"memory__forget":   newMemoryForgetTool,
+	}
+	for name, f := range factories {
+		registry.RegisterNative(name, f)
+	}
+}
+
+// noAccountMsg refuses user memories to senders who aren't logged in, whose
+// nick anyone could take
+const noAccountMsg = "Only users logged in to services can have memories of their own"
+
+// memoryOwner returns who a user-scoped memory belongs to: the sender's
+// $a:account, lowercased, or empty when they are not logged in
+func memoryOwner(ctx ChatContextInterface) string {
+	if user := ctx.GetUser(ctx.GetSource()); user != nil && user.Account != "" && user.Account != "*" && user.Account != "0" {
+		return "$a:" + strings.ToLower(user.Account)
+	}
+	return ""
+}
+
+// memoryVisible matches the memories the current request may see: those of
+// its channel and those of its sender
+func memoryVisible(ctx ChatContextInterface) func(store.Memory) bool {
+	channel, owner := strings.ToLower(ctx.GetChannelName()), memoryOwner(ctx)
+	return func(m store.Memory) bool {
+		switch m.Scope {
+		case store.MemoryChannel:
+			return channel != "" && m.Owner == channel
+		case store.MemoryUser:
+			return owner != "" && m.Owner == owner
+		}
+		return false
+	}
+}
+
+// FormatMemory shows a memory on one line, e.g.
+// "#12 [alice] likes rust (2026-03-15)"
+func FormatMemory(m store.Memory) string {
+	who := m.Owner
+	if m.Scope == store.MemoryUser {
+		who = m.Nick
+	}
+	return fmt.Sprintf("#%d [%s] %s (%s)", m.ID, who, m.Text, m.Time.Format("2006-01-02"))
+}
+
+func newMemoryRememberTool() tools.Tool {
+	return &tools.Func{
+		Name: "memory__remember",
+		Desc: "Remember a fact for future conversations. Use scope 'user' for something about the person talking to you, if they are logged in to services, 'channel' for something everyone in this channel should share",
+		Params: schema.Params{
+			"text":  schema.S("The fact to remember, written so it makes sense on its own later"),
+			"scope": schema.Enum("Who the memory belongs to", store.MemoryUser, store.MemoryChannel),
+		},
+		Required: []string{"text"},
+		Run: func(ctx context.Context, args tools.Args) (string, error) {
+			chatCtx, err := validateContext(ctx)
+			if err != nil {
+				return "", err
+			}
+
+			text := strings.TrimSpace(args.String("text"))
+			if text == "" {
+				return "", fmt.Errorf("text must not be empty")
+			}
+			if len(text) > memoryTextMax {
+				return fmt.Sprintf("Memory is too long (%d bytes, at most %d)", len(text), memoryTextMax), nil
+			}
+
+			channel := chatCtx.GetChannelName()
+			memory := store.Memory{
+				Scope:   store.MemoryUser,
+				Owner:   memoryOwner(chatCtx),
+				Channel: channel,
+				Nick:    chatCtx.GetSource(),
+				Text:    text,
+			}
+			if args.String("scope") == store.MemoryChannel {
+				if channel == "" {
+					return notInChannelMsg, nil
+				}
+				memory.Scope, memory.Owner = store.MemoryChannel, strings.ToLower(channel)
+			} else if memory.Owner == "" {
+				return noAccountMsg, nil
+			}
+
+			memory, err = chatCtx.GetSystem().GetMemory().Add(memory)
+			if err != nil {
+				return "", err
+			}
+			chatCtx.GetLogger().Info("memory_remember", "id", memory.ID, "scope", memory.Scope, "owner", memory.Owner)
+			return fmt.Sprintf("Remembered as #%d", memory.ID), nil
+		},
+	}
+}
+
+func newMemoryRecallTool() tools.Tool {
+	return &tools.Func{
+		Name: "memory__recall",
+		Desc: "Search what you remembered about the person talking to you and about this channel. Leave the query empty for the most recent memories",
+		Params: schema.Params{
+			"query": schema.S("Keywords to search for"),
+			"limit": schema.Int(fmt.Sprintf("How many memories to return (default %d, at most %d)", memoryRecallDefault, memoryRecallMax)),
+		},
+		Run: func(ctx context.Context, args tools.Args) (string, error) {
+			chatCtx, err := validateContext(ctx)
+			if err != nil {
+				return "", err
+			}
+
+			limit := min(max(args.Int("limit", memoryRecallDefault), 1), memoryRecallMax)
+			query := strings.TrimSpace(args.String("query"))
+			memories := chatCtx.GetSystem().GetMemory()
+
+			var found []store.Memory
+			if query == "" {
+				found = memories.Recent(limit, memoryVisible(chatCtx))
+			} else {
+				found = memories.Search(query, limit, memoryVisible(chatCtx))
+			}
+
+			chatCtx.GetLogger().Info("memory_recall", "query", query, "found", len(found))
+			if len(found) == 0 {
+				return "Nothing remembered", nil
+			}
+			lines := make([]string, 0, len(found))
+			for _, m := range found {
+				lines = append(lines, FormatMemory(m))
+			}
+			return strings.Join(lines, "\n"), nil
+		},
+	}
+}
+
+func newMemoryForgetTool() tools.Tool {
+	return &tools.Func{
+		Name: "memory__forget",
+		Desc: "Forget a memory by its number, e.g. when it is wrong or someone asks you to",
+		Params: schema.Params{
+			"id": schema.Int("The memory number shown by memory__recall"),
+		},
+		Required: []string{"id"},
+		Run: func(ctx context.Context, args tools.Args) (string, error) {
+			chatCtx, err := validateContext(ctx)
+			if err != nil {
+				return "", err
+			}
+
+			id := args.Int("id", 0)
+			ok, err := chatCtx.GetSystem().GetMemory().Forget(id, memoryVisible(chatCtx))
+			if err != nil {
+				return "", err
+			}
+			if !ok {
+				return fmt.Sprintf("No memory #%d here", id), nil
+			}
+			chatCtx.GetLogger().Info("memory_forget", "id", id)
+			return fmt.Sprintf("Forgot #%d", id), nil
+		},
+	}
+}
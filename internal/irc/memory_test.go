@@ -0,0 +1,58 @@
+package irc
+
+import (
+	"strings"
+	"testing"
+
+	mocktest "pkdindustries/soulshack/internal/testing"
+)
+
+func TestMemoryTools_Scopes(t *testing.T) {
+	sys := mocktest.NewMockSystem()
+	alice := mocktest.NewMockContext().
+		WithSystem(sys).
+		WithChannelName("#dev").
+		WithUser("alice", "al", "example.org").
+		WithSource("alice")
+	alice.Users["alice"].Account = "Alice"
+
+	runTool(t, newMemoryRememberTool(), alice, map[string]any{"text": "alice prefers tabs"})
+	runTool(t, newMemoryRememberTool(), alice, map[string]any{"text": "releases ship on fridays", "scope": "channel"})
+
+	// Another user in the channel sees the channel's memory but not alice's,
+	// and can't keep memories of their own without an account
+	bob := mocktest.NewMockContext().WithSystem(sys).WithChannelName("#dev").WithSource("bob")
+	if result := runTool(t, newMemoryRememberTool(), bob, map[string]any{"text": "bob likes go"}); result != noAccountMsg {
+		t.Errorf("expected bob refused a memory by nick, got: %s", result)
+	}
+	result := runTool(t, newMemoryRecallTool(), bob, map[string]any{})
+	if !strings.Contains(result, "fridays") || strings.Contains(result, "tabs") {
+		t.Errorf("expected only the channel memory for bob, got: %s", result)
+	}
+	if result := runTool(t, newMemoryForgetTool(), bob, map[string]any{"id": 1}); !strings.Contains(result, "No memory #1") {
+		t.Errorf("expected bob unable to forget alice's memory, got: %s", result)
+	}
+
+	// Alice keeps her memories under another nick, by account
+	private := mocktest.NewMockContext().
+		WithSystem(sys).
+		WithPrivate(true).
+		WithChannelName("").
+		WithUser("alice_", "al", "example.org").
+		WithSource("alice_")
+	private.Users["alice_"].Account = "alice"
+	result = runTool(t, newMemoryRecallTool(), private, map[string]any{"query": "tabs or spaces"})
+	if !strings.Contains(result, "#1 [alice] alice prefers tabs") {
+		t.Errorf("expected alice's memory recalled by account, got: %s", result)
+	}
+	if result := runTool(t, newMemoryRememberTool(), private, map[string]any{"text": "x", "scope": "channel"}); result != notInChannelMsg {
+		t.Errorf("expected channel memories refused in private, got: %s", result)
+	}
+
+	if result := runTool(t, newMemoryForgetTool(), private, map[string]any{"id": 1}); result != "Forgot #1" {
+		t.Errorf("expected alice to forget her memory, got: %s", result)
+	}
+	if result := runTool(t, newMemoryRecallTool(), private, map[string]any{}); result != "Nothing remembered" {
+		t.Errorf("expected nothing left for alice, got: %s", result)
+	}
+}
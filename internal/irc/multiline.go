@@ -0,0 +1,159 @@
+package irc
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lrstanley/girc"
+)
+
+// multilineCap lets a batch of PRIVMSGs be shown as one message (IRCv3
+// draft/multiline)
+const multilineCap = "draft/multiline"
+
+// concatTag marks a line of a batch that continues the one before it, joined
+// without a newline
+const concatTag = "draft/multiline-concat"
+
+// batchCommand opens and closes a batch; girc has no constant for it
+const batchCommand = "BATCH"
+
+// MultilineLimits are the limits a server advertises for multiline batches
+type MultilineLimits struct {
+	MaxBytes int // total bytes of the lines and the newlines joining them
+	MaxLines int // 0 = no limit
+}
+
+// parseMultilineCap reads the multiline limits from a CAP LS or NEW list,
+// e.g. "batch draft/multiline=max-bytes=4096,max-lines=24"
+func parseMultilineCap(caps string) (MultilineLimits, bool) {
+	for _, c := range strings.Fields(caps) {
+		name, value, _ := strings.Cut(c, "=")
+		if name != multilineCap {
+			continue
+		}
+		var limits MultilineLimits
+		for param := range strings.SplitSeq(value, ",") {
+			key, n, _ := strings.Cut(param, "=")
+			switch key {
+			case "max-bytes":
+				limits.MaxBytes, _ = strconv.Atoi(n)
+			case "max-lines":
+				limits.MaxLines, _ = strconv.Atoi(n)
+			}
+		}
+		return limits, limits.MaxBytes > 0
+	}
+	return MultilineLimits{}, false
+}
+
+// onCap tracks whether multiline is enabled on the connection, and its limits
+func (q *SendQueue) onCap(e girc.Event) {
+	if len(e.Params) < 2 {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	switch e.Params[1] {
+	case girc.CAP_LS, girc.CAP_NEW:
+		if e.Params[1] == girc.CAP_LS {
+			q.multiline = nil // a new connection
+		}
+		if limits, ok := parseMultilineCap(e.Last()); ok {
+			q.offered = &limits
+		}
+	case girc.CAP_ACK:
+		if slices.Contains(strings.Fields(e.Last()), multilineCap) {
+			q.multiline = q.offered
+		}
+	case girc.CAP_DEL:
+		if slices.Contains(strings.Fields(e.Last()), multilineCap) {
+			q.multiline = nil
+		}
+	}
+}
+
+// Multiline returns the multiline limits when the server has enabled it
+func (q *SendQueue) Multiline() (MultilineLimits, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.multiline == nil {
+		return MultilineLimits{}, false
+	}
+	return *q.multiline, true
+}
+
+// splitBatches groups lines into as few batches as the limits allow, keeping
+// their order. Lines longer than width are sent as several PRIVMSGs, each
+// counted against the line limit. A line over either limit gets a batch of
+// its own.
+func splitBatches(lines []string, limits MultilineLimits, width int) [][]string {
+	var batches [][]string
+	var batch []string
+	size, count := 0, 0
+	for _, line := range lines {
+		n := len(splitLine(line, width))
+		full := limits.MaxLines > 0 && count+n > limits.MaxLines
+		if len(batch) > 0 && (full || size+1+len(line) > limits.MaxBytes) {
+			batches = append(batches, batch)
+			batch, size, count = nil, 0, 0
+		}
+		if len(batch) > 0 {
+			size++ // the newline joining it to the previous line
+		}
+		batch = append(batch, line)
+		size += len(line)
+		count += n
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+// splitLine cuts a line into pieces of at most width bytes, at the last space
+// where there is one. The space stays at the end of the piece before the cut,
+// since clients join the pieces of a batch as they are. A width of 0 leaves
+// the line whole.
+func splitLine(line string, width int) []string {
+	var pieces []string
+	for width > 0 && len(line) > width {
+		cut := width
+		if idx := strings.LastIndexByte(line[:width], ' '); idx > 0 {
+			cut = idx + 1
+		} else {
+			for cut > 1 && !utf8.RuneStart(line[cut]) {
+				cut--
+			}
+		}
+		pieces = append(pieces, line[:cut])
+		line = line[cut:]
+	}
+	return append(pieces, line)
+}
+
+// writeBatch sends a multiline batch. Tags such as +draft/reply go on the
+// opening BATCH, which clients treat as the message. Lines longer than width
+// are split, with draft/multiline-concat on the continuations so clients show
+// them as the one line.
+func writeBatch(client *girc.Client, o *outbound, width int) {
+	b := make([]byte, 4)
+	rand.Read(b)
+	ref := hex.EncodeToString(b)
+
+	client.Send(&girc.Event{Command: batchCommand, Params: []string{"+" + ref, multilineCap, o.target}, Tags: o.tags})
+	for _, line := range o.lines {
+		for i, piece := range splitLine(line, width) {
+			tags := girc.Tags{"batch": ref}
+			if i > 0 {
+				tags[concatTag] = ""
+			}
+			client.Send(&girc.Event{Command: girc.PRIVMSG, Params: []string{o.target, piece}, Tags: tags})
+		}
+	}
+	client.Send(&girc.Event{Command: batchCommand, Params: []string{"-" + ref}})
+}
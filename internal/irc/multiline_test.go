@@ -0,0 +1,98 @@
+package irc
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lrstanley/girc"
+)
+
+func TestParseMultilineCap(t *testing.T) {
+	tests := []struct {
+		caps   string
+		want   MultilineLimits
+		wantOK bool
+	}{
+		{"batch draft/multiline=max-bytes=4096,max-lines=24 message-tags", MultilineLimits{MaxBytes: 4096, MaxLines: 24}, true},
+		{"draft/multiline=max-bytes=2048", MultilineLimits{MaxBytes: 2048}, true},
+		{"draft/multiline", MultilineLimits{}, false},
+		{"batch message-tags", MultilineLimits{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseMultilineCap(tt.caps)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("%q: got %+v %v, want %+v %v", tt.caps, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestSplitBatches(t *testing.T) {
+	tests := []struct {
+		name   string
+		lines  []string
+		limits MultilineLimits
+		width  int
+		want   [][]string
+	}{
+		{"fits", []string{"one", "two"}, MultilineLimits{MaxBytes: 100}, 0, [][]string{{"one", "two"}}},
+		{"max lines", []string{"a", "b", "c"}, MultilineLimits{MaxBytes: 100, MaxLines: 2}, 0, [][]string{{"a", "b"}, {"c"}}},
+		// "aaaa\nbbbb" is 9 bytes
+		{"max bytes counts newlines", []string{"aaaa", "bbbb", "cc"}, MultilineLimits{MaxBytes: 9}, 0, [][]string{{"aaaa", "bbbb"}, {"cc"}}},
+		{"oversized line alone", []string{"a", "too long", "b"}, MultilineLimits{MaxBytes: 4}, 0, [][]string{{"a"}, {"too long"}, {"b"}}},
+		{"empty", nil, MultilineLimits{MaxBytes: 4}, 0, nil},
+		// "one two three" is sent as three PRIVMSGs at width 4
+		{"split lines count", []string{"one two three", "four"}, MultilineLimits{MaxBytes: 100, MaxLines: 3}, 4, [][]string{{"one two three"}, {"four"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitBatches(tt.lines, tt.limits, tt.width); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitLine(t *testing.T) {
+	tests := []struct {
+		line  string
+		width int
+		want  []string
+	}{
+		{"short", 10, []string{"short"}},
+		{"no width", 0, []string{"no width"}},
+		// The space stays on the first piece, so joining them restores the line
+		{"split at the last space", 12, []string{"split at ", "the last ", "space"}},
+		{"unbroken", 4, []string{"unbr", "oken"}},
+		{"héllo", 2, []string{"h", "é", "ll", "o"}},
+	}
+	for _, tt := range tests {
+		got := splitLine(tt.line, tt.width)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitLine(%q, %d) = %q, want %q", tt.line, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestSendQueue_TracksMultiline(t *testing.T) {
+	q := testSendQueue(5, 0, 0)
+	cap := func(raw string) { q.onCap(*girc.ParseEvent(raw)) }
+
+	cap(":server CAP * LS :batch message-tags draft/multiline=max-bytes=4096,max-lines=24")
+	if _, ok := q.Multiline(); ok {
+		t.Fatal("expected multiline off until acknowledged")
+	}
+	cap(":server CAP soulshack ACK :batch message-tags draft/multiline")
+	if limits, ok := q.Multiline(); !ok || limits.MaxBytes != 4096 || limits.MaxLines != 24 {
+		t.Fatalf("expected multiline on with the offered limits, got %+v %v", limits, ok)
+	}
+	cap(":server CAP soulshack DEL :draft/multiline")
+	if _, ok := q.Multiline(); ok {
+		t.Error("expected multiline off once deleted")
+	}
+
+	cap(":server CAP soulshack ACK :draft/multiline")
+	cap(":server CAP * LS :batch message-tags")
+	if _, ok := q.Multiline(); ok {
+		t.Error("expected a new connection to start without multiline")
+	}
+}
@@ -5,6 +5,10 @@ import (
 	"net"
 	"regexp"
 	"strings"
+
+	"github.com/lrstanley/girc"
+
+	"pkdindustries/soulshack/internal/config"
 )
 
 // CheckAddressed returns true if message starts with botNick followed by a separator or end of string.
@@ -27,17 +31,105 @@ func CheckAddressed(message, botNick string) bool {
 // CheckAdmin returns true if hostmask matches any admin in the list.
 // WARNING: Returns true if adminList is empty (legacy behavior - everyone is admin).
 func CheckAdmin(hostmask string, adminList []string) bool {
+	return MatchAdmin(hostmask, "", adminList)
+}
+
+// accountPrefix marks admin entries that name a services account
+const accountPrefix = "$a:"
+
+// MatchAdmin returns true if the user matches any admin in the list. Entries
+// are hostmasks with * and ? globs, or services accounts written as $a:name.
+// account is the user's services account, or empty if not logged in.
+// WARNING: Returns true if adminList is empty (legacy behavior - everyone is admin).
+func MatchAdmin(hostmask, account string, adminList []string) bool {
 	if len(adminList) == 0 {
 		return true
 	}
 	for _, admin := range adminList {
-		if admin == hostmask {
+		if name, ok := strings.CutPrefix(admin, accountPrefix); ok {
+			if account != "" && MatchMask(name, account) {
+				return true
+			}
+			continue
+		}
+		if hostmask != "" && MatchMask(admin, hostmask) {
 			return true
 		}
 	}
 	return false
 }
 
+// Sender describes who sent an event, for matching role members
+type Sender struct {
+	Hostmask string
+	Account  string // services account, empty if not logged in
+	Op       bool   // has +o or higher in the event's channel
+	Voice    bool   // has +v or higher in the event's channel
+}
+
+// MatchRole returns the highest role the sender holds. The admins list always
+// grants owner; when it is empty and no role has members, everyone is owner
+// (legacy behavior). Role members are hostmask globs, $a:account entries, or
+// +o and +v for channel status.
+func MatchRole(sender Sender, admins []string, perms *config.Permissions) config.Role {
+	if (len(admins) > 0 || !perms.HasMembers()) && MatchAdmin(sender.Hostmask, sender.Account, admins) {
+		return config.RoleOwner
+	}
+	if perms == nil {
+		return config.RoleEveryone
+	}
+	for role := config.RoleOwner; role > config.RoleEveryone; role-- {
+		for _, member := range perms.Members[role] {
+			switch member {
+			case "+o":
+				if sender.Op {
+					return role
+				}
+			case "+v":
+				if sender.Voice {
+					return role
+				}
+			default:
+				if MatchAdmin(sender.Hostmask, sender.Account, []string{member}) {
+					return role
+				}
+			}
+		}
+	}
+	return config.RoleEveryone
+}
+
+// MatchMask reports whether s matches an IRC glob mask, where * matches any
+// run of characters and ? matches exactly one. Comparison uses rfc1459
+// case-mapping, so {}|^ match []\~ and letters are case-insensitive.
+func MatchMask(mask, s string) bool {
+	p, str := girc.ToRFC1459(mask), girc.ToRFC1459(s)
+
+	// Iterative wildcard match, backtracking to the last * on mismatch
+	pi, si := 0, 0
+	star, mark := -1, 0
+	for si < len(str) {
+		switch {
+		case pi < len(p) && (p[pi] == '?' || p[pi] == str[si]):
+			pi++
+			si++
+		case pi < len(p) && p[pi] == '*':
+			star, mark = pi, si
+			pi++
+		case star != -1:
+			pi = star + 1
+			mark++
+			si = mark
+		default:
+			return false
+		}
+	}
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+	return pi == len(p)
+}
+
 // CheckPrivate returns true if target is not a channel (doesn't start with #).
 func CheckPrivate(target string) bool {
 	return !strings.HasPrefix(target, "#")
@@ -45,18 +137,37 @@ func CheckPrivate(target string) bool {
 
 // RFC 2812 compliant patterns
 var (
-	// Nick: starts with letter or special, followed by letters, digits, special, or hyphen
+	// Nick: starts with letter, special, or glob, followed by letters, digits, special, hyphen, or glob
 	// Special chars: [\]^_`{|}
-	nickRegex = regexp.MustCompile(`^[a-zA-Z\[\]\\^\x60_{|}][a-zA-Z0-9\[\]\\^\x60_{|}\-]*$`)
+	nickRegex = regexp.MustCompile(`^[a-zA-Z\[\]\\^\x60_{|}*?][a-zA-Z0-9\[\]\\^\x60_{|}\-*?]*$`)
 
-	// User: alphanumeric with optional ~ prefix, allows -_.
-	userRegex = regexp.MustCompile(`^~?[a-zA-Z0-9_.\-]+$`)
+	// User: alphanumeric with optional ~ prefix, allows -_. and globs
+	userRegex = regexp.MustCompile(`^~?[a-zA-Z0-9_.\-*?]+$`)
 
 	// Hostname: DNS labels (letters, digits, hyphens) separated by dots
 	hostnameRegex = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9\-]*[a-zA-Z0-9])?\.)*[a-zA-Z0-9]([a-zA-Z0-9\-]*[a-zA-Z0-9])?$`)
+
+	// Host masks: globbed hostnames or IPs, and services cloaks like user/alice
+	hostMaskRegex = regexp.MustCompile(`^[a-zA-Z0-9.\-:/_*?]+$`)
+
+	// Account: services account names, optionally globbed
+	accountRegex = regexp.MustCompile(`^[^\s!@]+$`)
 )
 
+// ValidateAdminMask validates an admin list entry: a hostmask, or a
+// services account written as $a:name.
+func ValidateAdminMask(entry string) error {
+	if name, ok := strings.CutPrefix(entry, accountPrefix); ok {
+		if !accountRegex.MatchString(name) {
+			return errors.New("invalid account (format: $a:accountname)")
+		}
+		return nil
+	}
+	return ValidateHostmask(entry)
+}
+
 // ValidateHostmask validates an IRC hostmask in the format nick!user@host per RFC 2812.
+// The * and ? glob characters are allowed in every part.
 func ValidateHostmask(hostmask string) error {
 	if hostmask == "" {
 		return errors.New("hostmask cannot be empty")
@@ -115,6 +226,14 @@ func ValidateHostmask(hostmask string) error {
 		return nil
 	}
 
+	// Globbed hosts and cloaks can't be checked as hostnames
+	if strings.ContainsAny(host, "*?/") {
+		if !hostMaskRegex.MatchString(host) {
+			return errors.New("invalid host: must be a valid hostname, IP address, or mask")
+		}
+		return nil
+	}
+
 	// Check if it's a valid hostname
 	if !hostnameRegex.MatchString(host) {
 		return errors.New("invalid host: must be a valid hostname or IP address")
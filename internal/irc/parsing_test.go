@@ -1,6 +1,10 @@
 package irc
 
-import "testing"
+import (
+	"testing"
+
+	"pkdindustries/soulshack/internal/config"
+)
 
 func TestCheckAddressed(t *testing.T) {
 	tests := []struct {
@@ -93,6 +97,114 @@ func TestCheckAdmin_MultipleAdmins(t *testing.T) {
 	}
 }
 
+func TestMatchMask(t *testing.T) {
+	tests := []struct {
+		mask, s string
+		want    bool
+	}{
+		{"*!*@admin.example.com", "alice!~al@admin.example.com", true},
+		{"*!*@*.example.com", "bob!b@host.example.com", true},
+		{"*!*@*.example.com", "bob!b@example.org", false},
+		{"al?ce!*@*", "alice!a@h", true},
+		{"al?ce!*@*", "allice!a@h", false},
+		{"*!*@user/alice", "alice!~a@user/alice", true},
+		{"Alice!*@*", "aLiCe!a@h", true},
+		{"nick[away]!*@*", "NICK{AWAY}!u@h", true}, // rfc1459 case-mapping
+		{"*", "", true},
+		{"a*b*c", "abxbc", true},
+		{"a*b*c", "abxbd", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mask+"~"+tt.s, func(t *testing.T) {
+			if got := MatchMask(tt.mask, tt.s); got != tt.want {
+				t.Errorf("MatchMask(%q, %q) = %v, want %v", tt.mask, tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchAdmin_Accounts(t *testing.T) {
+	admins := []string{"$a:alice", "*!*@trusted.host"}
+
+	tests := []struct {
+		name     string
+		hostmask string
+		account  string
+		want     bool
+	}{
+		{"account match", "x!y@dynamic.isp", "alice", true},
+		{"account case-mapped", "x!y@dynamic.isp", "ALICE", true},
+		{"other account", "x!y@dynamic.isp", "mallory", false},
+		{"not logged in", "alice!y@dynamic.isp", "", false},
+		{"hostmask glob still works", "bob!b@trusted.host", "", true},
+		{"account entry is not a hostmask", "$a:alice", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchAdmin(tt.hostmask, tt.account, admins); got != tt.want {
+				t.Errorf("MatchAdmin(%q, %q) = %v, want %v", tt.hostmask, tt.account, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchRole(t *testing.T) {
+	perms := &config.Permissions{Members: map[config.Role][]string{
+		config.RoleOperator: {"+o", "$a:bob"},
+		config.RoleTrusted:  {"+v", "*!*@friends.net"},
+	}}
+	admins := []string{"$a:alice"}
+
+	tests := []struct {
+		name   string
+		sender Sender
+		admins []string
+		perms  *config.Permissions
+		want   config.Role
+	}{
+		{"admin is owner", Sender{Hostmask: "a!a@host", Account: "alice"}, admins, perms, config.RoleOwner},
+		{"account member", Sender{Hostmask: "b!b@host", Account: "bob"}, admins, perms, config.RoleOperator},
+		{"channel op", Sender{Hostmask: "c!c@host", Op: true, Voice: true}, admins, perms, config.RoleOperator},
+		{"voiced", Sender{Hostmask: "d!d@host", Voice: true}, admins, perms, config.RoleTrusted},
+		{"hostmask member", Sender{Hostmask: "e!e@friends.net"}, admins, perms, config.RoleTrusted},
+		{"nobody", Sender{Hostmask: "f!f@host"}, admins, perms, config.RoleEveryone},
+		{"roles without admins", Sender{Hostmask: "f!f@host"}, nil, perms, config.RoleEveryone},
+		{"legacy empty config", Sender{Hostmask: "f!f@host"}, nil, nil, config.RoleOwner},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchRole(tt.sender, tt.admins, tt.perms); got != tt.want {
+				t.Errorf("MatchRole() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateAdminMask(t *testing.T) {
+	tests := []struct {
+		entry   string
+		wantErr bool
+	}{
+		{"$a:alice", false},
+		{"$a:", true},
+		{"$a:bad name", true},
+		{"*!*@admin.example.com", false},
+		{"nick", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.entry, func(t *testing.T) {
+			err := ValidateAdminMask(tt.entry)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateAdminMask(%q) = %v, wantErr %v", tt.entry, err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestCheckPrivate(t *testing.T) {
 	tests := []struct {
 		target string
@@ -136,6 +248,12 @@ func TestValidateHostmask_Valid(t *testing.T) {
 		{"backtick nick", "`nick!user@host.com"},
 		{"pipe nick", "nick|away!user@host.com"},
 		{"curly nick", "{nick}!user@host.com"},
+		{"wildcard all", "*!*@*"},
+		{"wildcard host", "*!*@admin.example.com"},
+		{"wildcard subdomain", "nick!*@*.example.com"},
+		{"single char glob", "ni?k!us?r@host.com"},
+		{"wildcard ip", "*!*@192.168.*"},
+		{"cloak", "*!*@user/alice"},
 	}
 
 	for _, tt := range tests {
@@ -167,6 +285,7 @@ func TestValidateHostmask_Invalid(t *testing.T) {
 		{"user with space", "nick!user name@host.com", "invalid user"},
 		{"invalid host", "nick!user@host..com", "invalid host"},
 		{"host with space", "nick!user@host name.com", "invalid host"},
+		{"glob host with space", "*!*@* .com", "invalid host"},
 	}
 
 	for _, tt := range tests {
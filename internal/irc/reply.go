@@ -0,0 +1,48 @@
+package irc
+
+import (
+	"sync/atomic"
+
+	"github.com/lrstanley/girc"
+)
+
+// replyTag threads a reply to the message it answers (IRCv3 +draft/reply)
+const replyTag = "+draft/reply"
+
+// replyThread ties the replies to an event to the message that caused it, so
+// it's clear on a busy channel which question the bot is answering
+type replyThread struct {
+	msgid       string // of the triggering PRIVMSG, empty when the server sent none
+	nick        string // its sender
+	tags        bool   // whether the server supports message-tags
+	highlighted atomic.Bool
+}
+
+// newReplyThread returns the thread for an event, or nil if it is not a
+// PRIVMSG
+func newReplyThread(e *girc.Event, tags bool) *replyThread {
+	if e.Command != girc.PRIVMSG || e.Source == nil {
+		return nil
+	}
+	msgid, _ := e.Tags.Get("msgid")
+	return &replyThread{msgid: msgid, nick: e.Source.Name, tags: tags}
+}
+
+// apply returns the tags and text for one line of the reply. With message
+// tags and a msgid every line carries +draft/reply; otherwise the first line
+// to a channel starts with a "nick: " highlight instead.
+func (t *replyThread) apply(message string, channel bool) (girc.Tags, string) {
+	if t == nil {
+		return nil, message
+	}
+	if t.tags && t.msgid != "" {
+		tags := girc.Tags{}
+		if err := tags.Set(replyTag, t.msgid); err == nil {
+			return tags, message
+		}
+	}
+	if channel && t.highlighted.CompareAndSwap(false, true) {
+		message = t.nick + ": " + message
+	}
+	return nil, message
+}
@@ -0,0 +1,49 @@
+package irc
+
+import (
+	"testing"
+
+	"github.com/lrstanley/girc"
+)
+
+func TestReplyThread(t *testing.T) {
+	tagged := girc.ParseEvent("@msgid=abc123 :alice!a@example.org PRIVMSG #dev :soulshack: hi")
+	untagged := girc.ParseEvent(":alice!a@example.org PRIVMSG #dev :soulshack: hi")
+
+	tests := []struct {
+		name    string
+		event   *girc.Event
+		tags    bool
+		channel bool
+		want    []string // text of two lines
+		replyTo string
+	}{
+		{"tagged", tagged, true, true, []string{"one", "two"}, "abc123"},
+		{"no message-tags", tagged, false, true, []string{"alice: one", "two"}, ""},
+		{"no msgid", untagged, true, true, []string{"alice: one", "two"}, ""},
+		{"private", untagged, false, false, []string{"one", "two"}, ""},
+		{"tagged private", tagged, true, false, []string{"one", "two"}, "abc123"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thread := newReplyThread(tt.event, tt.tags)
+			for i, text := range []string{"one", "two"} {
+				tags, got := thread.apply(text, tt.channel)
+				if got != tt.want[i] {
+					t.Errorf("line %d: got %q, want %q", i, got, tt.want[i])
+				}
+				if replyTo, _ := tags.Get(replyTag); replyTo != tt.replyTo {
+					t.Errorf("line %d: got %s=%q, want %q", i, replyTag, replyTo, tt.replyTo)
+				}
+			}
+		})
+	}
+
+	// Server events are not threaded
+	if thread := newReplyThread(girc.ParseEvent(":server 001 soulshack :Welcome"), true); thread != nil {
+		t.Errorf("expected no thread for a numeric, got %+v", thread)
+	}
+	if tags, got := (*replyThread)(nil).apply("hi", true); tags != nil || got != "hi" {
+		t.Errorf("expected a nil thread to leave the line alone, got %v %q", tags, got)
+	}
+}
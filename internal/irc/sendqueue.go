@@ -0,0 +1,311 @@
+package irc
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/lrstanley/girc"
+
+	"pkdindustries/soulshack/internal/config"
+)
+
+// SendQueue paces outbound messages so long answers don't get the bot killed
+// for flooding. A global token bucket lets a burst of lines through and then
+// one line per delay, and each target also waits its own delay between lines.
+// Lines to the same target keep their order. Typing notifications go in a
+// lane of their own, behind any line waiting for their target.
+type SendQueue struct {
+	mu       sync.Mutex
+	pending  []*outbound
+	typing   map[string]*outbound // latest typing notification per target
+	wake     chan struct{}
+	tokens   float64
+	refilled time.Time
+	lastSent map[string]time.Time
+
+	cfg    *config.Configuration
+	write  func(*outbound)
+	now    func() time.Time
+	onSent func(target, text string, action bool)
+
+	// multiline limits as offered by the server, and once enabled
+	offered   *MultilineLimits
+	multiline *MultilineLimits
+}
+
+// outbound is a queued line. ctx is the request that produced it: once the
+// request is done, its remaining lines are coalesced into one.
+type outbound struct {
+	ctx    context.Context
+	target string
+	text   string
+	action bool
+	tags   girc.Tags // IRCv3 message tags, e.g. +draft/reply
+	lines  []string  // a multiline batch, sent instead of text
+	typing string    // a +typing state, sent as a TAGMSG instead of text
+	sent   chan struct{}
+}
+
+// NewSendQueue creates a queue that writes to client, paced by the send
+// settings in cfg. Start it with Run.
+func NewSendQueue(client *girc.Client, cfg *config.Configuration) *SendQueue {
+	q := newSendQueue(cfg, func(o *outbound) {
+		switch {
+		case o.action:
+			client.Cmd.Action(o.target, o.text)
+		case o.typing != "":
+			client.Send(&girc.Event{Command: girc.CAP_TAGMSG, Params: []string{o.target}, Tags: girc.Tags{"+typing": o.typing}})
+		case o.lines != nil:
+			writeBatch(client, o, cfg.ForChannel(o.target).Session.ChunkMax)
+		default:
+			client.Send(&girc.Event{Command: girc.PRIVMSG, Params: []string{o.target, o.text}, Tags: o.tags})
+		}
+	})
+	if client.Config.SupportedCaps == nil {
+		client.Config.SupportedCaps = make(map[string][]string)
+	}
+	client.Config.SupportedCaps[multilineCap] = nil
+	// CAP replies are handled in order, as ACK relies on what LS offered
+	client.Handlers.Add(girc.CAP, func(_ *girc.Client, e girc.Event) { q.onCap(e) })
+	return q
+}
+
+func newSendQueue(cfg *config.Configuration, write func(*outbound)) *SendQueue {
+	return &SendQueue{
+		wake:     make(chan struct{}, 1),
+		typing:   make(map[string]*outbound),
+		tokens:   float64(cfg.Server.SendBurst),
+		lastSent: make(map[string]time.Time),
+		cfg:      cfg,
+		write:    write,
+		now:      time.Now,
+	}
+}
+
+// OnSent sets a function called with every line once it is written. Set it
+// before Run.
+func (q *SendQueue) OnSent(fn func(target, text string, action bool)) {
+	q.onSent = fn
+}
+
+// Message queues a PRIVMSG and waits until it is sent or ctx is done
+func (q *SendQueue) Message(ctx context.Context, target, text string) {
+	q.enqueue(&outbound{ctx: ctx, target: target, text: text})
+}
+
+// MessageTags queues a PRIVMSG carrying IRCv3 message tags and waits until it
+// is sent or ctx is done
+func (q *SendQueue) MessageTags(ctx context.Context, target, text string, tags girc.Tags) {
+	q.enqueue(&outbound{ctx: ctx, target: target, text: text, tags: tags})
+}
+
+// Batch queues lines to go out as one multiline batch, counted as a single
+// line for pacing, and waits until it is sent or ctx is done
+func (q *SendQueue) Batch(ctx context.Context, target string, lines []string, tags girc.Tags) {
+	q.enqueue(&outbound{ctx: ctx, target: target, lines: lines, tags: tags})
+}
+
+// Action queues a CTCP ACTION and waits until it is sent or ctx is done
+func (q *SendQueue) Action(ctx context.Context, target, text string) {
+	q.enqueue(&outbound{ctx: ctx, target: target, text: text, action: true})
+}
+
+// Typing queues a typing notification without waiting. It replaces any
+// still queued for the target, and is dropped once a line to the target is
+// sent, as that clears the indicator.
+func (q *SendQueue) Typing(target, state string) {
+	q.mu.Lock()
+	q.typing[girc.ToRFC1459(target)] = &outbound{ctx: context.Background(), target: target, typing: state, sent: make(chan struct{})}
+	q.mu.Unlock()
+	q.notify()
+}
+
+func (q *SendQueue) enqueue(o *outbound) {
+	ctx := o.ctx
+	o.sent = make(chan struct{})
+	q.mu.Lock()
+	q.pending = append(q.pending, o)
+	q.mu.Unlock()
+	q.notify()
+
+	select {
+	case <-o.sent:
+	case <-ctx.Done():
+		q.notify() // let the queue coalesce what this request left behind
+	}
+}
+
+// sent reports a written line, or each line of a batch, to the OnSent hook
+func (q *SendQueue) sent(o *outbound) {
+	if q.onSent == nil || o.typing != "" {
+		return
+	}
+	if o.lines == nil {
+		q.onSent(o.target, o.text, o.action)
+		return
+	}
+	for _, line := range o.lines {
+		q.onSent(o.target, line, false)
+	}
+}
+
+func (q *SendQueue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run sends queued lines until ctx is done
+func (q *SendQueue) Run(ctx context.Context) {
+	for {
+		q.mu.Lock()
+		o, wait := q.next(q.now())
+		q.mu.Unlock()
+		if o != nil {
+			q.write(o)
+			q.sent(o)
+			close(o.sent)
+			continue
+		}
+
+		var retry <-chan time.Time
+		if wait > 0 {
+			retry = time.After(wait)
+		}
+		select {
+		case <-q.wake:
+		case <-retry:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// next removes and returns the first line that may be sent now, or else a
+// typing notification for a target with no lines waiting. Otherwise it
+// returns how long until one might be, or zero to wait for new lines.
+func (q *SendQueue) next(now time.Time) (*outbound, time.Duration) {
+	server := q.cfg.Snapshot().Server
+	q.coalesce()
+	if len(q.pending) == 0 && len(q.typing) == 0 {
+		return nil, 0
+	}
+
+	// Refill the global bucket
+	burst := float64(max(server.SendBurst, 1))
+	if server.SendDelay <= 0 {
+		q.tokens = burst
+	} else if !q.refilled.IsZero() {
+		q.tokens = min(burst, q.tokens+float64(now.Sub(q.refilled))/float64(server.SendDelay))
+	}
+	q.refilled = now
+	var tokenWait time.Duration
+	if q.tokens < 1 {
+		tokenWait = time.Duration((1 - q.tokens) * float64(server.SendDelay))
+	}
+
+	var targetWait time.Duration
+	blocked := make(map[string]bool)
+	for i, o := range q.pending {
+		target := girc.ToRFC1459(o.target)
+		if blocked[target] {
+			continue // keep per-target order
+		}
+		if ready := q.lastSent[target].Add(server.SendTargetDelay); now.Before(ready) {
+			blocked[target] = true
+			if until := ready.Sub(now); targetWait == 0 || until < targetWait {
+				targetWait = until
+			}
+			continue
+		}
+		if tokenWait > 0 {
+			return nil, tokenWait
+		}
+		q.tokens--
+		q.lastSent[target] = now
+		q.pending = slices.Delete(q.pending, i, i+1)
+		delete(q.typing, target)
+		q.prune(now)
+		return o, 0
+	}
+	if tokenWait > 0 {
+		return nil, max(tokenWait, targetWait)
+	}
+	for target, o := range q.typing {
+		if !blocked[target] {
+			q.tokens--
+			delete(q.typing, target)
+			return o, 0
+		}
+	}
+	return nil, targetWait
+}
+
+// coalesce merges the lines of requests that are done into a single line per
+// request and target, trimmed to the chunk size, so a cancelled answer ends
+// with one line instead of a slow trickle
+func (q *SendQueue) coalesce() {
+	var kept []*outbound
+	merged := make(map[context.Context]map[string]*outbound)
+	for _, o := range q.pending {
+		if o.ctx.Err() == nil || o.lines != nil {
+			kept = append(kept, o) // a batch is one line already
+			continue
+		}
+		if merged[o.ctx] == nil {
+			merged[o.ctx] = make(map[string]*outbound)
+		}
+		if first := merged[o.ctx][o.target]; first != nil {
+			first.text += " " + o.text
+			first.action = false
+			close(o.sent)
+			continue
+		}
+		merged[o.ctx][o.target] = o
+		kept = append(kept, o)
+	}
+
+	for _, targets := range merged {
+		for _, o := range targets {
+			o.ctx = context.Background() // merged once, send as is
+			limit := q.cfg.ForChannel(o.target).Session.ChunkMax
+			if limit <= 0 {
+				limit = 350
+			}
+			o.text = truncate(o.text, limit)
+		}
+	}
+	q.pending = kept
+}
+
+// truncate shortens text to at most limit bytes, ending it with an ellipsis.
+// The limit is never less than the ellipsis and one byte.
+func truncate(text string, limit int) string {
+	limit = max(limit, len("...")+1)
+	if len(text) <= limit {
+		return text
+	}
+	cut := text[:limit-3]
+	for !utf8.ValidString(cut) {
+		cut = cut[:len(cut)-1]
+	}
+	return strings.TrimSpace(cut) + "..."
+}
+
+// prune forgets targets that have been idle longer than their delay
+func (q *SendQueue) prune(now time.Time) {
+	if len(q.lastSent) < 256 {
+		return
+	}
+	delay := q.cfg.Snapshot().Server.SendTargetDelay
+	for target, at := range q.lastSent {
+		if now.Sub(at) > delay {
+			delete(q.lastSent, target)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package irc
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lrstanley/girc"
+
+	"pkdindustries/soulshack/internal/config"
+)
+
+func testSendQueue(burst int, delay, targetDelay time.Duration) *SendQueue {
+	cfg := &config.Configuration{
+		Server:  &config.ServerConfig{SendBurst: burst, SendDelay: delay, SendTargetDelay: targetDelay},
+		Session: &config.SessionConfig{ChunkMax: 20},
+	}
+	return newSendQueue(cfg, func(*outbound) {})
+}
+
+// queue adds a line without waiting for it to be sent
+func (q *SendQueue) queue(ctx context.Context, target, text string) *outbound {
+	o := &outbound{ctx: ctx, target: target, text: text, sent: make(chan struct{})}
+	q.pending = append(q.pending, o)
+	return o
+}
+
+func TestSendQueue_BurstThenPace(t *testing.T) {
+	q := testSendQueue(2, time.Second, 0)
+	ctx := context.Background()
+	for _, text := range []string{"one", "two", "three"} {
+		q.queue(ctx, "#test", text)
+	}
+
+	now := time.Now()
+	for _, want := range []string{"one", "two"} {
+		if o, _ := q.next(now); o == nil || o.text != want {
+			t.Fatalf("expected %q within the burst, got %+v", want, o)
+		}
+	}
+	o, wait := q.next(now)
+	if o != nil || wait != time.Second {
+		t.Fatalf("expected to wait 1s after the burst, got %+v after %s", o, wait)
+	}
+	if o, _ := q.next(now.Add(time.Second)); o == nil || o.text != "three" {
+		t.Fatalf("expected the third line after the delay, got %+v", o)
+	}
+}
+
+func TestSendQueue_TargetDelay(t *testing.T) {
+	q := testSendQueue(10, 0, time.Second)
+	ctx := context.Background()
+	q.queue(ctx, "#a", "a1")
+	q.queue(ctx, "#a", "a2")
+	q.queue(ctx, "#b", "b1")
+
+	now := time.Now()
+	var got []string
+	for {
+		o, _ := q.next(now)
+		if o == nil {
+			break
+		}
+		got = append(got, o.text)
+	}
+	if strings.Join(got, ",") != "a1,b1" {
+		t.Errorf("expected one line per target, got %v", got)
+	}
+	if o, _ := q.next(now.Add(time.Second)); o == nil || o.text != "a2" {
+		t.Errorf("expected a2 after the target delay, got %+v", o)
+	}
+}
+
+func TestSendQueue_CoalescesCancelledRequest(t *testing.T) {
+	q := testSendQueue(1, time.Hour, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	first := q.queue(ctx, "#test", "first")
+	rest := []*outbound{
+		q.queue(ctx, "#test", "second line"),
+		q.queue(ctx, "#test", "third line"),
+	}
+	other := q.queue(context.Background(), "#other", "unrelated")
+
+	now := time.Now()
+	if o, _ := q.next(now); o != first {
+		t.Fatalf("expected the first line, got %+v", o)
+	}
+	cancel()
+
+	o, _ := q.next(now.Add(time.Hour))
+	if o == nil || o.text != "second line third..." {
+		t.Fatalf("expected the remaining lines merged and trimmed, got %+v", o)
+	}
+	for _, r := range rest[1:] {
+		select {
+		case <-r.sent:
+		default:
+			t.Error("expected merged lines to be released")
+		}
+	}
+	if len(q.pending) != 1 || q.pending[0] != other {
+		t.Errorf("expected only the unrelated line left, got %d lines", len(q.pending))
+	}
+}
+
+func TestSendQueue_Typing(t *testing.T) {
+	q := testSendQueue(2, time.Second, time.Second)
+	ctx := context.Background()
+	q.queue(ctx, "#a", "a1")
+	now := time.Now()
+	if o, _ := q.next(now); o == nil || o.text != "a1" {
+		t.Fatalf("expected a1 first, got %+v", o)
+	}
+
+	q.Typing("#a", "active")
+	q.queue(ctx, "#a", "a2")
+	q.Typing("#b", "active")
+	q.Typing("#B", "paused")
+	if o, _ := q.next(now); o == nil || o.target != "#B" || o.typing != "paused" {
+		t.Fatalf("expected only the latest typing state for #b, got %+v", o)
+	}
+	if o, wait := q.next(now); o != nil || wait != time.Second {
+		t.Fatalf("expected typing for #a to wait behind a2, got %+v after %s", o, wait)
+	}
+	if o, _ := q.next(now.Add(time.Second)); o == nil || o.text != "a2" {
+		t.Fatalf("expected a2 after the delays, got %+v", o)
+	}
+	if o, wait := q.next(now.Add(time.Second)); o != nil || wait != 0 {
+		t.Errorf("expected typing for #a dropped once a line was sent, got %+v", o)
+	}
+}
+
+func TestSendQueue_Run(t *testing.T) {
+	var sent []string
+	var tags []girc.Tags
+	cfg := &config.Configuration{Server: &config.ServerConfig{SendBurst: 5}, Session: &config.SessionConfig{}}
+	q := newSendQueue(cfg, func(o *outbound) {
+		sent = append(sent, o.text)
+		tags = append(tags, o.tags)
+	})
+	var actions []bool
+	q.OnSent(func(target, text string, action bool) { actions = append(actions, action) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Run(ctx)
+
+	q.Message(ctx, "#test", "hello")
+	q.Action(ctx, "#test", "waves")
+	q.MessageTags(ctx, "#test", "threaded", girc.Tags{replyTag: "abc"})
+	if strings.Join(sent, ",") != "hello,waves,threaded" {
+		t.Errorf("expected lines sent in order, got %v", sent)
+	}
+	if tags[0] != nil || tags[2][replyTag] != "abc" {
+		t.Errorf("expected only the last line tagged, got %v", tags)
+	}
+	if len(actions) != 3 || actions[0] || !actions[1] {
+		t.Errorf("expected OnSent called for each line, got %v", actions)
+	}
+}
+
+func TestSendQueue_Batch(t *testing.T) {
+	var batches [][]string
+	cfg := &config.Configuration{Server: &config.ServerConfig{SendBurst: 1, SendDelay: time.Hour}, Session: &config.SessionConfig{}}
+	q := newSendQueue(cfg, func(o *outbound) { batches = append(batches, o.lines) })
+	var logged []string
+	q.OnSent(func(target, text string, action bool) { logged = append(logged, text) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Run(ctx)
+
+	// The whole batch fits in a burst of one
+	q.Batch(ctx, "#test", []string{"one", "two", "three"}, nil)
+	if len(batches) != 1 || strings.Join(batches[0], ",") != "one,two,three" {
+		t.Errorf("expected one batch written, got %v", batches)
+	}
+	if strings.Join(logged, ",") != "one,two,three" {
+		t.Errorf("expected OnSent called for each line, got %v", logged)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		text  string
+		limit int
+		want  string
+	}{
+		{"short", 20, "short"},
+		{"a longer line of text", 10, "a longe..."},
+		{"a longer line of text", 1, "a..."},
+		{"a longer line of text", 2, "a..."},
+		{"héllo world", 5, "h..."},
+	}
+	for _, tt := range tests {
+		if got := truncate(tt.text, tt.limit); got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.text, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestSendQueue_CoalesceChannelLimit(t *testing.T) {
+	q := testSendQueue(1, time.Hour, 0)
+	q.cfg.Overrides = config.NewChannelOverrides()
+	q.cfg.Overrides.Set("#wide", "chunkmax", "64")
+	ctx, cancel := context.WithCancel(context.Background())
+	q.queue(ctx, "#wide", "first")
+	q.queue(ctx, "#wide", "second line")
+	q.queue(ctx, "#wide", "third line and then a good many more words than the limit allows")
+
+	now := time.Now()
+	q.next(now)
+	cancel()
+
+	o, _ := q.next(now.Add(time.Hour))
+	if o == nil || o.text != "second line third line and then a good many more words than t..." {
+		t.Fatalf("expected the channel's chunkmax to apply, got %+v", o)
+	}
+}
@@ -7,6 +7,8 @@ import (
 
 	"github.com/alexschlessinger/pollytool/schema"
 	"github.com/alexschlessinger/pollytool/tools"
+
+	"pkdindustries/soulshack/internal/config"
 )
 
 type contextKey string
@@ -27,8 +29,7 @@ func InjectContext(ctx context.Context, chatCtx ChatContextInterface) context.Co
 	return context.WithValue(ctx, kContextKey, chatCtx)
 }
 
-func isBotOpped(ctx ChatContextInterface) bool {
-	channel := ctx.GetConfig().Server.Channel
+func isBotOpped(ctx ChatContextInterface, channel string) bool {
 	botNick := ctx.GetBotNick()
 
 	users := ctx.GetChannelUsers(channel)
@@ -44,7 +45,12 @@ func isBotOpped(ctx ChatContextInterface) bool {
 	return false
 }
 
-// validateAdminOp validates admin permissions and bot op status.
+// notInChannelMsg is returned by channel tools invoked from a private message
+const notInChannelMsg = "This tool can only be used in a channel"
+
+// validateAdminOp validates bot op status in the channel the request came
+// from. Whether the sender may use the tool at all is checked against the
+// permissions policy before the agent runs it, see ToolRole.
 // Returns (ctx, "", nil) on success, (nil, denial-msg, nil) on policy denial,
 // or (nil, "", err) on context/lookup error.
 func validateAdminOp(ctx context.Context) (ChatContextInterface, string, error) {
@@ -55,10 +61,10 @@ func validateAdminOp(ctx context.Context) (ChatContextInterface, string, error)
 	if err := ctx.Err(); err != nil {
 		return nil, "", err
 	}
-	if !chatCtx.IsAdmin() {
-		return nil, "You are not authorized to use this tool", nil
+	if chatCtx.GetChannelName() == "" {
+		return nil, notInChannelMsg, nil
 	}
-	if !isBotOpped(chatCtx) {
+	if !isBotOpped(chatCtx, chatCtx.GetChannelName()) {
 		return nil, "Bot does not have operator status in the channel", nil
 	}
 	return chatCtx, "", nil
@@ -75,19 +81,40 @@ func validateContext(ctx context.Context) (ChatContextInterface, error) {
 	return chatCtx, nil
 }
 
+// adminTools change channel state and need the owner role unless the
+// permissions policy says otherwise
+var adminTools = map[string]bool{
+	"irc__op":       true,
+	"irc__kick":     true,
+	"irc__ban":      true,
+	"irc__topic":    true,
+	"irc__mode_set": true,
+	"irc__invite":   true,
+}
+
+// ToolRole returns the role a tool needs when the permissions policy does not
+// name it
+func ToolRole(name string) config.Role {
+	if adminTools[name] {
+		return config.RoleOwner
+	}
+	return config.RoleEveryone
+}
+
 // RegisterIRCTools registers IRC tools as native tools with polly's registry
 func RegisterIRCTools(registry *tools.ToolRegistry) {
 	factories := map[string]func() tools.Tool{
-		"irc__op":         newIrcOpTool,
-		"irc__kick":       newIrcKickTool,
-		"irc__ban":        newIrcBanTool,
-		"irc__topic":      newIrcTopicTool,
-		"irc__action":     newIrcActionTool,
-		"irc__mode_set":   newIrcModeSetTool,
-		"irc__mode_query": newIrcModeQueryTool,
-		"irc__invite":     newIrcInviteTool,
-		"irc__names":      newIrcNamesTool,
-		"irc__whois":      newIrcWhoisTool,
+		"irc__op":             newIrcOpTool,
+		"irc__kick":           newIrcKickTool,
+		"irc__ban":            newIrcBanTool,
+		"irc__topic":          newIrcTopicTool,
+		"irc__action":         newIrcActionTool,
+		"irc__mode_set":       newIrcModeSetTool,
+		"irc__mode_query":     newIrcModeQueryTool,
+		"irc__invite":         newIrcInviteTool,
+		"irc__names":          newIrcNamesTool,
+		"irc__whois":          newIrcWhoisTool,
+		"irc__history_search": newIrcHistorySearchTool,
 	}
 	for name, f := range factories {
 		registry.RegisterNative(name, f)
@@ -119,7 +146,7 @@ func newIrcOpTool() tools.Tool {
 				mode = "+o"
 			}
 
-			channel := chatCtx.GetConfig().Server.Channel
+			channel := chatCtx.GetChannelName()
 			for _, nick := range users {
 				if err := ctx.Err(); err != nil {
 					return "", err
@@ -155,7 +182,7 @@ func newIrcKickTool() tools.Tool {
 			}
 			reason := args.String("reason")
 
-			channel := chatCtx.GetConfig().Server.Channel
+			channel := chatCtx.GetChannelName()
 			for _, nick := range users {
 				if err := ctx.Err(); err != nil {
 					return "", err
@@ -206,7 +233,7 @@ func newIrcBanTool() tools.Tool {
 				}
 			}
 
-			channel := chatCtx.GetConfig().Server.Channel
+			channel := chatCtx.GetChannelName()
 			if ban {
 				chatCtx.Ban(channel, banMask)
 			} else {
@@ -234,7 +261,7 @@ func newIrcTopicTool() tools.Tool {
 			}
 
 			topic := args.String("topic")
-			channel := chatCtx.GetConfig().Server.Channel
+			channel := chatCtx.GetChannelName()
 			chatCtx.Topic(channel, topic)
 
 			chatCtx.GetLogger().Info("irc_topic", "channel", channel, "topic", topic)
@@ -258,8 +285,11 @@ func newIrcActionTool() tools.Tool {
 			}
 
 			message := args.String("message")
-			channel := chatCtx.GetConfig().Server.Channel
-			chatCtx.SendAction(channel, message)
+			target := chatCtx.GetChannelName()
+			if target == "" {
+				target = chatCtx.GetSource()
+			}
+			chatCtx.SendAction(target, message)
 
 			chatCtx.GetLogger().Info("irc_action", "message", message)
 			return fmt.Sprintf("* %s", message), nil
@@ -290,7 +320,7 @@ func newIrcModeSetTool() tools.Tool {
 			modeFlags := parts[0]
 			modeParams := parts[1:]
 
-			channel := chatCtx.GetConfig().Server.Channel
+			channel := chatCtx.GetChannelName()
 			if len(modeParams) > 0 {
 				chatCtx.SetMode(channel, modeFlags, modeParams...)
 			} else {
@@ -314,7 +344,10 @@ func newIrcModeQueryTool() tools.Tool {
 				return "", err
 			}
 
-			channel := chatCtx.GetConfig().Server.Channel
+			channel := chatCtx.GetChannelName()
+			if channel == "" {
+				return notInChannelMsg, nil
+			}
 			ch := chatCtx.GetChannel(channel)
 
 			if ch == nil {
@@ -351,7 +384,7 @@ func newIrcInviteTool() tools.Tool {
 				return "", fmt.Errorf("users must be a non-empty array of strings")
 			}
 
-			channel := chatCtx.GetConfig().Server.Channel
+			channel := chatCtx.GetChannelName()
 			for _, user := range users {
 				if err := ctx.Err(); err != nil {
 					return "", err
@@ -377,7 +410,10 @@ func newIrcNamesTool() tools.Tool {
 				return "", err
 			}
 
-			channel := chatCtx.GetConfig().Server.Channel
+			channel := chatCtx.GetChannelName()
+			if channel == "" {
+				return notInChannelMsg, nil
+			}
 			users := chatCtx.GetChannelUsers(channel)
 
 			if users == nil {
@@ -0,0 +1,84 @@
+package irc
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/alexschlessinger/pollytool/tools"
+
+	"pkdindustries/soulshack/internal/core"
+	mocktest "pkdindustries/soulshack/internal/testing"
+)
+
+func runTool(t *testing.T, tool tools.Tool, mock *mocktest.MockChatContext, args map[string]any) string {
+	t.Helper()
+	result, err := tool.Execute(InjectContext(context.Background(), mock), args)
+	if err != nil {
+		t.Fatalf("tool %s returned error: %v", tool.GetName(), err)
+	}
+	return result
+}
+
+func TestKickTool_UsesEventChannel(t *testing.T) {
+	mock := mocktest.NewMockContext().
+		WithAdmin(true).
+		WithChannelName("#dev")
+	mock.ChannelUsers["#dev"] = []core.ChannelUser{{Nick: mock.BotNick, IsOp: true}}
+
+	runTool(t, newIrcKickTool(), mock, map[string]any{"users": []any{"troll"}, "reason": "bye"})
+
+	if len(mock.KickCalls) != 1 {
+		t.Fatalf("expected 1 kick, got %d", len(mock.KickCalls))
+	}
+	if mock.KickCalls[0].Channel != "#dev" {
+		t.Errorf("expected kick in #dev, got %s", mock.KickCalls[0].Channel)
+	}
+}
+
+func TestAdminTools_RequireOpInEventChannel(t *testing.T) {
+	// Opped in #test, but the request came from #dev
+	mock := mocktest.NewMockContext().
+		WithAdmin(true).
+		WithChannelName("#dev")
+	mock.ChannelUsers["#test"] = []core.ChannelUser{{Nick: mock.BotNick, IsOp: true}}
+
+	result := runTool(t, newIrcTopicTool(), mock, map[string]any{"topic": "hello"})
+
+	if !strings.Contains(result, "operator status") {
+		t.Errorf("expected op denial, got: %s", result)
+	}
+	if len(mock.TopicCalls) != 0 {
+		t.Errorf("expected no topic change, got %v", mock.TopicCalls)
+	}
+}
+
+func TestChannelTools_DeniedInPrivate(t *testing.T) {
+	mock := mocktest.NewMockContext().
+		WithAdmin(true).
+		WithPrivate(true).
+		WithChannelName("")
+
+	for _, tool := range []tools.Tool{newIrcBanTool(), newIrcNamesTool(), newIrcModeQueryTool()} {
+		result := runTool(t, tool, mock, map[string]any{"target": "troll", "ban": true})
+		if result != notInChannelMsg {
+			t.Errorf("%s: expected %q, got %q", tool.GetName(), notInChannelMsg, result)
+		}
+	}
+	if len(mock.BanCalls) != 0 {
+		t.Errorf("expected no bans, got %v", mock.BanCalls)
+	}
+}
+
+func TestActionTool_PrivateTargetsSender(t *testing.T) {
+	mock := mocktest.NewMockContext().
+		WithPrivate(true).
+		WithChannelName("").
+		WithSource("alice")
+
+	runTool(t, newIrcActionTool(), mock, map[string]any{"message": "waves"})
+
+	if len(mock.SendActionCalls) != 1 || mock.SendActionCalls[0].Target != "alice" {
+		t.Errorf("expected action sent to alice, got %+v", mock.SendActionCalls)
+	}
+}
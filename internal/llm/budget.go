@@ -0,0 +1,193 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lrstanley/girc"
+
+	"pkdindustries/soulshack/internal/config"
+	"pkdindustries/soulshack/internal/core"
+	"pkdindustries/soulshack/internal/irc"
+	"pkdindustries/soulshack/internal/store"
+)
+
+// GlobalBudget is the scope of the budgets shared by every channel and user
+const GlobalBudget = "global"
+
+// Budget is a token allowance for one scope and period, with what has been
+// spent against it so far
+type Budget struct {
+	Scope  string // GlobalBudget, a channel, or a user
+	Period string // daily or monthly
+	Limit  int
+	Spent  int
+}
+
+// Exhausted reports whether the budget is used up
+func (b Budget) Exhausted() bool {
+	return b.Spent >= b.Limit
+}
+
+// Percentage returns how much of the budget is used
+func (b Budget) Percentage() int {
+	return b.Spent * 100 / b.Limit
+}
+
+// String describes the budget for messages, e.g. "daily token budget for #dev"
+func (b Budget) String() string {
+	if b.Scope == GlobalBudget {
+		return b.Period + " token budget"
+	}
+	return fmt.Sprintf("%s token budget for %s", b.Period, b.Scope)
+}
+
+// Renews says when the budget starts over, for messages
+func (b Budget) Renews() string {
+	if b.Period == "monthly" {
+		return "next month"
+	}
+	return "tomorrow"
+}
+
+// BudgetError is returned when a budget is used up and there is no budget
+// model to fall back to
+type BudgetError struct {
+	Budget Budget
+}
+
+func (e *BudgetError) Error() string {
+	return e.Budget.String() + " is used up"
+}
+
+// ErrorReply words a completion error for the user
+func ErrorReply(err error) string {
+	var budget *BudgetError
+	if errors.As(err, &budget) {
+		return fmt.Sprintf("Sorry, the %s is used up. Please try again %s.", budget.Budget, budget.Budget.Renews())
+	}
+	return err.Error()
+}
+
+// Budgets returns the enabled budgets that apply to a request from user in
+// channel, along with the global ones. The user is named by their
+// core.SenderIdentity. Leave channel or user empty to skip their budgets.
+func Budgets(cfg *config.Configuration, ledger *store.UsageLedger, channel, user string, now time.Time) []Budget {
+	var budgets []Budget
+	add := func(scope string, daily, monthly int, match func(store.UsageRecord) bool) {
+		periods := []struct {
+			name  string
+			limit int
+			since time.Time
+		}{
+			{"daily", daily, store.DayStart(now)},
+			{"monthly", monthly, store.MonthStart(now)},
+		}
+		for _, p := range periods {
+			if p.limit <= 0 {
+				continue
+			}
+			spent := ledger.Spent(budgetKey(scope), p.since, match)
+			budgets = append(budgets, Budget{Scope: scope, Period: p.name, Limit: p.limit, Spent: spent})
+		}
+	}
+
+	bot := cfg.Bot
+	add(GlobalBudget, bot.DailyBudget, bot.MonthlyBudget, func(store.UsageRecord) bool { return true })
+	if channel != "" {
+		add(channel, bot.ChannelDailyBudget, bot.ChannelMonthlyBudget, func(r store.UsageRecord) bool {
+			return strings.EqualFold(r.Channel, channel)
+		})
+	}
+	if user != "" {
+		add(user, bot.UserDailyBudget, bot.UserMonthlyBudget, func(r store.UsageRecord) bool {
+			return strings.EqualFold(r.User(), user)
+		})
+	}
+	return budgets
+}
+
+// ResetBudget starts the daily and monthly budgets of a scope over
+func ResetBudget(ledger *store.UsageLedger, scope string) error {
+	return ledger.ResetBudget(budgetKey(scope))
+}
+
+// budgetKey names a scope in the usage ledger, ignoring case. Users are
+// prefixed so they can't collide with the global scope.
+func budgetKey(scope string) string {
+	switch {
+	case scope == GlobalBudget:
+		return scope
+	case girc.IsValidChannel(scope):
+		return "channel:" + strings.ToLower(scope)
+	}
+	return "user:" + strings.ToLower(scope)
+}
+
+// requestAccount returns the services account of the sender, if known
+func requestAccount(ctx core.ChatContextInterface) string {
+	if user := ctx.GetUser(ctx.GetSource()); user != nil && user.Account != "*" {
+		return user.Account
+	}
+	return ""
+}
+
+// Track budget warnings to avoid repeats
+var (
+	warnedBudgets = make(map[string]int) // scope and period -> last warning percentage
+	budgetMutex   sync.Mutex
+)
+
+// checkBudgets checks the request against the token budgets, warning as they
+// fill up. It returns the model to use, which is the configured budget model
+// once any budget is used up, or a *BudgetError when there is none to fall
+// back to.
+func checkBudgets(ctx irc.ChatContextInterface) (string, error) {
+	cfg := ctx.GetConfig()
+	user := core.SenderIdentity(ctx)
+	budgets := Budgets(cfg, ctx.GetSystem().GetUsage(), ctx.GetChannelName(), user, time.Now())
+
+	model := cfg.Model.Model
+	for _, b := range budgets {
+		if !b.Exhausted() {
+			warnBudget(ctx, b, b.Percentage())
+			continue
+		}
+		if cfg.Bot.BudgetModel == "" {
+			ctx.GetLogger().Warn("budget_exhausted", "scope", b.Scope, "period", b.Period, "limit", b.Limit)
+			return "", &BudgetError{Budget: b}
+		}
+		warnBudget(ctx, b, 100)
+		model = cfg.Bot.BudgetModel
+	}
+	return model, nil
+}
+
+// warnBudget warns once as a budget passes 75% and 90%, and once when it is
+// used up and requests fall back to the budget model
+func warnBudget(ctx irc.ChatContextInterface, b Budget, percentage int) {
+	key := budgetKey(b.Scope) + ":" + b.Period
+
+	budgetMutex.Lock()
+	defer budgetMutex.Unlock()
+
+	lastWarning := warnedBudgets[key]
+
+	if percentage >= 100 && lastWarning < 100 {
+		ctx.GetLogger().Warn("budget_exhausted", "scope", b.Scope, "period", b.Period, "limit", b.Limit, "fallback", ctx.GetConfig().Bot.BudgetModel)
+		ctx.ReplyAction(fmt.Sprintf("%s used up - switching to %s", b, ctx.GetConfig().Bot.BudgetModel))
+		warnedBudgets[key] = 100
+	} else if percentage >= 90 && lastWarning < 90 {
+		ctx.ReplyAction(fmt.Sprintf("%s at 90%%", b))
+		warnedBudgets[key] = 90
+	} else if percentage >= 75 && lastWarning < 75 {
+		ctx.ReplyAction(fmt.Sprintf("%s at 75%%", b))
+		warnedBudgets[key] = 75
+	} else if percentage < 75 && lastWarning > 0 {
+		// A new period or a reset starts the warnings over
+		delete(warnedBudgets, key)
+	}
+}
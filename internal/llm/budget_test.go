@@ -0,0 +1,133 @@
+package llm
+
+import (
+	"errors"
+	"testing"
+
+	"pkdindustries/soulshack/internal/config"
+	"pkdindustries/soulshack/internal/core"
+	"pkdindustries/soulshack/internal/store"
+	mocktest "pkdindustries/soulshack/internal/testing"
+)
+
+func TestCheckBudgets(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(*config.BotConfig)
+		spent     []store.UsageRecord
+		wantModel string
+		wantErr   string
+		wantWarn  string
+	}{
+		{
+			name:      "no budgets",
+			spent:     []store.UsageRecord{{Nick: "testuser", Channel: "#test", InputTokens: 1000}},
+			wantModel: "test/model",
+		},
+		{
+			name:      "under budget",
+			configure: func(b *config.BotConfig) { b.DailyBudget = 1000 },
+			spent:     []store.UsageRecord{{Nick: "testuser", Channel: "#test", InputTokens: 100}},
+			wantModel: "test/model",
+		},
+		{
+			name:      "warns at 75%",
+			configure: func(b *config.BotConfig) { b.ChannelDailyBudget = 1000 },
+			spent:     []store.UsageRecord{{Nick: "other", Channel: "#test", InputTokens: 800}},
+			wantModel: "test/model",
+			wantWarn:  "daily token budget for #test at 75%",
+		},
+		{
+			name:      "other channels don't count",
+			configure: func(b *config.BotConfig) { b.ChannelDailyBudget = 1000 },
+			spent:     []store.UsageRecord{{Nick: "testuser", Channel: "#other", InputTokens: 5000}},
+			wantModel: "test/model",
+		},
+		{
+			name:      "user budget refuses",
+			configure: func(b *config.BotConfig) { b.UserMonthlyBudget = 1000 },
+			spent:     []store.UsageRecord{{Nick: "testuser", Identity: "~test@example.com", Channel: "#other", InputTokens: 600, OutputTokens: 400}},
+			wantErr:   "Sorry, the monthly token budget for ~test@example.com is used up. Please try again next month.",
+		},
+		{
+			name:      "user budget follows the sender across nicks and case",
+			configure: func(b *config.BotConfig) { b.UserDailyBudget = 1000 },
+			spent:     []store.UsageRecord{{Nick: "oldnick", Identity: "~Test@Example.com", InputTokens: 1000}},
+			wantErr:   "Sorry, the daily token budget for ~test@example.com is used up. Please try again tomorrow.",
+		},
+		{
+			name: "falls back to the budget model",
+			configure: func(b *config.BotConfig) {
+				b.DailyBudget = 1000
+				b.BudgetModel = "test/cheap"
+			},
+			spent:     []store.UsageRecord{{Nick: "other", InputTokens: 1000}},
+			wantModel: "test/cheap",
+			wantWarn:  "daily token budget used up - switching to test/cheap",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnedBudgets = make(map[string]int)
+			cfg := mocktest.DefaultTestConfig()
+			cfg.Model.Model = "test/model"
+			if tt.configure != nil {
+				tt.configure(cfg.Bot)
+			}
+			sys := mocktest.NewMockSystem()
+			for _, r := range tt.spent {
+				sys.Usage.Record(r)
+			}
+			ctx := mocktest.NewMockContext().WithConfig(cfg).WithSystem(sys)
+			ctx.Users["testuser"] = &core.UserInfo{Nick: "testuser", Ident: "~test", Host: "example.com"}
+
+			model, err := checkBudgets(ctx)
+			if tt.wantErr != "" {
+				var budget *BudgetError
+				if !errors.As(err, &budget) {
+					t.Fatalf("expected a budget error, got %v", err)
+				}
+				if reply := ErrorReply(err); reply != tt.wantErr {
+					t.Errorf("expected reply %q, got %q", tt.wantErr, reply)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if model != tt.wantModel {
+				t.Errorf("expected model %q, got %q", tt.wantModel, model)
+			}
+			if tt.wantWarn == "" && len(ctx.Actions) > 0 {
+				t.Errorf("expected no warning, got %v", ctx.Actions)
+			}
+			if tt.wantWarn != "" && (len(ctx.Actions) != 1 || ctx.Actions[0] != tt.wantWarn) {
+				t.Errorf("expected warning %q, got %v", tt.wantWarn, ctx.Actions)
+			}
+		})
+	}
+}
+
+func TestCheckBudgets_WarnsOnceAndAfterReset(t *testing.T) {
+	warnedBudgets = make(map[string]int)
+	cfg := mocktest.DefaultTestConfig()
+	cfg.Bot.DailyBudget = 1000
+	sys := mocktest.NewMockSystem()
+	sys.Usage.Record(store.UsageRecord{Nick: "testuser", InputTokens: 950})
+	ctx := mocktest.NewMockContext().WithConfig(cfg).WithSystem(sys)
+
+	checkBudgets(ctx)
+	checkBudgets(ctx)
+	if len(ctx.Actions) != 1 {
+		t.Fatalf("expected a single 90%% warning, got %v", ctx.Actions)
+	}
+
+	ResetBudget(sys.Usage, GlobalBudget)
+	checkBudgets(ctx)
+	sys.Usage.Record(store.UsageRecord{Nick: "testuser", InputTokens: 950})
+	checkBudgets(ctx)
+	if len(ctx.Actions) != 2 {
+		t.Errorf("expected the warning again after a reset, got %v", ctx.Actions)
+	}
+}
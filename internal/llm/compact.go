@@ -0,0 +1,135 @@
+package llm
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/alexschlessinger/pollytool/messages"
+	"github.com/alexschlessinger/pollytool/sessions"
+
+	"pkdindustries/soulshack/internal/irc"
+)
+
+// summaryPrefix starts the message that replaces compacted history
+const summaryPrefix = "Summary of the earlier conversation:\n"
+
+// compactPrompt instructs the model writing a compaction summary
+const compactPrompt = `You summarize IRC conversations for a chat bot that takes part in them. ` +
+	`Write a concise summary of the conversation below: who said what, questions and answers, ` +
+	`decisions, facts about people, and anything that was left open. Keep nicks as they appear. ` +
+	`Reply with the summary only.`
+
+// compactToolResultMax caps how much of a tool result goes into the summary request
+const compactToolResultMax = 500
+
+// compactSession summarizes the oldest part of the session once it reaches
+// the compaction threshold, replacing it with a single summary message, and
+// reports whether it did. Left alone, the session trims that history away
+// instead. The summary is a direct call to the model, so nothing of it
+// reaches IRC.
+func compactSession(ctx irc.ChatContextInterface, model string) (bool, error) {
+	cfg := ctx.GetConfig()
+	session := ctx.GetSession()
+	if cfg.Session.CompactAt <= 0 || session.GetCapacityPercentage() < float64(cfg.Session.CompactAt) {
+		return false, nil
+	}
+
+	history := session.GetHistory()
+	start, cut := compactionCut(history)
+	if cut == 0 {
+		return false, nil
+	}
+
+	req := NewCompletionRequest(cfg, session, nil)
+	req.Model = cmp.Or(cfg.Session.CompactModel, model)
+	req.Messages = []messages.ChatMessage{
+		{Role: messages.MessageRoleSystem, Content: compactPrompt},
+		{Role: messages.MessageRoleUser, Content: renderHistory(history[start:cut])},
+	}
+	setBaseURL(cfg, req)
+
+	reply, err := ctx.GetSystem().GetLLM().Complete(ctx, req)
+	if err != nil {
+		return false, err
+	}
+	recordUsage(ctx, req.Model, reply.GetInputTokens(), reply.GetOutputTokens())
+	summary := strings.TrimSpace(reply.Content)
+	if summary == "" {
+		return false, errors.New("empty summary")
+	}
+
+	// Clear keeps the system prompt; the summary stands in for everything
+	// up to the cut
+	session.Clear()
+	session.AddMessage(messages.ChatMessage{Role: messages.MessageRoleUser, Content: summaryPrefix + summary})
+	for _, msg := range history[cut:] {
+		session.AddMessage(msg)
+	}
+
+	ctx.GetLogger().Info("session_compacted",
+		"messages", cut-start,
+		"summary_tokens", sessions.EstimateTokens(messages.ChatMessage{Content: summary}),
+		"model", req.Model,
+	)
+	return true, nil
+}
+
+// compactionCut picks the oldest part of history to summarize: from after the
+// system prompt up to cut, covering about half the history's tokens. cut is
+// always an assistant message answering a user message, so the kept history
+// follows the summary with a reply and tool calls stay with their results.
+// cut is 0 when there is no such message.
+func compactionCut(history []messages.ChatMessage) (start, cut int) {
+	if len(history) > 0 && history[0].Role == messages.MessageRoleSystem {
+		start = 1
+	}
+	total := 0
+	for _, msg := range history[start:] {
+		total += sessions.GetMessageTokens(msg)
+	}
+
+	used := 0
+	for i := start; i < len(history); i++ {
+		if i > start+1 && history[i].Role == messages.MessageRoleAssistant && history[i-1].Role == messages.MessageRoleUser {
+			cut = i
+			if used >= total/2 {
+				break
+			}
+		}
+		used += sessions.GetMessageTokens(history[i])
+	}
+	return start, cut
+}
+
+// renderHistory writes messages out as a plain transcript for the summarizer
+func renderHistory(history []messages.ChatMessage) string {
+	var b strings.Builder
+	for _, msg := range history {
+		switch msg.Role {
+		case messages.MessageRoleUser:
+			fmt.Fprintf(&b, "%s\n", msg.Content)
+		case messages.MessageRoleAssistant:
+			if msg.Content != "" {
+				fmt.Fprintf(&b, "(bot) %s\n", msg.Content)
+			}
+			for _, tc := range msg.ToolCalls {
+				fmt.Fprintf(&b, "(bot called %s) %s\n", tc.Name, tc.Arguments)
+			}
+		case messages.MessageRoleTool:
+			result := msg.Content
+			if len(result) > compactToolResultMax {
+				// Cut on a rune boundary so the request stays valid UTF-8
+				cut := compactToolResultMax
+				for cut > 0 && !utf8.RuneStart(result[cut]) {
+					cut--
+				}
+				result = result[:cut] + "..."
+			}
+			fmt.Fprintf(&b, "(tool result) %s\n", result)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,143 @@
+package llm
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/alexschlessinger/pollytool/messages"
+	"github.com/alexschlessinger/pollytool/sessions"
+
+	mocktest "pkdindustries/soulshack/internal/testing"
+)
+
+func TestCompactionCut(t *testing.T) {
+	system := messages.ChatMessage{Role: messages.MessageRoleSystem, Content: "prompt"}
+	user := messages.ChatMessage{Role: messages.MessageRoleUser, Content: strings.Repeat("u", 400)}
+	reply := messages.ChatMessage{Role: messages.MessageRoleAssistant, Content: strings.Repeat("a", 400)}
+	call := messages.ChatMessage{Role: messages.MessageRoleAssistant, ToolCalls: []messages.ChatMessageToolCall{{ID: "1", Name: "web__fetch"}}}
+	result := messages.ChatMessage{Role: messages.MessageRoleTool, ToolCallID: "1", Content: strings.Repeat("r", 40)}
+	short := messages.ChatMessage{Role: messages.MessageRoleUser, Content: "ok"}
+	ack := messages.ChatMessage{Role: messages.MessageRoleAssistant, Content: "ok"}
+
+	tests := []struct {
+		name    string
+		history []messages.ChatMessage
+		cut     int
+	}{
+		{"too short", []messages.ChatMessage{system, user, reply}, 0},
+		{"half", []messages.ChatMessage{system, user, reply, user, reply, user, reply}, 4},
+		{"keeps tool calls with results", []messages.ChatMessage{system, user, reply, user, call, result, ack, short, ack}, 4},
+		{"no system prompt", []messages.ChatMessage{user, reply, user, reply}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, cut := compactionCut(tt.history); cut != tt.cut {
+				t.Errorf("expected cut at %d, got %d", tt.cut, cut)
+			}
+		})
+	}
+}
+
+func TestRenderHistory_CutsToolResultsOnRunes(t *testing.T) {
+	// 499 bytes, then a 3-byte rune straddling the cap
+	result := messages.ChatMessage{Role: messages.MessageRoleTool, Content: strings.Repeat("r", compactToolResultMax-1) + "€€"}
+	got := renderHistory([]messages.ChatMessage{result})
+	if !utf8.ValidString(got) {
+		t.Fatalf("expected valid UTF-8, got %q", got)
+	}
+	if want := "(tool result) " + strings.Repeat("r", compactToolResultMax-1) + "...\n"; got != want {
+		t.Errorf("expected the result cut before the rune, got %q", got)
+	}
+}
+
+func TestCompactSession(t *testing.T) {
+	store := sessions.NewSyncMapSessionStore(&sessions.Metadata{MaxHistoryTokens: 1000, SystemPrompt: "prompt"})
+	session, _ := store.Get("#test")
+	for i := range 3 {
+		session.AddMessage(messages.ChatMessage{Role: messages.MessageRoleUser, Content: "(nick:alice) " + strings.Repeat("q", 400)})
+		session.AddMessage(messages.ChatMessage{Role: messages.MessageRoleAssistant, Content: strings.Repeat(string(rune('a'+i)), 400)})
+	}
+
+	sys := mocktest.NewMockSystem()
+	model := &mocktest.MockLLM{Responses: []string{"**alice** asked about q twice"}}
+	sys.LLM = model
+	cfg := mocktest.DefaultTestConfig()
+	ctx := mocktest.NewMockContext().WithConfig(cfg).WithSystem(sys).WithSession(session)
+
+	cfg.Session.CompactAt = 80
+	if compacted, err := compactSession(ctx, "test/model"); compacted || err != nil {
+		t.Fatalf("compactSession: %v, %v", compacted, err)
+	}
+	if len(session.GetHistory()) != 7 {
+		t.Fatalf("expected no compaction below the threshold, got %d messages", len(session.GetHistory()))
+	}
+
+	cfg.Session.CompactAt = 50
+	if compacted, err := compactSession(ctx, "test/model"); !compacted || err != nil {
+		t.Fatalf("compactSession: %v, %v", compacted, err)
+	}
+	history := session.GetHistory()
+	if len(history) != 5 {
+		t.Fatalf("expected system, summary and the newer half of history, got %d messages", len(history))
+	}
+	// The summary is kept as the model wrote it, without IRC formatting
+	if history[0].Role != messages.MessageRoleSystem || history[1].Content != summaryPrefix+"**alice** asked about q twice" {
+		t.Errorf("unexpected start of history: %+v", history[:2])
+	}
+	if len(model.Completions) != 1 || len(ctx.Replies) != 0 || len(ctx.Actions) != 0 {
+		t.Errorf("expected one direct completion and nothing sent, got %d, %v, %v", len(model.Completions), ctx.Replies, ctx.Actions)
+	}
+	if history[2].Role != messages.MessageRoleAssistant || !strings.HasPrefix(history[2].Content, "bbb") {
+		t.Errorf("expected the kept history to start with a reply, got %+v", history[2])
+	}
+}
+
+func TestCompactSession_FailureKeepsHistory(t *testing.T) {
+	store := sessions.NewSyncMapSessionStore(&sessions.Metadata{MaxHistoryTokens: 500})
+	session, _ := store.Get("#test")
+	for range 3 {
+		session.AddMessage(messages.ChatMessage{Role: messages.MessageRoleUser, Content: strings.Repeat("q", 200)})
+		session.AddMessage(messages.ChatMessage{Role: messages.MessageRoleAssistant, Content: strings.Repeat("a", 200)})
+	}
+
+	sys := mocktest.NewMockSystem()
+	sys.LLM = &mocktest.MockLLM{Error: errors.New("rate limited")}
+	cfg := mocktest.DefaultTestConfig()
+	cfg.Session.CompactAt = 50
+	ctx := mocktest.NewMockContext().WithConfig(cfg).WithSystem(sys).WithSession(session)
+
+	if compacted, err := compactSession(ctx, "test/model"); compacted || err == nil {
+		t.Fatalf("expected an error, got %v, %v", compacted, err)
+	}
+	if len(session.GetHistory()) != 6 {
+		t.Errorf("expected history untouched, got %d messages", len(session.GetHistory()))
+	}
+}
+
+func TestComplete_WarnsWhenCompactionFails(t *testing.T) {
+	warnedSessions = make(map[string]int)
+	store := sessions.NewSyncMapSessionStore(&sessions.Metadata{MaxHistoryTokens: 1000})
+	session, _ := store.Get("#test")
+	for range 4 {
+		session.AddMessage(messages.ChatMessage{Role: messages.MessageRoleUser, Content: strings.Repeat("q", 380)})
+		session.AddMessage(messages.ChatMessage{Role: messages.MessageRoleAssistant, Content: strings.Repeat("a", 380)})
+	}
+
+	sys := mocktest.NewMockSystem()
+	sys.LLM = &mocktest.MockLLM{Error: errors.New("rate limited")}
+	cfg := mocktest.DefaultTestConfig()
+	cfg.Session.CompactAt = 50
+	ctx := mocktest.NewMockContext().WithConfig(cfg).WithSystem(sys).WithSession(session)
+
+	out, err := Complete(ctx, "hello")
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	for range out {
+	}
+	if len(ctx.Actions) != 1 || ctx.Actions[0] != "Session at 75% capacity" {
+		t.Errorf("expected the capacity warning after compaction failed, got %v", ctx.Actions)
+	}
+}
@@ -1,6 +1,7 @@
 package llm
 
 import (
+	"path"
 	"sync"
 
 	"github.com/alexschlessinger/pollytool/llm"
@@ -60,7 +61,7 @@ func NewCompletionRequest(config *config.Configuration, session sessions.Session
 		Timeout:        config.API.Timeout,
 		Model:          config.Model.Model,
 		MaxTokens:      config.Model.MaxTokens,
-		Messages:       session.GetHistory(),
+		Messages:       withSystemPrompt(session.GetHistory(), config.Bot.Prompt),
 		Temperature:    llm.Float32Ptr(config.Model.Temperature),
 		Tools:          tools,
 		ThinkingEffort: thinkingEffort,
@@ -75,10 +76,58 @@ func NewCompletionRequest(config *config.Configuration, session sessions.Session
 	return req
 }
 
+// withSystemPrompt makes the leading system message match prompt. Sessions
+// keep the prompt they were created with, so this is what applies prompt
+// changes and per-channel prompts to existing history.
+func withSystemPrompt(history []messages.ChatMessage, prompt string) []messages.ChatMessage {
+	if len(history) > 0 && history[0].Role == messages.MessageRoleSystem {
+		if prompt == "" {
+			return history[1:]
+		}
+		history[0].Content = prompt
+		return history
+	}
+	if prompt == "" {
+		return history
+	}
+	return append([]messages.ChatMessage{{Role: messages.MessageRoleSystem, Content: prompt}}, history...)
+}
+
+// FilterTools returns the tools whose names match any of patterns
+// (path.Match syntax). An empty pattern list allows every tool.
+func FilterTools(all []tools.Tool, patterns []string) []tools.Tool {
+	if len(patterns) == 0 {
+		return all
+	}
+	var allowed []tools.Tool
+	for _, tool := range all {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, tool.GetName()); ok {
+				allowed = append(allowed, tool)
+				break
+			}
+		}
+	}
+	return allowed
+}
+
 // Complete processes a user message and returns a channel of response chunks.
 func Complete(ctx irc.ChatContextInterface, msg string) (<-chan string, error) {
-	// Check session capacity and warn if approaching limits
-	checkSessionCapacity(ctx)
+	// Check token budgets before anything is added to the session
+	model, err := checkBudgets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Summarize old history rather than lose it to trimming. Unless that
+	// just made room, warn if the session is approaching its limit.
+	compacted, err := compactSession(ctx, model)
+	if err != nil {
+		ctx.GetLogger().Warn("session_compaction_failed", "error", err)
+	}
+	if !compacted {
+		checkSessionCapacity(ctx)
+	}
 
 	// Add user message to session
 	cmsg := messages.ChatMessage{
@@ -99,10 +148,11 @@ func Complete(ctx irc.ChatContextInterface, msg string) (<-chan string, error) {
 
 	var allTools []tools.Tool
 	if sys.GetToolRegistry() != nil {
-		allTools = sys.GetToolRegistry().All()
+		allTools = FilterTools(sys.GetToolRegistry().All(), cfg.Bot.AllowedTools)
 	}
 
 	req := NewCompletionRequest(cfg, session, allTools)
+	req.Model = model
 
 	// Get response stream from LLM
 	stream := sys.GetLLM().ChatCompletionStream(ctx, req)
@@ -342,3 +342,24 @@ func TestCheckSessionCapacity_NoLimitSet(t *testing.T) {
 		t.Errorf("expected no warnings when no limit set, got: %v", mockCtx.Actions)
 	}
 }
+
+func TestNewCompletionRequest_UsesEffectivePrompt(t *testing.T) {
+	mockSys := mocktest.NewMockSystem()
+	session, _ := mockSys.SessionStore.Get("#dev")
+	session.AddMessage(messages.ChatMessage{Role: messages.MessageRoleUser, Content: "hi"})
+
+	cfg := mocktest.DefaultTestConfig()
+	cfg.Bot.Prompt = "be terse"
+
+	req := NewCompletionRequest(cfg, session, nil)
+
+	if len(req.Messages) == 0 || req.Messages[0].Role != messages.MessageRoleSystem {
+		t.Fatalf("expected leading system message, got %+v", req.Messages)
+	}
+	if req.Messages[0].Content != "be terse" {
+		t.Errorf("expected effective prompt, got %q", req.Messages[0].Content)
+	}
+	if history := session.GetHistory(); len(history) > 0 && history[0].Content == "be terse" {
+		t.Error("session history should not be modified")
+	}
+}
@@ -2,6 +2,7 @@ package llm
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -13,6 +14,8 @@ import (
 	"pkdindustries/soulshack/internal/config"
 	"pkdindustries/soulshack/internal/core"
 	"pkdindustries/soulshack/internal/irc"
+	"pkdindustries/soulshack/internal/metrics"
+	"pkdindustries/soulshack/internal/store"
 )
 
 // PollyLLM wraps pollytool's MultiPass and Agent to implement soulshack's LLM interface
@@ -31,65 +34,136 @@ func NewPollyLLM(config config.APIConfig) *PollyLLM {
 	return &PollyLLM{client: llm.NewMultiPass(apiKeys)}
 }
 
-// ChatCompletionStream returns a channel of string chunks for IRC output
+// ChatCompletionStream returns a channel of string chunks for IRC output,
+// rendered in the configured format and chunked. Text the bot keeps for
+// itself, such as compaction summaries, comes from Complete instead.
 func (p *PollyLLM) ChatCompletionStream(chatCtx core.ChatContextInterface, req *CompletionRequest) <-chan string {
 	cfg := chatCtx.GetConfig()
-	// Only apply OllamaURL for ollama/ models
-	if strings.HasPrefix(req.Model, "ollama/") && cfg.API.OllamaURL != "" {
-		req.BaseURL = cfg.API.OllamaURL
-	}
+	setBaseURL(cfg, req)
 
 	maxChunkSize := 400
 	if cfg.Session.ChunkMax > 0 {
 		maxChunkSize = cfg.Session.ChunkMax
 	}
+	// Multiline replies are split at chunkmax when they are sent, and joined
+	// again by the client, so lines only need cutting to fit a batch
+	if maxBytes, ok := chatCtx.Multiline(); ok {
+		maxChunkSize = maxBytes
+	}
 
 	output := make(chan string, 10)
 
 	go func() {
 		defer close(output)
 
-		agent := llm.NewAgent(p.client, chatCtx.GetSystem().GetToolRegistry(), llm.AgentConfig{
+		// The agent offers every tool in its registry, so restricted
+		// channels, and requests without tools, get a registry holding only
+		// the tools they allow
+		registry := chatCtx.GetSystem().GetToolRegistry()
+		if len(cfg.Bot.AllowedTools) > 0 || len(req.Tools) == 0 {
+			registry = tools.NewToolRegistry(req.Tools)
+		}
+
+		agent := llm.NewAgent(p.client, registry, llm.AgentConfig{
 			MaxIterations: 10,
 			ToolTimeout:   cfg.API.Timeout,
 		})
 
-		chunker := irc.NewChunker(output, maxChunkSize)
-		cb := newCallbackHandler(chatCtx, chunker, cfg)
+		renderer := irc.NewRenderer(irc.NewChunker(output, maxChunkSize), cfg.Session.Format)
+		cb := newCallbackHandler(chatCtx, renderer, cfg, startAgentTrace(chatCtx, req.Model))
+		cb.typing = startTyping(chatCtx)
 
+		start := time.Now()
 		resp, err := agent.Run(chatCtx, req, cb.build())
+		metrics.LLMDuration.WithLabelValues(req.Model).Observe(time.Since(start).Seconds())
+		cb.trace.end(err)
+		cb.typing.end()
 
-		chunker.Flush()
+		renderer.Flush()
 
 		if err != nil {
 			chatCtx.GetLogger().Error("agent_error", "error", err.Error())
+			metrics.LLMRequests.WithLabelValues(req.Model, "error").Inc()
 			return
 		}
 
+		input, output := 0, 0
 		for _, msg := range resp.AllMessages {
 			chatCtx.GetSession().AddMessage(msg)
+			input += msg.GetInputTokens()
+			output += msg.GetOutputTokens()
 		}
+		metrics.LLMRequests.WithLabelValues(req.Model, "ok").Inc()
+		metrics.LLMTokens.WithLabelValues(req.Model, "input").Add(float64(input))
+		metrics.LLMTokens.WithLabelValues(req.Model, "output").Add(float64(output))
+		chatCtx.GetSystem().GetRateLimiter().Spend(chatCtx, input+output)
+		recordUsage(chatCtx, req.Model, input, output)
 	}()
 
 	return output
 }
 
+// Complete runs one completion straight against the provider, without the
+// agent, rendering or chunking
+func (p *PollyLLM) Complete(ctx context.Context, req *CompletionRequest) (messages.ChatMessage, error) {
+	for event := range p.client.ChatCompletionStream(ctx, req, &llm.SimpleProcessor{}) {
+		switch event.Type {
+		case messages.EventTypeComplete:
+			return *event.Message, nil
+		case messages.EventTypeError:
+			return messages.ChatMessage{}, event.Error
+		}
+	}
+	return messages.ChatMessage{}, errors.New("no response from LLM")
+}
+
+// setBaseURL points ollama/ models at the configured Ollama server
+func setBaseURL(cfg *config.Configuration, req *CompletionRequest) {
+	if strings.HasPrefix(req.Model, "ollama/") && cfg.API.OllamaURL != "" {
+		req.BaseURL = cfg.API.OllamaURL
+	}
+}
+
+// recordUsage adds a finished request to the usage ledger
+func recordUsage(chatCtx core.ChatContextInterface, model string, input, output int) {
+	if input == 0 && output == 0 {
+		return
+	}
+	record := store.UsageRecord{
+		Nick:         chatCtx.GetSource(),
+		Account:      requestAccount(chatCtx),
+		Identity:     core.SenderIdentity(chatCtx),
+		Channel:      chatCtx.GetChannelName(),
+		Model:        model,
+		InputTokens:  input,
+		OutputTokens: output,
+		Cost:         chatCtx.GetConfig().Cost(model, input, output),
+	}
+	if err := chatCtx.GetSystem().GetUsage().Record(record); err != nil {
+		chatCtx.GetLogger().Warn("usage_record_failed", "error", err)
+	}
+}
+
 // callbackHandler organizes callback construction
 type callbackHandler struct {
 	chatCtx          core.ChatContextInterface
-	chunker          *irc.Chunker
+	renderer         *irc.Renderer
 	cfg              *config.Configuration
 	startTime        time.Time
 	lastThinkingTime time.Time
 	toolCount        int
+	denied           map[string]bool // calls refused in this round, by ID and name
+	trace            *agentTrace
+	typing           *typingIndicator
 }
 
-func newCallbackHandler(chatCtx core.ChatContextInterface, chunker *irc.Chunker, cfg *config.Configuration) *callbackHandler {
+func newCallbackHandler(chatCtx core.ChatContextInterface, renderer *irc.Renderer, cfg *config.Configuration, trace *agentTrace) *callbackHandler {
 	return &callbackHandler{
 		chatCtx:   chatCtx,
-		chunker:   chunker,
+		renderer:  renderer,
 		cfg:       cfg,
 		startTime: time.Now(),
+		trace:     trace,
 	}
 }
 
@@ -99,6 +173,7 @@ func (h *callbackHandler) build() *llm.AgentCallbacks {
 		OnContent:         h.onContent,
 		BeforeToolExecute: h.beforeToolExecute,
 		OnToolStart:       h.onToolStart,
+		ApproveToolCalls:  h.approveToolCalls,
 		OnToolEnd:         h.onToolEnd,
 		OnComplete:        h.onComplete,
 		OnError:           h.onError,
@@ -122,6 +197,7 @@ func (h *callbackHandler) onComplete(response *messages.ChatMessage) {
 
 func (h *callbackHandler) onReasoning(content string) {
 	h.chatCtx.GetLogger().Debug("reasoning_chunk", "content", content)
+	h.setTyping(core.TypingActive)
 
 	if !h.cfg.Bot.ShowThinkingAction {
 		return
@@ -142,21 +218,53 @@ func (h *callbackHandler) onContent(content string) {
 		"content", content,
 		"content_len", len(content),
 	)
-	h.chunker.Write(content)
+	h.setTyping(core.TypingActive)
+	h.renderer.Write(content)
+}
+
+// setTyping updates the typing indicator, if the request has one
+func (h *callbackHandler) setTyping(state string) {
+	if h.typing != nil {
+		h.typing.set(state)
+	}
 }
 
 func (h *callbackHandler) beforeToolExecute(ctx context.Context, tc messages.ChatMessageToolCall, args map[string]any) context.Context {
-	return irc.InjectContext(ctx, h.chatCtx)
+	return irc.InjectContext(h.trace.toolStart(ctx, tc), h.chatCtx)
 }
 
-func (h *callbackHandler) onToolStart(calls []messages.ChatMessageToolCall) {
-	h.chunker.Flush()
+// approveToolCalls denies tools the sender's role is not allowed to use, and
+// announces the ones that will run. The agent asks only after onToolStart,
+// so denied calls are never announced.
+func (h *callbackHandler) approveToolCalls(calls []messages.ChatMessageToolCall) []bool {
+	role := h.chatCtx.GetRole()
+	approved := make([]bool, len(calls))
+	var running []messages.ChatMessageToolCall
+	h.denied = make(map[string]bool)
+	for i, tc := range calls {
+		required := h.cfg.Permissions.Tool(tc.Name, irc.ToolRole(tc.Name))
+		approved[i] = role >= required
+		if approved[i] {
+			running = append(running, tc)
+			continue
+		}
+		h.denied[tc.ID+tc.Name] = true
+		h.chatCtx.GetLogger().Warn("tool_denied", "tool", tc.Name, "role", role, "required", required)
+		if required > config.RoleEveryone {
+			h.auditTool(tc, "denied", "")
+		}
+	}
+	h.announceTools(running)
+	return approved
+}
 
+// announceTools counts and logs the tool calls about to run and, with tool
+// actions on, tells the channel
+func (h *callbackHandler) announceTools(calls []messages.ChatMessageToolCall) {
 	h.toolCount += len(calls)
-
-	// Log each tool
 	for _, tc := range calls {
 		h.chatCtx.GetLogger().Info("tool_started", "tool", tc.Name)
+		metrics.ToolCalls.WithLabelValues(tc.Name).Inc()
 	}
 
 	if !h.cfg.Bot.ShowToolActions || len(calls) == 0 {
@@ -181,8 +289,42 @@ func (h *callbackHandler) onToolStart(calls []messages.ChatMessageToolCall) {
 	}
 }
 
+// auditTool records a call to a privileged tool in the audit log
+func (h *callbackHandler) auditTool(tc messages.ChatMessageToolCall, outcome, detail string) {
+	entry := core.NewAuditEntry(h.chatCtx, "tool", tc.Name)
+	entry.Args = tc.Arguments
+	entry.Outcome = outcome
+	entry.Detail = detail
+	core.Audit(h.chatCtx, entry)
+}
+
+func (h *callbackHandler) onToolStart(calls []messages.ChatMessageToolCall) {
+	h.renderer.Flush()
+	h.setTyping(core.TypingPaused)
+	h.trace.toolsStarted(calls)
+}
+
 func (h *callbackHandler) onToolEnd(tc messages.ChatMessageToolCall, result string, duration time.Duration, toolErr error) {
+	h.trace.toolEnd(tc, toolErr)
+	h.setTyping(core.TypingActive)
+
+	// Denied calls end here too without running; they were logged and
+	// audited when refused
+	if h.denied[tc.ID+tc.Name] {
+		return
+	}
+	metrics.ToolDuration.WithLabelValues(tc.Name).Observe(duration.Seconds())
+
+	if required := h.cfg.Permissions.Tool(tc.Name, irc.ToolRole(tc.Name)); required > config.RoleEveryone {
+		outcome, detail := "ok", result
+		if toolErr != nil {
+			outcome, detail = "error", toolErr.Error()
+		}
+		h.auditTool(tc, outcome, detail)
+	}
+
 	if toolErr != nil {
+		metrics.ToolFailures.WithLabelValues(tc.Name).Inc()
 		h.chatCtx.GetLogger().Error("tool_failed",
 			"tool", tc.Name,
 			"duration_ms", duration.Milliseconds(),
@@ -205,7 +347,7 @@ func (h *callbackHandler) onToolEnd(tc messages.ChatMessageToolCall, result stri
 
 func (h *callbackHandler) onError(err error) {
 	h.chatCtx.GetLogger().Error("stream_error", "error", err.Error())
-	h.chunker.Write(fmt.Sprintf("Error: %v", err))
+	h.renderer.Write(fmt.Sprintf("Error: %v", err))
 }
 
 // CreateAgentForRegistry creates an agent with the given registry for external use
@@ -0,0 +1,84 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/alexschlessinger/pollytool/messages"
+
+	"pkdindustries/soulshack/internal/config"
+	"pkdindustries/soulshack/internal/metrics"
+	mocktest "pkdindustries/soulshack/internal/testing"
+)
+
+func TestCallbackHandler_AuditsPrivilegedTools(t *testing.T) {
+	sys := mocktest.NewMockSystem()
+	kick := messages.ChatMessageToolCall{ID: "1", Name: "irc__kick", Arguments: `{"nick":"eve"}`}
+	fetch := messages.ChatMessageToolCall{ID: "2", Name: "web__fetch", Arguments: `{"url":"x"}`}
+
+	// an everyone-role sender is refused the kick
+	ctx := mocktest.NewMockContext().WithSystem(sys).WithSource("eve")
+	h := newCallbackHandler(ctx, nil, ctx.GetConfig(), startAgentTrace(context.Background(), "test/model"))
+	approved := h.approveToolCalls([]messages.ChatMessageToolCall{kick, fetch})
+	if approved[0] || !approved[1] {
+		t.Fatalf("unexpected approvals: %v", approved)
+	}
+	h.onToolEnd(kick, "Tool call denied by user.", 0, nil)
+	h.onToolEnd(fetch, "page", 0, nil)
+
+	// an owner's kick runs and fails
+	ctx = mocktest.NewMockContext().WithSystem(sys).WithAdmin(true).WithSource("alice")
+	h = newCallbackHandler(ctx, nil, ctx.GetConfig(), startAgentTrace(context.Background(), "test/model"))
+	h.approveToolCalls([]messages.ChatMessageToolCall{kick})
+	h.onToolEnd(kick, "", 0, errors.New("not an operator"))
+
+	entries := sys.Audit.Recent(10, nil)
+	if len(entries) != 2 {
+		t.Fatalf("expected only the kicks audited, got %+v", entries)
+	}
+	if e := entries[1]; e.Nick != "eve" || e.Kind != "tool" || e.Outcome != "denied" || e.Args != kick.Arguments {
+		t.Errorf("unexpected denied entry: %+v", e)
+	}
+	if e := entries[0]; e.Nick != "alice" || e.Outcome != "error" || e.Detail != "not an operator" {
+		t.Errorf("unexpected failed entry: %+v", e)
+	}
+}
+
+func TestCallbackHandler_AnnouncesApprovedTools(t *testing.T) {
+	kick := messages.ChatMessageToolCall{ID: "1", Name: "irc__kick"}
+	fetch := messages.ChatMessageToolCall{ID: "2", Name: "web__fetch"}
+
+	ctx := mocktest.NewMockContext().WithSource("eve")
+	ctx.GetConfig().Bot.ShowToolActions = true
+	h := newCallbackHandler(ctx, nil, ctx.GetConfig(), startAgentTrace(context.Background(), "test/model"))
+	h.approveToolCalls([]messages.ChatMessageToolCall{kick, fetch})
+	h.approveToolCalls([]messages.ChatMessageToolCall{kick})
+
+	if !slices.Equal(ctx.Actions, []string{"calling fetch"}) {
+		t.Errorf("expected only the approved call announced, got %v", ctx.Actions)
+	}
+}
+
+func TestCallbackHandler_DeniedCallsNotCounted(t *testing.T) {
+	call := messages.ChatMessageToolCall{ID: "1", Name: "test__denied"}
+	ctx := mocktest.NewMockContext().WithSource("eve")
+	ctx.GetConfig().Permissions = &config.Permissions{Tools: map[string]config.Role{call.Name: config.RoleOwner}}
+	h := newCallbackHandler(ctx, nil, ctx.GetConfig(), startAgentTrace(context.Background(), "test/model"))
+	h.approveToolCalls([]messages.ChatMessageToolCall{call})
+	h.onToolEnd(call, "Tool call denied by user.", 0, nil)
+
+	rec := httptest.NewRecorder()
+	metrics.Handler(ctx.GetSystem().GetSessionStore()).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+	if strings.Contains(string(body), `tool="test__denied"`) {
+		t.Errorf("expected no tool metrics for a denied call")
+	}
+	if h.toolCount != 0 {
+		t.Errorf("expected no tools counted for the request, got %d", h.toolCount)
+	}
+}
@@ -0,0 +1,99 @@
+package llm
+
+import (
+	"context"
+	"sync"
+
+	"github.com/alexschlessinger/pollytool/messages"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"pkdindustries/soulshack/internal/core"
+)
+
+// agentTrace turns agent callbacks into spans. The agent has no hook per
+// iteration, so an iteration is taken to run from the model call until the
+// last of the tools it asked for has finished.
+type agentTrace struct {
+	mu        sync.Mutex
+	ctx       context.Context
+	run       trace.Span
+	iteration trace.Span
+	count     int
+	pending   int                   // tool calls still running
+	tools     map[string]trace.Span // by tool call ID
+}
+
+// startAgentTrace opens the span for an agent run and its first iteration
+func startAgentTrace(ctx context.Context, model string) *agentTrace {
+	ctx, run := core.Tracer().Start(ctx, "agent.run", trace.WithAttributes(attribute.String("model", model)))
+	t := &agentTrace{ctx: ctx, run: run, tools: make(map[string]trace.Span)}
+	t.nextIteration()
+	return t
+}
+
+// nextIteration ends the current iteration span and starts the next one.
+// Callers hold mu, or own t exclusively.
+func (t *agentTrace) nextIteration() {
+	if t.iteration != nil {
+		t.iteration.End()
+	}
+	t.count++
+	_, t.iteration = core.Tracer().Start(t.ctx, "agent.iteration", trace.WithAttributes(attribute.Int("iteration", t.count)))
+}
+
+// toolsStarted records the tool calls the model asked for
+func (t *agentTrace) toolsStarted(calls []messages.ChatMessageToolCall) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pending += len(calls)
+	t.iteration.SetAttributes(attribute.Int("tool_calls", len(calls)))
+}
+
+// toolStart opens a span for a tool call under the current iteration and
+// returns ctx carrying it, so spans the tool creates nest below
+func (t *agentTrace) toolStart(ctx context.Context, tc messages.ChatMessageToolCall) context.Context {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ctx, span := core.Tracer().Start(trace.ContextWithSpan(ctx, t.iteration), "tool "+tc.Name, trace.WithAttributes(
+		attribute.String("tool.name", tc.Name),
+		attribute.String("tool.call_id", tc.ID),
+	))
+	t.tools[tc.ID+tc.Name] = span
+	return ctx
+}
+
+// toolEnd closes a tool call's span. Denied calls never started one. Once no
+// tool calls are left, the next iteration begins.
+func (t *agentTrace) toolEnd(tc messages.ChatMessageToolCall, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if span, ok := t.tools[tc.ID+tc.Name]; ok {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+		delete(t.tools, tc.ID+tc.Name)
+	}
+	if t.pending--; t.pending == 0 {
+		t.nextIteration()
+	}
+}
+
+// end closes the open spans once the agent has returned
+func (t *agentTrace) end(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, span := range t.tools {
+		span.End()
+	}
+	t.iteration.End()
+	t.run.SetAttributes(attribute.Int("iterations", t.count))
+	if err != nil {
+		t.run.RecordError(err)
+		t.run.SetStatus(codes.Error, err.Error())
+	}
+	t.run.End()
+}
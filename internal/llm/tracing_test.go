@@ -0,0 +1,55 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alexschlessinger/pollytool/messages"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestAgentTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	calls := []messages.ChatMessageToolCall{{ID: "1", Name: "irc__kick"}, {ID: "2", Name: "web__fetch"}}
+	tr := startAgentTrace(context.Background(), "test/model")
+	tr.toolsStarted(calls)
+	tr.toolStart(context.Background(), calls[1])
+	tr.toolEnd(calls[0], nil) // denied, never started
+	tr.toolEnd(calls[1], errors.New("timeout"))
+	tr.end(nil)
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	iterations := 0
+	for _, span := range recorder.Ended() {
+		if span.Name() == "agent.iteration" {
+			iterations++
+		}
+		spans[span.Name()] = span
+	}
+	if iterations != 2 {
+		t.Errorf("expected a second iteration after the tools finished, got %d", iterations)
+	}
+	tool, ok := spans["tool web__fetch"]
+	if !ok {
+		t.Fatalf("expected a span for the executed tool, got %v", spans)
+	}
+	if _, ok := spans["tool irc__kick"]; ok {
+		t.Error("expected no span for the denied tool")
+	}
+	if tool.Status().Code != codes.Error {
+		t.Errorf("expected the failed tool span marked as an error, got %v", tool.Status())
+	}
+	run := spans["agent.run"]
+	if run == nil || tool.Parent().TraceID() != run.SpanContext().TraceID() {
+		t.Error("expected tool spans in the agent run's trace")
+	}
+}
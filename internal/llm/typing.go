@@ -0,0 +1,76 @@
+package llm
+
+import (
+	"sync"
+	"time"
+
+	"pkdindustries/soulshack/internal/core"
+)
+
+// typingInterval is how often an active typing notification is repeated.
+// Clients drop one after 6 seconds and expect at most one every 3.
+const typingInterval = 3 * time.Second
+
+// typingIndicator keeps the channel informed that a completion is running:
+// active while the model works and paused while tools run. No done is sent at
+// the end, since most of the answer may still be queued; its first line
+// clears the indicator.
+type typingIndicator struct {
+	chatCtx core.ChatContextInterface
+	mu      sync.Mutex
+	state   string
+	sent    time.Time
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// startTyping sends active and keeps repeating it until end
+func startTyping(chatCtx core.ChatContextInterface) *typingIndicator {
+	t := &typingIndicator{
+		chatCtx: chatCtx,
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	t.set(core.TypingActive)
+	go t.run()
+	return t
+}
+
+func (t *typingIndicator) run() {
+	defer close(t.stopped)
+	ticker := time.NewTicker(typingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			t.mu.Lock()
+			if t.state == core.TypingActive && time.Since(t.sent) >= typingInterval {
+				t.send(core.TypingActive)
+			}
+			t.mu.Unlock()
+		case <-t.stop:
+			return
+		}
+	}
+}
+
+// set changes the state, notifying the channel if it differs
+func (t *typingIndicator) set(state string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.state != state {
+		t.send(state)
+	}
+}
+
+// send notifies the channel. Callers hold mu.
+func (t *typingIndicator) send(state string) {
+	t.state, t.sent = state, time.Now()
+	t.chatCtx.Typing(state)
+}
+
+// end stops repeating
+func (t *typingIndicator) end() {
+	close(t.stop)
+	<-t.stopped
+}
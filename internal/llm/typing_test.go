@@ -0,0 +1,33 @@
+package llm
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/alexschlessinger/pollytool/messages"
+
+	"pkdindustries/soulshack/internal/config"
+	"pkdindustries/soulshack/internal/irc"
+	mocktest "pkdindustries/soulshack/internal/testing"
+)
+
+func TestCallbackHandler_Typing(t *testing.T) {
+	ctx := mocktest.NewMockContext()
+	output := make(chan string, 10)
+	h := newCallbackHandler(ctx, irc.NewRenderer(irc.NewChunker(output, 400), config.FormatIRC), ctx.GetConfig(), startAgentTrace(context.Background(), "test/model"))
+	h.typing = startTyping(ctx)
+
+	call := messages.ChatMessageToolCall{ID: "1", Name: "web__fetch"}
+	h.onReasoning("hmm")
+	h.onToolStart([]messages.ChatMessageToolCall{call})
+	h.onToolEnd(call, "page", 0, nil)
+	h.onContent("here it is")
+	h.onContent(" and more")
+	h.typing.end()
+
+	want := []string{"active", "paused", "active"}
+	if !slices.Equal(ctx.TypingCalls, want) {
+		t.Errorf("expected typing %v, got %v", want, ctx.TypingCalls)
+	}
+}
@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/alexschlessinger/pollytool/sessions"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "soulshack"
+
+// Metrics are always collected; they are only exposed when a listen address
+// is configured
+var (
+	BehaviorRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "behavior_requests_total",
+		Help:      "IRC events handled, by behavior.",
+	}, []string{"behavior"})
+
+	LLMRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "llm_requests_total",
+		Help:      "LLM requests, by model and result (ok or error).",
+	}, []string{"model", "result"})
+
+	LLMDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "llm_request_duration_seconds",
+		Help:      "Time to complete an LLM request including tool calls, by model.",
+		Buckets:   []float64{0.5, 1, 2.5, 5, 10, 20, 40, 80, 160},
+	}, []string{"model"})
+
+	LLMTokens = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "llm_tokens_total",
+		Help:      "LLM tokens used, by model and direction (input or output).",
+	}, []string{"model", "direction"})
+
+	ToolCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tool_calls_total",
+		Help:      "Tool invocations, by tool.",
+	}, []string{"tool"})
+
+	ToolFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tool_failures_total",
+		Help:      "Tool invocations that returned an error, by tool.",
+	}, []string{"tool"})
+
+	ToolDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "tool_duration_seconds",
+		Help:      "Tool execution time, by tool.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"tool"})
+
+	LockWait = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "lock_wait_seconds",
+		Help:      "Time spent waiting for the per-channel request lock, by operation.",
+		Buckets:   []float64{0.001, 0.01, 0.1, 0.5, 1, 5, 15, 30, 60},
+	}, []string{"operation"})
+
+	LockTimeouts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "lock_timeouts_total",
+		Help:      "Requests dropped because the request lock was not acquired in time, by operation.",
+	}, []string{"operation"})
+
+	Reconnects = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "irc_reconnects_total",
+		Help:      "Attempts to reconnect to the IRC server.",
+	})
+)
+
+// Handler returns the /metrics handler, reporting the active session count
+// from store alongside the other metrics
+func Handler(store sessions.SessionStore) http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		BehaviorRequests, LLMRequests, LLMDuration, LLMTokens,
+		ToolCalls, ToolFailures, ToolDuration,
+		LockWait, LockTimeouts, Reconnects,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "active_sessions",
+			Help:      "Conversation sessions currently held by the session store.",
+		}, func() float64 {
+			count := 0
+			store.Range(func(key, value any) bool {
+				count++
+				return true
+			})
+			return float64(count)
+		}),
+	)
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// Serve exposes /metrics on addr until ctx is done
+func Serve(ctx context.Context, addr string, store sessions.SessionStore) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler(store))
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	slog.Info("metrics_listening", "addr", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("metrics_failed", "addr", addr, "error", err)
+	}
+}
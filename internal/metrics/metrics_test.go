@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alexschlessinger/pollytool/sessions"
+)
+
+func TestHandler(t *testing.T) {
+	store := sessions.NewSyncMapSessionStore(&sessions.Metadata{})
+	store.Get("#a")
+	store.Get("#b")
+
+	BehaviorRequests.WithLabelValues("addressed").Inc()
+	LLMTokens.WithLabelValues("test/model", "input").Add(120)
+	ToolFailures.WithLabelValues("irc__kick").Inc()
+	LockWait.WithLabelValues("addressed").Observe(0.2)
+
+	rec := httptest.NewRecorder()
+	Handler(store).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+
+	for _, want := range []string{
+		`soulshack_behavior_requests_total{behavior="addressed"} 1`,
+		`soulshack_llm_tokens_total{direction="input",model="test/model"} 120`,
+		`soulshack_tool_failures_total{tool="irc__kick"} 1`,
+		`soulshack_lock_wait_seconds_count{operation="addressed"} 1`,
+		`soulshack_active_sessions 2`,
+		`soulshack_irc_reconnects_total 0`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("expected metrics to contain %q", want)
+		}
+	}
+}
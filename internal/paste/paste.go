@@ -0,0 +1,141 @@
+package paste
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Paster stores text somewhere it can be linked to
+type Paster interface {
+	Paste(ctx context.Context, text string) (string, error)
+}
+
+// keep is how many pastes the built-in server holds; the oldest are dropped
+// beyond it
+const keep = 500
+
+// Server is the built-in paste service. Pastes are held in memory and served
+// as plain text at /<id>.
+type Server struct {
+	baseURL string
+	mu      sync.Mutex
+	pastes  map[string]string
+	order   []string // ids, oldest first
+}
+
+// NewServer creates a paste server whose pastes are linked under baseURL
+func NewServer(baseURL string) *Server {
+	return &Server{baseURL: strings.TrimSuffix(baseURL, "/"), pastes: make(map[string]string)}
+}
+
+// Paste stores text and returns its URL
+func (s *Server) Paste(ctx context.Context, text string) (string, error) {
+	b := make([]byte, 8)
+	rand.Read(b)
+	id := hex.EncodeToString(b)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pastes[id] = text
+	s.order = append(s.order, id)
+	if len(s.order) > keep {
+		delete(s.pastes, s.order[0])
+		s.order = s.order[1:]
+	}
+	return s.baseURL + "/" + id, nil
+}
+
+// Handler serves pastes by id
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{id}", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		text, ok := s.pastes[r.PathValue("id")]
+		s.mu.Unlock()
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		io.WriteString(w, text)
+	})
+	return mux
+}
+
+// Serve runs the paste server on addr until ctx is done
+func (s *Server) Serve(ctx context.Context, addr string) {
+	server := &http.Server{Addr: addr, Handler: s.Handler(), ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	slog.Info("paste_listening", "addr", addr, "url", s.baseURL)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("paste_failed", "addr", addr, "error", err)
+	}
+}
+
+// Endpoint posts pastes to a pastebin-compatible service, one that takes a
+// multipart form upload and answers with the paste's URL, such as 0x0.st
+// (field "file") or sprunge.us (field "sprunge")
+type Endpoint struct {
+	url    string
+	field  string
+	client *http.Client
+}
+
+// NewEndpoint creates a paster posting to url in the named form field
+func NewEndpoint(url, field string) *Endpoint {
+	return &Endpoint{url: url, field: field, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Paste uploads text and returns the URL the service answers with
+func (e *Endpoint) Paste(ctx context.Context, text string) (string, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile(e.field, "response.txt")
+	if err != nil {
+		return "", err
+	}
+	io.WriteString(part, text)
+	form.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("paste failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	answer, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return "", fmt.Errorf("paste failed: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("paste failed: %s", resp.Status)
+	}
+	link, _, _ := strings.Cut(strings.TrimSpace(string(answer)), "\n")
+	if u, err := url.Parse(link); err != nil || u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("paste service answered without a URL: %q", link)
+	}
+	return link, nil
+}
@@ -0,0 +1,102 @@
+package paste
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServer_PasteAndServe(t *testing.T) {
+	s := NewServer("https://paste.example.com/")
+	link, err := s.Paste(context.Background(), "line one\nline two")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, ok := strings.CutPrefix(link, "https://paste.example.com/")
+	if !ok || id == "" {
+		t.Fatalf("unexpected link %q", link)
+	}
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/"+id, nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "line one\nline two" {
+		t.Errorf("got %d %q", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("expected plain text, got %q", ct)
+	}
+
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown paste, got %d", rec.Code)
+	}
+}
+
+func TestServer_DropsOldest(t *testing.T) {
+	s := NewServer("http://localhost")
+	first, _ := s.Paste(context.Background(), "first")
+	for i := range keep {
+		s.Paste(context.Background(), fmt.Sprint(i))
+	}
+	if _, ok := s.pastes[strings.TrimPrefix(first, "http://localhost/")]; ok {
+		t.Error("expected the oldest paste to be dropped")
+	}
+	if len(s.pastes) != keep || len(s.order) != keep {
+		t.Errorf("expected %d pastes kept, got %d", keep, len(s.pastes))
+	}
+}
+
+func TestEndpoint_Paste(t *testing.T) {
+	var got string
+	service := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		b, _ := io.ReadAll(f)
+		got = string(b)
+		io.WriteString(w, "https://0x0.example/abc.txt\n")
+	}))
+	defer service.Close()
+
+	link, err := NewEndpoint(service.URL, "file").Paste(context.Background(), "the response")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link != "https://0x0.example/abc.txt" {
+		t.Errorf("unexpected link %q", link)
+	}
+	if got != "the response" {
+		t.Errorf("service received %q", got)
+	}
+}
+
+func TestEndpoint_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		answer string
+	}{
+		{"status", http.StatusRequestEntityTooLarge, "too large"},
+		{"no url", http.StatusOK, "<html>upload form</html>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.answer)
+			}))
+			defer service.Close()
+
+			if _, err := NewEndpoint(service.URL, "file").Paste(context.Background(), "text"); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package store
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// auditKeep is how many recent entries the audit log holds in memory
+const auditKeep = 1000
+
+// AuditEntry records a privileged action: an admin command, or a call to a
+// tool that needs more than the everyone role
+type AuditEntry struct {
+	Time     time.Time `json:"time"`
+	Kind     string    `json:"kind"`   // command, tool or api
+	Action   string    `json:"action"` // command or tool name
+	Nick     string    `json:"nick"`
+	Hostmask string    `json:"hostmask,omitempty"`
+	Account  string    `json:"account,omitempty"`
+	Channel  string    `json:"channel,omitempty"`
+	Args     string    `json:"args,omitempty"`   // command arguments, or the tool arguments the model sent
+	Outcome  string    `json:"outcome"`          // ok, denied or error
+	Detail   string    `json:"detail,omitempty"` // replies, tool result or error
+}
+
+// AuditLog is an append-only record of privileged actions. Entries are
+// appended to a JSONL file when one is configured; the most recent are kept
+// in memory for /audit.
+type AuditLog struct {
+	mu      sync.Mutex
+	entries []AuditEntry
+	file    *jsonlFile
+	now     func() time.Time
+}
+
+// NewAuditLog opens the audit log at path, loading its most recent entries.
+// An empty path keeps entries in memory only.
+func NewAuditLog(path string) (*AuditLog, error) {
+	l := &AuditLog{now: time.Now}
+	file, err := openJSONL(path, "audit log", l.keep)
+	if err != nil {
+		return nil, err
+	}
+	l.file = file
+	return l, nil
+}
+
+// keep adds an entry to the in-memory tail
+func (l *AuditLog) keep(e AuditEntry) {
+	if len(l.entries) >= auditKeep {
+		l.entries = slices.Delete(l.entries, 0, len(l.entries)-auditKeep+1)
+	}
+	l.entries = append(l.entries, e)
+}
+
+// Record appends an entry to the log
+func (l *AuditLog) Record(e AuditEntry) error {
+	if e.Time.IsZero() {
+		e.Time = l.now()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.keep(e)
+	return l.file.append(e)
+}
+
+// Recent returns up to n of the latest entries that match, newest first. A
+// nil match returns all entries.
+func (l *AuditLog) Recent(n int, match func(AuditEntry) bool) []AuditEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var found []AuditEntry
+	for i := len(l.entries) - 1; i >= 0 && len(found) < n; i-- {
+		if match == nil || match(l.entries[i]) {
+			found = append(found, l.entries[i])
+		}
+	}
+	return found
+}
+
+// Close closes the audit log file
+func (l *AuditLog) Close() error {
+	return l.file.Close()
+}
@@ -0,0 +1,43 @@
+package store
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestAuditLog_SurvivesRestart(t *testing.T) {
+	reloaded, path := reopen(t, "audit.jsonl", NewAuditLog, func(audit *AuditLog) {
+		audit.Record(AuditEntry{Kind: "command", Action: "/set", Nick: "alice", Args: "model a/big", Outcome: "ok"})
+		audit.Record(AuditEntry{Kind: "tool", Action: "irc__kick", Nick: "bob", Channel: "#dev", Args: `{"nick":"eve"}`, Outcome: "denied"})
+	})
+	reloaded.Record(AuditEntry{Kind: "command", Action: "/admins", Nick: "alice", Outcome: "ok"})
+
+	recent := reloaded.Recent(10, nil)
+	if len(recent) != 3 || recent[0].Action != "/admins" || recent[2].Action != "/set" {
+		t.Fatalf("expected entries newest first across restart, got %+v", recent)
+	}
+	if recent[1].Time.IsZero() {
+		t.Error("expected entries timestamped")
+	}
+
+	byAlice := reloaded.Recent(1, func(e AuditEntry) bool { return e.Nick == "alice" })
+	if len(byAlice) != 1 || byAlice[0].Action != "/admins" {
+		t.Errorf("expected only alice's latest entry, got %+v", byAlice)
+	}
+
+	data, _ := os.ReadFile(path)
+	if lines := strings.Count(string(data), "\n"); lines != 3 {
+		t.Errorf("expected 3 lines appended to the file, got %d", lines)
+	}
+}
+
+func TestAuditLog_KeepsRecent(t *testing.T) {
+	audit, _ := NewAuditLog("")
+	for range auditKeep + 10 {
+		audit.Record(AuditEntry{Action: "/set"})
+	}
+	if n := len(audit.Recent(auditKeep*2, nil)); n != auditKeep {
+		t.Errorf("expected %d entries kept in memory, got %d", auditKeep, n)
+	}
+}
@@ -0,0 +1,269 @@
+package store
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// historyDays and historyKeep bound a channel's index: it is built from the
+// transcripts of the last historyDays days and holds at most historyKeep
+// lines, dropping the oldest beyond that
+const (
+	historyDays = 90
+	historyKeep = 100000
+)
+
+// HistoryQuery selects transcript lines. Every field is optional; Text
+// matches lines holding all of its words, each of which also matches longer
+// words it starts, so "http" finds links.
+type HistoryQuery struct {
+	Text  string
+	Nick  string
+	Since time.Time // inclusive
+	Until time.Time // exclusive
+	Limit int
+}
+
+// historyIndex is the full-text index of a channel's messages and actions.
+// It has its own lock so building and searching it never holds up Write.
+type historyIndex struct {
+	ready chan struct{} // closed once loaded from the transcript files
+	err   error         // why loading failed, set before ready is closed
+
+	mu       sync.Mutex
+	lines    []TranscriptLine // oldest first
+	postings map[string][]int // word -> indexes into lines, ascending
+}
+
+func newHistoryIndex() *historyIndex {
+	return &historyIndex{ready: make(chan struct{}), postings: make(map[string][]int)}
+}
+
+// transcriptFile is a transcript to load, up to the size it had when the
+// index was registered; later lines reach the index through Write
+type transcriptFile struct {
+	path string
+	size int64
+}
+
+// add indexes a line written to the channel, dropping the oldest lines once
+// the index is well over historyKeep
+func (h *historyIndex) add(line TranscriptLine) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.insert(line)
+	if len(h.lines) > historyKeep+historyKeep/4 {
+		h.rebuild(h.lines[len(h.lines)-historyKeep:])
+	}
+}
+
+// insert indexes a line. Callers hold mu or own the index.
+func (h *historyIndex) insert(line TranscriptLine) {
+	if line.Kind != TranscriptMessage && line.Kind != TranscriptAction {
+		return
+	}
+	i := len(h.lines)
+	h.lines = append(h.lines, line)
+	for _, term := range slices.Compact(slices.Sorted(slices.Values(searchTerms(line.Text)))) {
+		h.postings[term] = append(h.postings[term], i)
+	}
+}
+
+// rebuild replaces the index with lines. Callers hold mu.
+func (h *historyIndex) rebuild(lines []TranscriptLine) {
+	h.lines, h.postings = nil, make(map[string][]int)
+	for _, line := range lines {
+		h.insert(line)
+	}
+}
+
+// load reads the transcript files into the index, after any lines Write
+// added while they were read, then marks the index ready
+func (h *historyIndex) load(files []transcriptFile) {
+	defer close(h.ready)
+
+	loaded := newHistoryIndex()
+	for _, file := range files {
+		if h.err = loaded.read(file); h.err != nil {
+			return
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.rebuild(append(loaded.lines, h.lines...)[max(0, len(loaded.lines)+len(h.lines)-historyKeep):])
+}
+
+// read adds the lines of one transcript file to the index
+func (h *historyIndex) read(file transcriptFile) error {
+	f, err := os.Open(file.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return scanJSONL(io.LimitReader(f, file.size), file.path, "transcript", h.insert)
+}
+
+// Search returns a channel's messages and actions matching the query, newest
+// first. The channel's index is built from its transcripts on first use and
+// kept up to date by Write.
+func (t *Transcript) Search(channel string, q HistoryQuery) ([]TranscriptLine, error) {
+	index, err := t.history(channel)
+	if err != nil {
+		return nil, err
+	}
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	// Candidates hold a word starting with each word of the text, or are
+	// all lines without one
+	var candidates []int
+	if terms := searchTerms(q.Text); len(terms) > 0 {
+		candidates = index.matching(terms[0])
+		for _, term := range terms[1:] {
+			candidates = intersect(candidates, index.matching(term))
+		}
+	} else {
+		candidates = make([]int, len(index.lines))
+		for i := range candidates {
+			candidates[i] = i
+		}
+	}
+
+	var found []TranscriptLine
+	for i := len(candidates) - 1; i >= 0 && (q.Limit <= 0 || len(found) < q.Limit); i-- {
+		line := index.lines[candidates[i]]
+		if q.Nick != "" && !strings.EqualFold(line.Nick, q.Nick) {
+			continue
+		}
+		if !q.Since.IsZero() && line.Time.Before(q.Since) {
+			continue
+		}
+		if !q.Until.IsZero() && !line.Time.Before(q.Until) {
+			continue
+		}
+		found = append(found, line)
+	}
+	return found, nil
+}
+
+// matching returns the lines holding a word that starts with term, ascending.
+// Callers hold mu.
+func (h *historyIndex) matching(term string) []int {
+	var found []int
+	for word, lines := range h.postings {
+		if strings.HasPrefix(word, term) {
+			found = union(found, lines)
+		}
+	}
+	return found
+}
+
+// history returns a channel's index, building it from the transcript files
+// when it is not loaded yet. The index is registered and the files sized
+// under mu, so each line is either read from a file or added by Write, and
+// then read without holding mu.
+func (t *Transcript) history(channel string) (*historyIndex, error) {
+	name := transcriptName(channel)
+	t.mu.Lock()
+	index, loading := t.index[name]
+	var files []transcriptFile
+	if !loading {
+		var err error
+		if files, err = t.historyFiles(name); err != nil {
+			t.mu.Unlock()
+			return nil, err
+		}
+		index = newHistoryIndex()
+		t.index[name] = index
+	}
+	t.mu.Unlock()
+
+	if loading {
+		<-index.ready
+		return index, index.err
+	}
+
+	index.load(files)
+	if index.err != nil {
+		// Let the next search try again
+		t.mu.Lock()
+		delete(t.index, name)
+		t.mu.Unlock()
+		return nil, index.err
+	}
+	index.mu.Lock()
+	slog.Debug("history_indexed", "channel", channel, "files", len(files), "lines", len(index.lines), "terms", len(index.postings))
+	index.mu.Unlock()
+	return index, nil
+}
+
+// historyFiles lists a channel's transcripts from the last historyDays days
+// with their current sizes, oldest first. Callers hold mu.
+func (t *Transcript) historyFiles(name string) ([]transcriptFile, error) {
+	paths, err := filepath.Glob(filepath.Join(t.dir, name, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(paths) // named by date
+	oldest := time.Now().AddDate(0, 0, -historyDays).Format(time.DateOnly)
+
+	var files []transcriptFile
+	for _, path := range paths {
+		if strings.TrimSuffix(filepath.Base(path), ".jsonl") < oldest {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, transcriptFile{path: path, size: info.Size()})
+	}
+	return files, nil
+}
+
+// intersect returns the values in both ascending lists
+func intersect(a, b []int) []int {
+	var both []int
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			both = append(both, a[i])
+			i++
+			j++
+		}
+	}
+	return both
+}
+
+// union returns the values in either ascending list
+func union(a, b []int) []int {
+	either := make([]int, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] < b[j]:
+			either = append(either, a[i])
+			i++
+		case a[i] > b[j]:
+			either = append(either, b[j])
+			j++
+		default:
+			either = append(either, a[i])
+			i++
+			j++
+		}
+	}
+	either = append(either, a[i:]...)
+	return append(either, b[j:]...)
+}
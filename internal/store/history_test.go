@@ -0,0 +1,102 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTranscript_Search(t *testing.T) {
+	dir := t.TempDir()
+	transcript, _ := NewTranscript(dir)
+	y, m, d := time.Now().AddDate(0, 0, -3).Date()
+	day := time.Date(y, m, d, 10, 0, 0, 0, time.Local)
+	for _, line := range []TranscriptLine{
+		{Time: day, Kind: TranscriptMessage, Nick: "bob", Text: "new docs at https://docs.example.org/v2"},
+		{Time: day.Add(time.Hour), Kind: TranscriptJoin, Nick: "carol", Host: "c@example.org"},
+		{Time: day.Add(2 * time.Hour), Kind: TranscriptAction, Nick: "alice", Text: "reads the docs"},
+		{Time: day.AddDate(0, 0, 1), Kind: TranscriptMessage, Nick: "Bob", Text: "mirror: https://mirror.example.org"},
+	} {
+		line.Channel = "#dev"
+		transcript.Write(line)
+	}
+	transcript.Close()
+
+	// A fresh transcript builds its index from the files
+	reopened, _ := NewTranscript(dir)
+	defer reopened.Close()
+
+	tests := []struct {
+		name     string
+		query    HistoryQuery
+		expected []string
+	}{
+		{"all words", HistoryQuery{Text: "Docs example"}, []string{"bob"}},
+		{"by word", HistoryQuery{Text: "https"}, []string{"Bob", "bob"}},
+		{"by prefix", HistoryQuery{Text: "http"}, []string{"Bob", "bob"}},
+		{"prefixes of all words", HistoryQuery{Text: "mirr exam"}, []string{"Bob"}},
+		{"by nick", HistoryQuery{Nick: "BOB"}, []string{"Bob", "bob"}},
+		{"since", HistoryQuery{Text: "docs", Since: day.Add(time.Hour)}, []string{"alice"}},
+		{"until", HistoryQuery{Text: "https", Until: day.AddDate(0, 0, 1)}, []string{"bob"}},
+		{"limit", HistoryQuery{Limit: 1}, []string{"Bob"}},
+		{"no match", HistoryQuery{Text: "kubernetes"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := reopened.Search("#Dev", tt.query)
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			if len(found) != len(tt.expected) {
+				t.Fatalf("expected %d lines, got %+v", len(tt.expected), found)
+			}
+			for i, nick := range tt.expected {
+				if found[i].Nick != nick {
+					t.Errorf("line %d: expected %s, got %+v", i, nick, found[i])
+				}
+			}
+		})
+	}
+
+	// Lines written after the index is built are searchable
+	reopened.Write(TranscriptLine{Channel: "#dev", Kind: TranscriptMessage, Nick: "dave", Text: "docs are stale"})
+	found, _ := reopened.Search("#dev", HistoryQuery{Text: "docs"})
+	if len(found) != 3 || found[0].Nick != "dave" {
+		t.Errorf("expected the new line first, got %+v", found)
+	}
+}
+
+func TestTranscript_SearchSkipsOldTranscripts(t *testing.T) {
+	dir := t.TempDir()
+	transcript, _ := NewTranscript(dir)
+	defer transcript.Close()
+	old := time.Now().AddDate(0, 0, -historyDays-1)
+	transcript.Write(TranscriptLine{Time: old, Channel: "#dev", Kind: TranscriptMessage, Nick: "bob", Text: "ancient docs"})
+	transcript.Write(TranscriptLine{Channel: "#dev", Kind: TranscriptMessage, Nick: "alice", Text: "fresh docs"})
+
+	found, err := transcript.Search("#dev", HistoryQuery{Text: "docs"})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(found) != 1 || found[0].Nick != "alice" {
+		t.Errorf("expected only the recent transcript indexed, got %+v", found)
+	}
+}
+
+func TestHistoryIndex_WritesDuringLoad(t *testing.T) {
+	index := newHistoryIndex()
+	index.add(TranscriptLine{Kind: TranscriptMessage, Nick: "carol", Text: "written while loading"})
+
+	path := filepath.Join(t.TempDir(), "2026-03-14.jsonl")
+	os.WriteFile(path, []byte(`{"kind":"message","nick":"bob","text":"from the file"}`+"\n"+`{"kind":"message","nick":"dave","text":"after sizing"}`+"\n"), 0o600)
+	info, _ := os.Stat(path)
+	index.load([]transcriptFile{{path: path, size: info.Size() / 2}})
+
+	if len(index.lines) != 2 || index.lines[0].Nick != "bob" || index.lines[1].Nick != "carol" {
+		t.Errorf("expected the file's lines up to its size, then those written meanwhile, got %+v", index.lines)
+	}
+	if got := index.matching("load"); len(got) != 1 || got[0] != 1 {
+		t.Errorf("expected the written line reindexed after the file's, got %v", got)
+	}
+}
@@ -0,0 +1,85 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+)
+
+// jsonlFile is an append-only file of JSON lines, read back in full when it
+// is opened. Without a path nothing is written.
+type jsonlFile struct {
+	file *os.File
+}
+
+// openJSONL passes each line of the file at path to load, then opens the
+// file for appending. The name describes the file in errors.
+func openJSONL[T any](path, name string, load func(T)) (*jsonlFile, error) {
+	if path == "" {
+		return &jsonlFile{}, nil
+	}
+	if err := readJSONL(path, name, load); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	return &jsonlFile{file: f}, nil
+}
+
+// readJSONL passes each line of the file at path to load, skipping lines
+// that don't parse. A missing file holds no lines.
+func readJSONL[T any](path, name string, load func(T)) error {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	defer f.Close()
+	return scanJSONL(f, path, name, load)
+}
+
+// scanJSONL passes each line read from r to load, skipping lines that don't
+// parse
+func scanJSONL[T any](r io.Reader, path, name string, load func(T)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var v T
+		if err := json.Unmarshal(scanner.Bytes(), &v); err != nil {
+			slog.Warn("jsonl_line_invalid", "file", name, "path", path, "error", err)
+			continue
+		}
+		load(v)
+	}
+	return scanner.Err()
+}
+
+// append writes v as a line. Callers serialize appends.
+func (j *jsonlFile) append(v any) error {
+	if j.file == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = j.file.Write(append(data, '\n'))
+	return err
+}
+
+// Close closes the file
+func (j *jsonlFile) Close() error {
+	if j.file == nil {
+		return nil
+	}
+	return j.file.Close()
+}
@@ -0,0 +1,64 @@
+package store
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// reopen opens a store on a new file, lets fill add to it, then closes it
+// and opens the file again as a restart would. It returns the reopened store
+// and the file's path.
+func reopen[T any](t *testing.T, name string, open func(string) (T, error), fill func(T)) (T, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	s, err := open(path)
+	if err != nil {
+		t.Fatalf("open %s: %v", name, err)
+	}
+	fill(s)
+	if c, ok := any(s).(io.Closer); ok {
+		c.Close()
+	}
+
+	reloaded, err := open(path)
+	if err != nil {
+		t.Fatalf("reopen %s: %v", name, err)
+	}
+	if c, ok := any(reloaded).(io.Closer); ok {
+		t.Cleanup(func() { c.Close() })
+	}
+	return reloaded, path
+}
+
+func TestOpenJSONL(t *testing.T) {
+	type line struct{ N int }
+	path := filepath.Join(t.TempDir(), "lines.jsonl")
+	os.WriteFile(path, []byte("{\"N\":1}\nnot json\n{\"N\":2}\n"), 0o600)
+
+	var loaded []int
+	file, err := openJSONL(path, "lines", func(l line) { loaded = append(loaded, l.N) })
+	if err != nil {
+		t.Fatalf("openJSONL: %v", err)
+	}
+	if !slices.Equal(loaded, []int{1, 2}) {
+		t.Errorf("expected the invalid line skipped, got %v", loaded)
+	}
+	file.append(line{3})
+	file.Close()
+
+	loaded = nil
+	if err := readJSONL(path, "lines", func(l line) { loaded = append(loaded, l.N) }); err != nil {
+		t.Fatalf("readJSONL: %v", err)
+	}
+	if !slices.Equal(loaded, []int{1, 2, 3}) {
+		t.Errorf("expected the line appended, got %v", loaded)
+	}
+
+	memory, err := openJSONL("", "lines", func(line) {})
+	if err != nil || memory.append(line{4}) != nil || memory.Close() != nil {
+		t.Errorf("expected a file without a path to keep nothing, got %v", err)
+	}
+}
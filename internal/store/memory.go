@@ -0,0 +1,252 @@
+package store
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Scopes of a memory
+const (
+	MemoryChannel = "channel" // shared by everyone in a channel
+	MemoryUser    = "user"    // about one user, wherever they talk to the bot
+)
+
+// memoryKeep is how many memories one channel or user can hold; the oldest
+// are dropped beyond it
+const memoryKeep = 200
+
+// BM25 parameters
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Memory is a fact the model chose to remember
+type Memory struct {
+	ID      int       `json:"id"`
+	Scope   string    `json:"scope"`             // channel or user
+	Owner   string    `json:"owner"`             // the channel, or the user's $a:account or nick, lowercased
+	Channel string    `json:"channel,omitempty"` // where it was remembered, empty in private
+	Nick    string    `json:"nick"`              // who it was remembered for
+	Text    string    `json:"text"`
+	Time    time.Time `json:"time"`
+}
+
+// MemoryStore holds long-term memories, saved to a JSON file when one is
+// configured
+type MemoryStore struct {
+	mu       sync.Mutex
+	path     string
+	memories []Memory // oldest first
+	nextID   int
+	now      func() time.Time
+}
+
+// NewMemoryStore opens the memory file at path, loading what it holds. An
+// empty path keeps memories in memory only.
+func NewMemoryStore(path string) (*MemoryStore, error) {
+	s := &MemoryStore{path: path, nextID: 1, now: time.Now}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read memory file: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.memories); err != nil {
+		return nil, fmt.Errorf("failed to parse memory file: %w", err)
+	}
+	for _, m := range s.memories {
+		s.nextID = max(s.nextID, m.ID+1)
+	}
+	return s, nil
+}
+
+// Add stores a memory, assigning its ID and time, and returns it. The store
+// is left as it was if the memory cannot be saved.
+func (s *MemoryStore) Add(m Memory) (Memory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m.ID = s.nextID
+	if m.Time.IsZero() {
+		m.Time = s.now()
+	}
+	memories := append(slices.Clip(s.memories), m)
+
+	// Drop the owner's oldest memories beyond the limit
+	held := 0
+	for i := len(memories) - 1; i >= 0; i-- {
+		if memories[i].Scope != m.Scope || memories[i].Owner != m.Owner {
+			continue
+		}
+		held++
+		if held > memoryKeep {
+			memories = slices.Delete(memories, i, i+1)
+		}
+	}
+	if err := s.save(memories); err != nil {
+		return Memory{}, err
+	}
+	s.memories = memories
+	s.nextID++
+	return m, nil
+}
+
+// Recent returns up to n of the latest memories that match, newest first. A
+// nil match returns all memories.
+func (s *MemoryStore) Recent(n int, match func(Memory) bool) []Memory {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var found []Memory
+	for i := len(s.memories) - 1; i >= 0 && len(found) < n; i-- {
+		if match == nil || match(s.memories[i]) {
+			found = append(found, s.memories[i])
+		}
+	}
+	return found
+}
+
+// Search ranks the memories that match against query with BM25 and returns
+// up to n of the best, leaving out those sharing no term with it
+func (s *MemoryStore) Search(query string, n int, match func(Memory) bool) []Memory {
+	terms := slices.Compact(slices.Sorted(slices.Values(searchTerms(query))))
+	if len(terms) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	var docs []Memory
+	for _, m := range s.memories {
+		if match == nil || match(m) {
+			docs = append(docs, m)
+		}
+	}
+	s.mu.Unlock()
+
+	// Term frequencies per document, and how many documents hold each term
+	freqs := make([]map[string]int, len(docs))
+	lengths := make([]int, len(docs))
+	df := make(map[string]int)
+	total := 0
+	for i, m := range docs {
+		freqs[i] = make(map[string]int)
+		words := searchTerms(m.Text)
+		for _, w := range words {
+			if freqs[i][w] == 0 {
+				df[w]++
+			}
+			freqs[i][w]++
+		}
+		lengths[i] = len(words)
+		total += len(words)
+	}
+	if total == 0 {
+		return nil
+	}
+	avg := float64(total) / float64(len(docs))
+
+	type scored struct {
+		memory Memory
+		score  float64
+	}
+	var hits []scored
+	for i, m := range docs {
+		score := 0.0
+		for _, t := range terms {
+			f := float64(freqs[i][t])
+			if f == 0 {
+				continue
+			}
+			idf := math.Log(1 + (float64(len(docs)-df[t])+0.5)/(float64(df[t])+0.5))
+			score += idf * f * (bm25K1 + 1) / (f + bm25K1*(1-bm25B+bm25B*float64(lengths[i])/avg))
+		}
+		if score > 0 {
+			hits = append(hits, scored{m, score})
+		}
+	}
+
+	// Best first, newer first on ties
+	slices.SortStableFunc(hits, func(a, b scored) int {
+		return cmp.Or(cmp.Compare(b.score, a.score), cmp.Compare(b.memory.ID, a.memory.ID))
+	})
+	found := make([]Memory, 0, min(n, len(hits)))
+	for _, h := range hits[:min(n, len(hits))] {
+		found = append(found, h.memory)
+	}
+	return found
+}
+
+// searchTerms splits text into lowercased words for search
+func searchTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// Forget removes the memory with id if it matches, reporting whether it did.
+// Like Purge, it changes nothing if the change cannot be saved.
+func (s *MemoryStore) Forget(id int, match func(Memory) bool) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.memories, func(m Memory) bool {
+		return m.ID == id && (match == nil || match(m))
+	})
+	if i < 0 {
+		return false, nil
+	}
+	memories := slices.Delete(slices.Clone(s.memories), i, i+1)
+	if err := s.save(memories); err != nil {
+		return false, err
+	}
+	s.memories = memories
+	return true, nil
+}
+
+// Purge removes every memory that matches and returns how many it removed
+func (s *MemoryStore) Purge(match func(Memory) bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	memories := slices.DeleteFunc(slices.Clone(s.memories), match)
+	removed := len(s.memories) - len(memories)
+	if removed == 0 {
+		return 0, nil
+	}
+	if err := s.save(memories); err != nil {
+		return 0, err
+	}
+	s.memories = memories
+	return removed, nil
+}
+
+// save writes memories to the file, which callers then keep. Callers hold
+// mu.
+func (s *MemoryStore) save(memories []Memory) error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(memories, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(s.path, data); err != nil {
+		return fmt.Errorf("failed to save memories: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package store
+
+import "testing"
+
+func TestMemoryStore_SurvivesRestart(t *testing.T) {
+	reloaded, _ := reopen(t, "memory.json", NewMemoryStore, func(memories *MemoryStore) {
+		memories.Add(Memory{Scope: MemoryUser, Owner: "alice", Nick: "alice", Text: "prefers tabs"})
+		memories.Add(Memory{Scope: MemoryChannel, Owner: "#dev", Channel: "#dev", Nick: "bob", Text: "deploys on fridays"})
+		memories.Forget(1, nil)
+	})
+	added, _ := reloaded.Add(Memory{Scope: MemoryUser, Owner: "bob", Nick: "bob", Text: "likes go"})
+	if added.ID != 3 {
+		t.Errorf("expected IDs to continue after restart, got %d", added.ID)
+	}
+
+	recent := reloaded.Recent(10, nil)
+	if len(recent) != 2 || recent[0].Text != "likes go" || recent[1].Text != "deploys on fridays" {
+		t.Fatalf("expected the forgotten memory gone across restart, got %+v", recent)
+	}
+	if recent[1].Time.IsZero() {
+		t.Error("expected memories timestamped")
+	}
+}
+
+func TestMemoryStore_Search(t *testing.T) {
+	memories, _ := NewMemoryStore("")
+	for _, text := range []string{
+		"alice works on the parser",
+		"the build server is ci.example.org",
+		"alice's favourite editor is vim, alice uses vim daily",
+		"bob is on holiday until June",
+	} {
+		memories.Add(Memory{Scope: MemoryChannel, Owner: "#dev", Text: text})
+	}
+	memories.Add(Memory{Scope: MemoryChannel, Owner: "#ops", Text: "vim is banned on the servers"})
+	inDev := func(m Memory) bool { return m.Owner == "#dev" }
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []int
+	}{
+		{"ranked by term frequency", "alice vim", []int{3, 1}},
+		{"case and punctuation", "BUILD-server?", []int{2}},
+		{"no shared terms", "kubernetes", nil},
+		{"empty", "  ", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found := memories.Search(tt.query, 10, inDev)
+			if len(found) != len(tt.expected) {
+				t.Fatalf("expected %d results, got %+v", len(tt.expected), found)
+			}
+			for i, id := range tt.expected {
+				if found[i].ID != id {
+					t.Errorf("result %d: expected #%d, got #%d", i, id, found[i].ID)
+				}
+			}
+		})
+	}
+
+	if found := memories.Search("vim", 1, nil); len(found) != 1 {
+		t.Errorf("expected the limit applied, got %+v", found)
+	}
+}
+
+func TestMemoryStore_KeepAndPurge(t *testing.T) {
+	memories, _ := NewMemoryStore("")
+	for range memoryKeep + 5 {
+		memories.Add(Memory{Scope: MemoryUser, Owner: "alice", Text: "fact"})
+	}
+	memories.Add(Memory{Scope: MemoryUser, Owner: "bob", Text: "fact"})
+
+	alice := func(m Memory) bool { return m.Owner == "alice" }
+	if n := len(memories.Recent(memoryKeep*2, alice)); n != memoryKeep {
+		t.Errorf("expected %d memories kept for alice, got %d", memoryKeep, n)
+	}
+	if oldest := memories.Recent(memoryKeep, alice)[memoryKeep-1]; oldest.ID != 6 {
+		t.Errorf("expected the oldest dropped, oldest kept is #%d", oldest.ID)
+	}
+
+	removed, err := memories.Purge(alice)
+	if err != nil || removed != memoryKeep {
+		t.Fatalf("expected %d purged, got %d (%v)", memoryKeep, removed, err)
+	}
+	if left := memories.Recent(10, nil); len(left) != 1 || left[0].Owner != "bob" {
+		t.Errorf("expected only bob's memory left, got %+v", left)
+	}
+}
+
+func TestMemoryStore_FailedSaveChangesNothing(t *testing.T) {
+	memories, err := NewMemoryStore(t.TempDir() + "/missing/memory.json")
+	if err != nil {
+		t.Fatalf("NewMemoryStore: %v", err)
+	}
+	if _, err := memories.Add(Memory{Scope: MemoryUser, Owner: "$a:alice", Text: "prefers tabs"}); err == nil {
+		t.Fatal("expected the save to fail")
+	}
+	if recent := memories.Recent(10, nil); len(recent) != 0 {
+		t.Errorf("expected the memory dropped when it could not be saved, got %+v", recent)
+	}
+	if memories.nextID != 1 {
+		t.Errorf("expected the ID not used, got next %d", memories.nextID)
+	}
+}
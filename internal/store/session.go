@@ -0,0 +1,451 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alexschlessinger/pollytool/messages"
+	"github.com/alexschlessinger/pollytool/sessions"
+)
+
+// sessionFile is the on-disk representation of a session
+type sessionFile struct {
+	Name     string                 `json:"name"`
+	History  []messages.ChatMessage `json:"history"`
+	LastUsed time.Time              `json:"lastUsed"`
+	Metadata *sessions.Metadata     `json:"metadata"`
+}
+
+// FileSessionStore is an in-memory session store that writes every session
+// to its own JSON file, so history survives restarts.
+// Unlike pollytool's file store it holds no file locks and is safe to share
+// between goroutines in a single process.
+type FileSessionStore struct {
+	sync.Map
+	dir      string
+	defaults *sessions.Metadata
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// FileSession is a session that persists itself after every change
+type FileSession struct {
+	history  []messages.ChatMessage
+	last     time.Time
+	name     string
+	mu       sync.RWMutex
+	metadata *sessions.Metadata
+	store    *FileSessionStore
+}
+
+// NewFileSessionStore creates a session store rooted at dir, loading any
+// sessions saved by a previous run. Sessions that expired while the bot was
+// down are removed, and with a TTL the rest expire in the background until
+// Close.
+func NewFileSessionStore(dir string, metadata *sessions.Metadata) (*FileSessionStore, error) {
+	if metadata == nil {
+		metadata = &sessions.Metadata{}
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create session directory: %w", err)
+	}
+
+	store := &FileSessionStore{
+		dir:      dir,
+		defaults: metadata,
+		stop:     make(chan struct{}),
+	}
+	if err := store.load(); err != nil {
+		return nil, err
+	}
+	store.Expire()
+
+	if metadata.TTL > 0 {
+		go func() {
+			ticker := time.NewTicker(metadata.TTL)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					store.Expire()
+				case <-store.stop:
+					return
+				}
+			}
+		}()
+	}
+
+	return store, nil
+}
+
+// Close stops expiring sessions in the background. Sessions are saved as
+// they change, so there is nothing to flush.
+func (s *FileSessionStore) Close() error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	return nil
+}
+
+// load reads every saved session from disk
+func (s *FileSessionStore) load() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read session directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(s.dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			slog.Warn("session_load_failed", "path", path, "error", err)
+			continue
+		}
+		var f sessionFile
+		if err := json.Unmarshal(data, &f); err != nil || f.Name == "" {
+			slog.Warn("session_load_failed", "path", path, "error", err)
+			continue
+		}
+		if f.Metadata == nil {
+			f.Metadata = &sessions.Metadata{Name: f.Name}
+		}
+		restoreTokenUsage(f.History)
+
+		s.Store(f.Name, &FileSession{
+			history:  f.History,
+			last:     f.LastUsed,
+			name:     f.Name,
+			metadata: f.Metadata,
+			store:    s,
+		})
+	}
+	return nil
+}
+
+// restoreTokenUsage converts token counts decoded from JSON as float64 back
+// to ints, which is what messages.ChatMessage expects
+func restoreTokenUsage(history []messages.ChatMessage) {
+	for i := range history {
+		msg := &history[i]
+		in, inOK := msg.Metadata[messages.MetadataKeyInputTokens].(float64)
+		out, outOK := msg.Metadata[messages.MetadataKeyOutputTokens].(float64)
+		if inOK || outOK {
+			msg.SetTokenUsage(int(in), int(out))
+		}
+	}
+}
+
+// path returns the file a session is saved to
+func (s *FileSessionStore) path(id string) string {
+	return filepath.Join(s.dir, url.PathEscape(id)+".json")
+}
+
+// Get retrieves or creates a session
+func (s *FileSessionStore) Get(id string) (sessions.Session, error) {
+	if value, ok := s.Load(id); ok {
+		session := value.(*FileSession)
+		session.mu.Lock()
+		session.last = time.Now()
+		session.mu.Unlock()
+		return session, nil
+	}
+
+	contextInfo := &sessions.Metadata{
+		Name:             id,
+		Created:          time.Now(),
+		LastUsed:         time.Now(),
+		SystemPrompt:     s.defaults.SystemPrompt,
+		MaxHistoryTokens: s.defaults.MaxHistoryTokens,
+		TTL:              s.defaults.TTL,
+	}
+
+	session := &FileSession{
+		name:     id,
+		last:     time.Now(),
+		metadata: contextInfo,
+		store:    s,
+	}
+	if contextInfo.SystemPrompt != "" {
+		session.history = []messages.ChatMessage{{
+			Role:    messages.MessageRoleSystem,
+			Content: contextInfo.SystemPrompt,
+		}}
+	}
+
+	// Another goroutine may have created the same session in the meantime
+	if actual, loaded := s.LoadOrStore(id, session); loaded {
+		return actual.(*FileSession), nil
+	}
+	session.mu.Lock()
+	session.save()
+	session.mu.Unlock()
+	return session, nil
+}
+
+// Delete removes a session and its file
+func (s *FileSessionStore) Delete(id string) {
+	s.Map.Delete(id)
+	if err := os.Remove(s.path(id)); err != nil && !os.IsNotExist(err) {
+		slog.Warn("session_delete_failed", "session", id, "error", err)
+	}
+}
+
+// Range iterates over all sessions
+func (s *FileSessionStore) Range(f func(key, value any) bool) {
+	s.Map.Range(f)
+}
+
+// Expire removes sessions that have not been used within their TTL
+func (s *FileSessionStore) Expire() {
+	s.Range(func(key, value any) bool {
+		session := value.(*FileSession)
+		session.mu.RLock()
+		lastAccess := session.last
+		ttl := session.metadata.TTL
+		session.mu.RUnlock()
+
+		if ttl == 0 {
+			ttl = s.defaults.TTL
+		}
+		if ttl > 0 && time.Since(lastAccess) > ttl {
+			s.Delete(key.(string))
+		}
+		return true
+	})
+}
+
+// List returns all session names
+func (s *FileSessionStore) List() ([]string, error) {
+	var names []string
+	s.Range(func(key, value any) bool {
+		names = append(names, key.(string))
+		return true
+	})
+	return names, nil
+}
+
+// Exists checks if a session exists without creating it
+func (s *FileSessionStore) Exists(id string) bool {
+	_, ok := s.Load(id)
+	return ok
+}
+
+// GetAllMetadata returns metadata for all sessions
+func (s *FileSessionStore) GetAllMetadata() map[string]*sessions.Metadata {
+	result := make(map[string]*sessions.Metadata)
+	s.Range(func(key, value any) bool {
+		session := value.(*FileSession)
+		session.mu.RLock()
+		result[key.(string)] = session.metadata
+		session.mu.RUnlock()
+		return true
+	})
+	return result
+}
+
+// GetLast returns the name of the most recently used session
+func (s *FileSessionStore) GetLast() string {
+	var lastContext string
+	var lastTime time.Time
+	s.Range(func(key, value any) bool {
+		session := value.(*FileSession)
+		session.mu.RLock()
+		sessionTime := session.last
+		session.mu.RUnlock()
+		if sessionTime.After(lastTime) {
+			lastTime = sessionTime
+			lastContext = key.(string)
+		}
+		return true
+	})
+	return lastContext
+}
+
+// save writes the session to disk. Callers must hold s.mu.
+func (s *FileSession) save() {
+	// Sessions removed from the store (deleted or expired) are not written back
+	if value, ok := s.store.Load(s.name); !ok || value != s {
+		return
+	}
+
+	s.metadata.LastUsed = s.last
+	data, err := json.Marshal(sessionFile{
+		Name:     s.name,
+		History:  s.history,
+		LastUsed: s.last,
+		Metadata: s.metadata,
+	})
+	if err != nil {
+		slog.Warn("session_save_failed", "session", s.name, "error", err)
+		return
+	}
+	if err := writeFileAtomic(s.store.path(s.name), data); err != nil {
+		slog.Warn("session_save_failed", "session", s.name, "error", err)
+	}
+}
+
+// writeFileAtomic writes data to a temporary file and renames it into place,
+// so a crash never leaves a half-written session behind
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+strings.TrimSuffix(filepath.Base(path), ".json")+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// GetHistory returns a copy of the session history
+func (s *FileSession) GetHistory() []messages.ChatMessage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return sessions.CopyHistory(s.history)
+}
+
+// AddMessage adds a message to the session history
+func (s *FileSession) AddMessage(msg messages.ChatMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.history = append(s.history, msg)
+	s.last = time.Now()
+	if s.metadata.MaxHistoryTokens > 0 {
+		s.history = sessions.TrimHistory(s.history, s.metadata.MaxHistoryTokens)
+	}
+	s.save()
+}
+
+// Clear clears the session history, keeping the system prompt
+func (s *FileSession) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.history = s.history[:0]
+	if s.metadata.SystemPrompt != "" {
+		s.history = append(s.history, messages.ChatMessage{
+			Role:    messages.MessageRoleSystem,
+			Content: s.metadata.SystemPrompt,
+		})
+	}
+	s.last = time.Now()
+	s.save()
+}
+
+// Close is a no-op; sessions are saved as they change
+func (s *FileSession) Close() {}
+
+// GetName returns the session name
+func (s *FileSession) GetName() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.name
+}
+
+// GetMetadata returns the session metadata
+func (s *FileSession) GetMetadata() *sessions.Metadata {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.metadata
+}
+
+// SetMetadata replaces the session metadata
+func (s *FileSession) SetMetadata(info *sessions.Metadata) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metadata = info
+	s.save()
+}
+
+// UpdateMetadata applies a partial update to the session metadata
+func (s *FileSession) UpdateMetadata(update *sessions.Metadata) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metadata = sessions.MergeMetadata(s.metadata, update)
+	s.last = time.Now()
+	s.save()
+	return nil
+}
+
+// GetLastUsed returns when the session was last accessed
+func (s *FileSession) GetLastUsed() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.last
+}
+
+// GetTotalTokens returns the sum of all message tokens in history
+func (s *FileSession) GetTotalTokens() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	total := 0
+	for _, msg := range s.history {
+		total += sessions.GetMessageTokens(msg)
+	}
+	return total
+}
+
+// GetCapacityPercentage returns the percentage of capacity used (0-100)
+func (s *FileSession) GetCapacityPercentage() float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.metadata == nil || s.metadata.MaxHistoryTokens == 0 {
+		return 0
+	}
+	total := 0
+	for _, msg := range s.history {
+		total += sessions.GetMessageTokens(msg)
+	}
+	return float64(total) / float64(s.metadata.MaxHistoryTokens) * 100
+}
+
+// GetTimeToExpiry returns the time remaining until the session expires
+func (s *FileSession) GetTimeToExpiry() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.metadata == nil || s.metadata.TTL == 0 {
+		return 0
+	}
+	remaining := s.metadata.TTL - time.Since(s.last)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// GetMessageCounts returns the count of messages by role
+func (s *FileSession) GetMessageCounts() map[string]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	counts := make(map[string]int)
+	for _, msg := range s.history {
+		counts[string(msg.Role)]++
+	}
+	return counts
+}
+
+// GetToolCallCount returns the total number of tool calls in the session
+func (s *FileSession) GetToolCallCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	total := 0
+	for _, msg := range s.history {
+		total += len(msg.ToolCalls)
+	}
+	return total
+}
@@ -0,0 +1,116 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alexschlessinger/pollytool/messages"
+	"github.com/alexschlessinger/pollytool/sessions"
+)
+
+func TestFileSessionStore_SurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	defaults := &sessions.Metadata{SystemPrompt: "be nice", TTL: time.Hour}
+
+	s, err := NewFileSessionStore(dir, defaults)
+	if err != nil {
+		t.Fatalf("NewFileSessionStore: %v", err)
+	}
+	defer s.Close()
+	session, _ := s.Get("#chan")
+	reply := messages.ChatMessage{Role: messages.MessageRoleAssistant, Content: "hi"}
+	reply.SetTokenUsage(0, 42)
+	session.AddMessage(messages.ChatMessage{Role: messages.MessageRoleUser, Content: "hello"})
+	session.AddMessage(reply)
+
+	reloaded, err := NewFileSessionStore(dir, defaults)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	defer reloaded.Close()
+	if !reloaded.Exists("#chan") {
+		t.Fatal("expected #chan to be restored")
+	}
+	restored, _ := reloaded.Get("#chan")
+	history := restored.GetHistory()
+	if len(history) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(history))
+	}
+	if history[0].Content != "be nice" || history[2].Content != "hi" {
+		t.Errorf("unexpected history: %+v", history)
+	}
+	if got := history[2].GetOutputTokens(); got != 42 {
+		t.Errorf("expected 42 output tokens after reload, got %d", got)
+	}
+	if restored.GetMetadata().TTL != time.Hour {
+		t.Errorf("expected TTL to be restored, got %v", restored.GetMetadata().TTL)
+	}
+}
+
+func TestFileSessionStore_DropsExpiredOnLoad(t *testing.T) {
+	dir := t.TempDir()
+	defaults := &sessions.Metadata{TTL: 20 * time.Millisecond}
+
+	s, err := NewFileSessionStore(dir, defaults)
+	if err != nil {
+		t.Fatalf("NewFileSessionStore: %v", err)
+	}
+	defer s.Close()
+	session, _ := s.Get("alice")
+	session.AddMessage(messages.ChatMessage{Role: messages.MessageRoleUser, Content: "hello"})
+
+	time.Sleep(50 * time.Millisecond)
+
+	reloaded, err := NewFileSessionStore(dir, defaults)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	defer reloaded.Close()
+	if reloaded.Exists("alice") {
+		t.Error("expected expired session to be dropped")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "alice.json")); !os.IsNotExist(err) {
+		t.Errorf("expected expired session file to be removed, got %v", err)
+	}
+}
+
+func TestFileSessionStore_DeleteRemovesFile(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewFileSessionStore(dir, nil)
+	if err != nil {
+		t.Fatalf("NewFileSessionStore: %v", err)
+	}
+	defer s.Close()
+	session, _ := s.Get("#a/b")
+	session.AddMessage(messages.ChatMessage{Role: messages.MessageRoleUser, Content: "hello"})
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 session file, got %d", len(entries))
+	}
+
+	s.Delete("#a/b")
+	session.AddMessage(messages.ChatMessage{Role: messages.MessageRoleUser, Content: "late"})
+
+	entries, _ = os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("expected no session files after delete, got %d", len(entries))
+	}
+}
+
+func TestFileSessionStore_CloseStopsExpiry(t *testing.T) {
+	s, err := NewFileSessionStore(t.TempDir(), &sessions.Metadata{TTL: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewFileSessionStore: %v", err)
+	}
+	s.Get("alice")
+	s.Close()
+	s.Close()
+
+	time.Sleep(50 * time.Millisecond)
+	if !s.Exists("alice") {
+		t.Error("expected no expiry after Close")
+	}
+}
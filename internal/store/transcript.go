@@ -0,0 +1,181 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kinds of transcript lines
+const (
+	TranscriptMessage = "message"
+	TranscriptAction  = "action"
+	TranscriptJoin    = "join"
+	TranscriptPart    = "part"
+	TranscriptKick    = "kick"
+	TranscriptTopic   = "topic"
+)
+
+// TranscriptLine is one channel event in a transcript
+type TranscriptLine struct {
+	Time    time.Time `json:"time"`
+	Channel string    `json:"channel"`
+	Kind    string    `json:"kind"`
+	Nick    string    `json:"nick"`
+	Host    string    `json:"host,omitempty"`   // ident@host, for joins and parts
+	Target  string    `json:"target,omitempty"` // the nick kicked
+	Text    string    `json:"text,omitempty"`   // message, reason or topic
+}
+
+// Format renders the line the way irssi logs it, without the newline
+func (l TranscriptLine) Format() string {
+	ts := l.Time.Format("15:04")
+	host := ""
+	if l.Host != "" {
+		host = " [" + l.Host + "]"
+	}
+	reason := ""
+	if l.Text != "" {
+		reason = " [" + l.Text + "]"
+	}
+	switch l.Kind {
+	case TranscriptAction:
+		return fmt.Sprintf("%s  * %s %s", ts, l.Nick, l.Text)
+	case TranscriptJoin:
+		return fmt.Sprintf("%s -!- %s%s has joined %s", ts, l.Nick, host, l.Channel)
+	case TranscriptPart:
+		return fmt.Sprintf("%s -!- %s%s has left %s%s", ts, l.Nick, host, l.Channel, reason)
+	case TranscriptKick:
+		return fmt.Sprintf("%s -!- %s was kicked from %s by %s%s", ts, l.Target, l.Channel, l.Nick, reason)
+	case TranscriptTopic:
+		return fmt.Sprintf("%s -!- %s changed the topic of %s to: %s", ts, l.Nick, l.Channel, l.Text)
+	}
+	return fmt.Sprintf("%s <%s> %s", ts, l.Nick, l.Text)
+}
+
+// Transcript archives channel conversations to disk. Each channel gets a
+// directory with a file per day in both irssi-style text (.log) and JSON
+// lines (.jsonl), named by the local date of the lines they hold.
+type Transcript struct {
+	mu    sync.Mutex
+	dir   string
+	files map[string]*transcriptDay // by channel directory
+	index map[string]*historyIndex  // by channel directory, once searched
+}
+
+// transcriptDay holds the open files for one channel and day
+type transcriptDay struct {
+	date  string
+	text  *os.File
+	jsonl *os.File
+}
+
+// NewTranscript writes transcripts below dir, creating it if needed
+func NewTranscript(dir string) (*Transcript, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create transcript directory: %w", err)
+	}
+	return &Transcript{
+		dir:   dir,
+		files: make(map[string]*transcriptDay),
+		index: make(map[string]*historyIndex),
+	}, nil
+}
+
+// Dir returns the directory holding a channel's transcripts
+func (t *Transcript) Dir(channel string) string {
+	return filepath.Join(t.dir, transcriptName(channel))
+}
+
+// transcriptName turns a channel into a safe directory name
+func transcriptName(channel string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == os.PathSeparator {
+			return '_'
+		}
+		return r
+	}, strings.ToLower(channel))
+}
+
+// Write appends a line to its channel's transcript for the line's day
+func (t *Transcript) Write(line TranscriptLine) error {
+	if line.Time.IsZero() {
+		line.Time = time.Now()
+	}
+	data, err := json.Marshal(line)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	day, err := t.open(line.Channel, line.Time)
+	if err != nil {
+		return err
+	}
+	if _, err := day.text.WriteString(line.Format() + "\n"); err != nil {
+		return err
+	}
+	if _, err := day.jsonl.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	if index, ok := t.index[transcriptName(line.Channel)]; ok {
+		index.add(line)
+	}
+	return nil
+}
+
+// open returns the files for a channel and day, rotating to new files when
+// the day changes. Callers hold mu.
+func (t *Transcript) open(channel string, at time.Time) (*transcriptDay, error) {
+	name := transcriptName(channel)
+	date := at.Format(time.DateOnly)
+	if day, ok := t.files[name]; ok {
+		if day.date == date {
+			return day, nil
+		}
+		day.close()
+		delete(t.files, name)
+	}
+
+	dir := filepath.Join(t.dir, name)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create transcript directory: %w", err)
+	}
+	base := filepath.Join(dir, date)
+	text, err := os.OpenFile(base+".log", os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open transcript: %w", err)
+	}
+	jsonl, err := os.OpenFile(base+".jsonl", os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		text.Close()
+		return nil, fmt.Errorf("failed to open transcript: %w", err)
+	}
+	fmt.Fprintf(text, "--- Log opened %s\n", at.Format("Mon Jan 02 15:04:05 2006"))
+
+	day := &transcriptDay{date: date, text: text, jsonl: jsonl}
+	t.files[name] = day
+	return day, nil
+}
+
+func (d *transcriptDay) close() {
+	fmt.Fprintf(d.text, "--- Log closed %s\n", time.Now().Format("Mon Jan 02 15:04:05 2006"))
+	d.text.Close()
+	d.jsonl.Close()
+}
+
+// Close closes the open transcript files
+func (t *Transcript) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for name, day := range t.files {
+		day.close()
+		delete(t.files, name)
+	}
+	return nil
+}
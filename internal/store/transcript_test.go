@@ -0,0 +1,61 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTranscriptLine_Format(t *testing.T) {
+	at := time.Date(2026, 3, 15, 9, 5, 0, 0, time.Local)
+	tests := []struct {
+		line TranscriptLine
+		want string
+	}{
+		{TranscriptLine{Kind: TranscriptMessage, Nick: "alice", Text: "hi"}, "09:05 <alice> hi"},
+		{TranscriptLine{Kind: TranscriptAction, Nick: "alice", Text: "waves"}, "09:05  * alice waves"},
+		{TranscriptLine{Kind: TranscriptJoin, Nick: "bob", Host: "b@example.org", Channel: "#dev"}, "09:05 -!- bob [b@example.org] has joined #dev"},
+		{TranscriptLine{Kind: TranscriptPart, Nick: "bob", Host: "b@example.org", Channel: "#dev", Text: "bye"}, "09:05 -!- bob [b@example.org] has left #dev [bye]"},
+		{TranscriptLine{Kind: TranscriptKick, Nick: "alice", Target: "eve", Channel: "#dev", Text: "spam"}, "09:05 -!- eve was kicked from #dev by alice [spam]"},
+		{TranscriptLine{Kind: TranscriptTopic, Nick: "alice", Channel: "#dev", Text: "release day"}, "09:05 -!- alice changed the topic of #dev to: release day"},
+	}
+	for _, tt := range tests {
+		tt.line.Time = at
+		if got := tt.line.Format(); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.line.Kind, got, tt.want)
+		}
+	}
+}
+
+func TestTranscript_RotatesDaily(t *testing.T) {
+	dir := t.TempDir()
+	transcript, err := NewTranscript(dir)
+	if err != nil {
+		t.Fatalf("NewTranscript: %v", err)
+	}
+	day := time.Date(2026, 3, 15, 23, 59, 0, 0, time.Local)
+	transcript.Write(TranscriptLine{Time: day, Channel: "#Dev", Kind: TranscriptMessage, Nick: "alice", Text: "late"})
+	transcript.Write(TranscriptLine{Time: day.Add(2 * time.Minute), Channel: "#dev", Kind: TranscriptMessage, Nick: "bob", Text: "early"})
+	transcript.Write(TranscriptLine{Time: day, Channel: "#a/../b", Kind: TranscriptMessage, Nick: "eve", Text: "x"})
+	transcript.Close()
+
+	first, err := os.ReadFile(filepath.Join(dir, "#dev", "2026-03-15.log"))
+	if err != nil {
+		t.Fatalf("expected a file for the first day: %v", err)
+	}
+	if !strings.Contains(string(first), "23:59 <alice> late") || strings.Contains(string(first), "early") {
+		t.Errorf("unexpected first day: %q", first)
+	}
+	second, err := os.ReadFile(filepath.Join(dir, "#dev", "2026-03-16.jsonl"))
+	if err != nil {
+		t.Fatalf("expected JSON lines for the second day: %v", err)
+	}
+	if !strings.Contains(string(second), `"nick":"bob"`) {
+		t.Errorf("unexpected second day: %q", second)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "#a_.._b", "2026-03-15.log")); err != nil {
+		t.Errorf("expected path separators in channel names replaced: %v", err)
+	}
+}
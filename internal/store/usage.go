@@ -0,0 +1,169 @@
+package store
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+)
+
+// UsageRecord is the token usage of one LLM request
+type UsageRecord struct {
+	Time         time.Time `json:"time"`
+	Nick         string    `json:"nick"`
+	Account      string    `json:"account,omitempty"`
+	Identity     string    `json:"identity,omitempty"` // core.SenderIdentity
+	Channel      string    `json:"channel,omitempty"`
+	Model        string    `json:"model"`
+	InputTokens  int       `json:"input_tokens"`
+	OutputTokens int       `json:"output_tokens"`
+	Cost         float64   `json:"cost"`
+	// Reset marks a budget reset for a scope instead of a request
+	Reset string `json:"reset,omitempty"`
+}
+
+// User names who made the request by their identity, as used for rate
+// limits. Records written without one fall back to the account, then nick.
+func (r UsageRecord) User() string {
+	switch {
+	case r.Identity != "":
+		return r.Identity
+	case r.Account != "":
+		return "$a:" + r.Account
+	}
+	return r.Nick
+}
+
+// UsageTotal sums the records sharing a key
+type UsageTotal struct {
+	Key          string
+	Requests     int
+	InputTokens  int
+	OutputTokens int
+	Cost         float64
+}
+
+// Tokens returns input and output tokens combined
+func (t UsageTotal) Tokens() int {
+	return t.InputTokens + t.OutputTokens
+}
+
+// UsageLedger keeps token usage for reporting. Records are appended to a
+// JSONL file when one is configured, so totals survive restarts; only the
+// current month is held in memory.
+type UsageLedger struct {
+	mu      sync.Mutex
+	records []UsageRecord
+	file    *jsonlFile
+	now     func() time.Time
+}
+
+// NewUsageLedger opens the ledger at path, loading this month's records. An
+// empty path keeps usage in memory only.
+func NewUsageLedger(path string) (*UsageLedger, error) {
+	l := &UsageLedger{now: time.Now}
+	month := MonthStart(l.now())
+	file, err := openJSONL(path, "usage ledger", func(r UsageRecord) {
+		if !r.Time.Before(month) {
+			l.records = append(l.records, r)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	l.file = file
+	return l, nil
+}
+
+// Record adds a request to the ledger
+func (l *UsageLedger) Record(r UsageRecord) error {
+	if r.Time.IsZero() {
+		r.Time = l.now()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Drop last month's records once a new month starts
+	month := MonthStart(l.now())
+	if len(l.records) > 0 && l.records[0].Time.Before(month) {
+		l.records = slices.DeleteFunc(l.records, func(r UsageRecord) bool { return r.Time.Before(month) })
+	}
+	l.records = append(l.records, r)
+	return l.file.append(r)
+}
+
+// Totals sums the records since a time, grouped by key and sorted by cost,
+// then tokens, highest first
+func (l *UsageLedger) Totals(since time.Time, key func(UsageRecord) string) []UsageTotal {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	byKey := make(map[string]*UsageTotal)
+	for _, r := range l.records {
+		if r.Time.Before(since) || r.Reset != "" {
+			continue
+		}
+		k := key(r)
+		t := byKey[k]
+		if t == nil {
+			t = &UsageTotal{Key: k}
+			byKey[k] = t
+		}
+		t.Requests++
+		t.InputTokens += r.InputTokens
+		t.OutputTokens += r.OutputTokens
+		t.Cost += r.Cost
+	}
+
+	totals := make([]UsageTotal, 0, len(byKey))
+	for _, t := range byKey {
+		totals = append(totals, *t)
+	}
+	slices.SortFunc(totals, func(a, b UsageTotal) int {
+		return cmp.Or(cmp.Compare(b.Cost, a.Cost), cmp.Compare(b.Tokens(), a.Tokens()), cmp.Compare(a.Key, b.Key))
+	})
+	return totals
+}
+
+// Spent returns the tokens used since a time by the records that match,
+// counting only those after the latest reset of scope
+func (l *UsageLedger) Spent(scope string, since time.Time, match func(UsageRecord) bool) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	spent := 0
+	for _, r := range l.records {
+		switch {
+		case r.Time.Before(since):
+		case r.Reset != "":
+			if r.Reset == scope {
+				spent = 0
+			}
+		case match(r):
+			spent += r.InputTokens + r.OutputTokens
+		}
+	}
+	return spent
+}
+
+// ResetBudget starts a budget scope's count over from now
+func (l *UsageLedger) ResetBudget(scope string) error {
+	return l.Record(UsageRecord{Reset: scope})
+}
+
+// Close closes the ledger file
+func (l *UsageLedger) Close() error {
+	return l.file.Close()
+}
+
+// DayStart returns midnight of t's day
+func DayStart(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// MonthStart returns midnight on the first of t's month
+func MonthStart(t time.Time) time.Time {
+	y, m, _ := t.Date()
+	return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,82 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUsageLedger_SurvivesRestart(t *testing.T) {
+	now := time.Now()
+	records := []UsageRecord{
+		{Time: MonthStart(now).Add(-time.Hour), Nick: "old", Model: "m", InputTokens: 999},
+		{Time: now, Nick: "alice", Account: "alice_acct", Channel: "#dev", Model: "a/big", InputTokens: 100, OutputTokens: 50, Cost: 0.5},
+		{Time: now, Nick: "bob", Identity: "~bob@host", Channel: "#dev", Model: "a/small", InputTokens: 10, OutputTokens: 5, Cost: 0.01},
+		{Time: now, Nick: "alice2", Account: "alice_acct", Model: "a/big", InputTokens: 100, OutputTokens: 50, Cost: 0.5},
+	}
+	reloaded, _ := reopen(t, "usage.jsonl", NewUsageLedger, func(ledger *UsageLedger) {
+		for _, r := range records {
+			if err := ledger.Record(r); err != nil {
+				t.Fatalf("Record: %v", err)
+			}
+		}
+	})
+
+	users := reloaded.Totals(MonthStart(now), UsageRecord.User)
+	if len(users) != 2 {
+		t.Fatalf("expected last month's record to be skipped, got %+v", users)
+	}
+	if users[0].Key != "$a:alice_acct" || users[0].Requests != 2 || users[0].Tokens() != 300 || users[0].Cost != 1 {
+		t.Errorf("expected alice's nicks summed under her account first, got %+v", users[0])
+	}
+	if users[1].Key != "~bob@host" {
+		t.Errorf("expected bob second under his identity, got %+v", users[1])
+	}
+
+	channels := reloaded.Totals(DayStart(now), func(r UsageRecord) string { return r.Channel })
+	if len(channels) != 2 || channels[0].Key != "#dev" || channels[0].Requests != 2 || channels[1].Key != "" {
+		t.Errorf("unexpected channel totals: %+v", channels)
+	}
+}
+
+func TestUsageLedger_SpentAndReset(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	ledger, _ := NewUsageLedger("")
+	ledger.now = func() time.Time { return now }
+	inDev := func(r UsageRecord) bool { return r.Channel == "#dev" }
+	all := func(UsageRecord) bool { return true }
+
+	ledger.Record(UsageRecord{Time: now.AddDate(0, 0, -1), Nick: "alice", Channel: "#dev", InputTokens: 500})
+	ledger.Record(UsageRecord{Nick: "alice", Channel: "#dev", InputTokens: 100, OutputTokens: 20})
+	ledger.Record(UsageRecord{Nick: "bob", Channel: "#ops", InputTokens: 30})
+
+	if got := ledger.Spent("#dev", DayStart(now), inDev); got != 120 {
+		t.Errorf("expected 120 spent in #dev today, got %d", got)
+	}
+	if got := ledger.Spent("#dev", MonthStart(now), inDev); got != 620 {
+		t.Errorf("expected 620 spent in #dev this month, got %d", got)
+	}
+
+	ledger.ResetBudget("#dev")
+	ledger.Record(UsageRecord{Nick: "alice", Channel: "#dev", InputTokens: 7})
+
+	if got := ledger.Spent("#dev", MonthStart(now), inDev); got != 7 {
+		t.Errorf("expected only usage after the reset to count, got %d", got)
+	}
+	if got := ledger.Spent("global", DayStart(now), all); got != 157 {
+		t.Errorf("expected other scopes unaffected by the reset, got %d", got)
+	}
+	if totals := ledger.Totals(MonthStart(now), UsageRecord.User); len(totals) != 2 || totals[0].Requests != 3 {
+		t.Errorf("expected reset markers left out of totals, got %+v", totals)
+	}
+}
+
+func TestUsageLedger_ResetSurvivesRestart(t *testing.T) {
+	reloaded, _ := reopen(t, "usage.jsonl", NewUsageLedger, func(ledger *UsageLedger) {
+		ledger.Record(UsageRecord{Nick: "alice", InputTokens: 100})
+		ledger.ResetBudget("global")
+		ledger.Record(UsageRecord{Nick: "alice", InputTokens: 7})
+	})
+	if got := reloaded.Spent("global", MonthStart(time.Now()), func(UsageRecord) bool { return true }); got != 7 {
+		t.Errorf("expected the reset to be reloaded, got %d spent", got)
+	}
+}
@@ -10,11 +10,11 @@ import (
 func DefaultTestConfig() *config.Configuration {
 	return &config.Configuration{
 		Server: &config.ServerConfig{
-			Nick:    "testbot",
-			Server:  "irc.test.local",
-			Port:    6667,
-			Channel: "#test",
-			SSL:     false,
+			Nick:     "testbot",
+			Server:   "irc.test.local",
+			Port:     6667,
+			Channels: []config.ChannelConfig{{Name: "#test"}},
+			SSL:      false,
 		},
 		Bot: &config.BotConfig{
 			Admins:             []string{},
@@ -35,6 +35,7 @@ func DefaultTestConfig() *config.Configuration {
 		},
 		Session: &config.SessionConfig{
 			ChunkMax:   350,
+			Format:     config.FormatIRC,
 			MaxContext: 100000,
 			TTL:        time.Minute * 10,
 		},
@@ -19,10 +19,15 @@ type MockChatContext struct {
 	// Configurable return values
 	Addressed bool
 	Admin     bool
+	Role      config.Role
 	Private   bool
 Command   string
 	Source    string
 	Args      []string
+	// MultilineEnabled makes ReplyMultiline available, as on a server with
+	// draft/multiline, holding up to MultilineBytes (default 4096)
+	MultilineEnabled bool
+	MultilineBytes   int
 
 	// Recorded calls (for assertions)
 	Replies          []string
@@ -39,6 +44,8 @@ Command   string
 	UnbanCalls      []string
 	InviteCalls     []InviteCall
 	SendActionCalls []ActionCall
+	TypingCalls     []string
+	Batches         [][]string // lines sent with ReplyMultiline
 
 	// Injected dependencies
 	session sessions.Session
@@ -52,6 +59,7 @@ Command   string
 	Channels     map[string]*core.ChannelInfo
 	ChannelUsers map[string][]core.ChannelUser
 	BotNick      string
+	ChannelName  string
 }
 
 type InviteCall struct {
@@ -84,12 +92,14 @@ Addressed:    true,
 		Replies:      []string{},
 		Actions:      []string{},
 		cfg:          DefaultTestConfig(),
+		sys:          NewMockSystem(),
 		logger:       slog.New(discardHandler{}),
 		client:       NewMockIRCClient(),
 		Users:        make(map[string]*core.UserInfo),
 		Channels:     make(map[string]*core.ChannelInfo),
 		ChannelUsers: make(map[string][]core.ChannelUser),
 		BotNick:      "soulshack",
+		ChannelName:  "#test",
 	}
 }
 
@@ -115,6 +125,12 @@ func (m *MockChatContext) WithAdmin(admin bool) *MockChatContext {
 	return m
 }
 
+// WithRole sets the sender's role
+func (m *MockChatContext) WithRole(role config.Role) *MockChatContext {
+	m.Role = role
+	return m
+}
+
 // WithAddressed sets whether the bot was addressed
 func (m *MockChatContext) WithAddressed(addressed bool) *MockChatContext {
 	m.Addressed = addressed
@@ -172,6 +188,12 @@ func (m *MockChatContext) WithURLWatcher(enabled bool) *MockChatContext {
 	return m
 }
 
+// WithChannelName sets the channel the event came from ("" for private messages)
+func (m *MockChatContext) WithChannelName(channel string) *MockChatContext {
+	m.ChannelName = channel
+	return m
+}
+
 // WithUser adds a mock user for LookupUser
 func (m *MockChatContext) WithUser(nick, ident, host string) *MockChatContext {
 	m.Users[nick] = &core.UserInfo{Nick: nick, Ident: ident, Host: host}
@@ -185,7 +207,15 @@ func (m *MockChatContext) IsAddressed() bool {
 }
 
 func (m *MockChatContext) IsAdmin() bool {
-	return m.Admin
+	return m.GetRole() == config.RoleOwner
+}
+
+// GetRole returns Role, or owner when the admin flag is set
+func (m *MockChatContext) GetRole() config.Role {
+	if m.Admin {
+		return config.RoleOwner
+	}
+	return m.Role
 }
 
 func (m *MockChatContext) IsPrivate() bool {
@@ -210,6 +240,22 @@ func (m *MockChatContext) Reply(msg string) {
 	m.Replies = append(m.Replies, msg)
 }
 
+// ReplyMultiline records the lines as one batch, and each as a reply
+func (m *MockChatContext) ReplyMultiline(lines []string) {
+	m.Batches = append(m.Batches, lines)
+	m.Replies = append(m.Replies, lines...)
+}
+
+func (m *MockChatContext) Multiline() (int, bool) {
+	if !m.MultilineEnabled {
+		return 0, false
+	}
+	if m.MultilineBytes == 0 {
+		return 4096, true
+	}
+	return m.MultilineBytes, true
+}
+
 func (m *MockChatContext) ReplyAction(msg string) {
 	m.Actions = append(m.Actions, msg)
 }
@@ -218,6 +264,10 @@ func (m *MockChatContext) SendAction(target, msg string) {
 	m.SendActionCalls = append(m.SendActionCalls, ActionCall{Target: target, Message: msg})
 }
 
+func (m *MockChatContext) Typing(state string) {
+	m.TypingCalls = append(m.TypingCalls, state)
+}
+
 // Controller methods
 
 func (m *MockChatContext) Join(channel string) bool {
@@ -290,11 +340,15 @@ func (m *MockChatContext) GetBotNick() string {
 	return m.BotNick
 }
 
+func (m *MockChatContext) GetChannelName() string {
+	return m.ChannelName
+}
+
 func (m *MockChatContext) GetLockKey() string {
-	if m.cfg != nil {
-		return m.cfg.Server.Channel
+	if m.ChannelName != "" {
+		return m.ChannelName
 	}
-	return "#test"
+	return m.Source
 }
 
 func (m *MockChatContext) IsOp(channel, nick string) bool {
@@ -1,14 +1,19 @@
 package testing
 
 import (
+	"context"
+	"strings"
 	"time"
 
 	"github.com/alexschlessinger/pollytool/llm"
+	"github.com/alexschlessinger/pollytool/messages"
 	"github.com/alexschlessinger/pollytool/sessions"
 	"github.com/alexschlessinger/pollytool/tools"
 
 	"pkdindustries/soulshack/internal/config"
 	"pkdindustries/soulshack/internal/core"
+	"pkdindustries/soulshack/internal/paste"
+	"pkdindustries/soulshack/internal/store"
 )
 
 // MockLLM implements core.LLM for testing
@@ -16,6 +21,8 @@ type MockLLM struct {
 	Responses []string      // Chunks to send
 	Delay     time.Duration // Delay between chunks (0 = immediate)
 	Error     error         // Error to return (sent as final chunk)
+
+	Completions []*llm.CompletionRequest // requests passed to Complete
 }
 
 // ChatCompletionStream implements core.LLM
@@ -39,11 +46,26 @@ func (m *MockLLM) ChatCompletionStream(ctx core.ChatContextInterface, req *llm.C
 		}
 		if m.Error != nil {
 			ch <- "Error: " + m.Error.Error()
+			return
 		}
+		// Like the agent, a completed response is added to the session
+		ctx.GetSession().AddMessage(messages.ChatMessage{
+			Role:    messages.MessageRoleAssistant,
+			Content: strings.Join(m.Responses, "\n"),
+		})
 	}()
 	return ch
 }
 
+// Complete implements core.LLM, replying with the responses joined
+func (m *MockLLM) Complete(ctx context.Context, req *llm.CompletionRequest) (messages.ChatMessage, error) {
+	m.Completions = append(m.Completions, req)
+	if m.Error != nil {
+		return messages.ChatMessage{}, m.Error
+	}
+	return messages.ChatMessage{Role: messages.MessageRoleAssistant, Content: strings.Join(m.Responses, "")}, nil
+}
+
 // Verify MockLLM implements core.LLM
 var _ core.LLM = (*MockLLM)(nil)
 
@@ -52,10 +74,21 @@ type MockSystem struct {
 	ToolRegistry *tools.ToolRegistry
 	SessionStore sessions.SessionStore
 	LLM          core.LLM
+	RateLimiter  *core.RateLimiter
+	Backlog      *core.Backlog
+	Usage        *store.UsageLedger
+	Audit        *store.AuditLog
+	Transcript   *store.Transcript
+	Memory       *store.MemoryStore
+	Paster       paste.Paster
+	RemovedTools []string // names passed to RemoveTool
 }
 
 // NewMockSystem creates a MockSystem with sensible defaults
 func NewMockSystem() *MockSystem {
+	usage, _ := store.NewUsageLedger("") // in memory, cannot fail
+	audit, _ := store.NewAuditLog("")
+	memory, _ := store.NewMemoryStore("")
 	return &MockSystem{
 		ToolRegistry: tools.NewToolRegistry([]tools.Tool{}),
 		SessionStore: sessions.NewSyncMapSessionStore(&sessions.Metadata{
@@ -66,6 +99,11 @@ func NewMockSystem() *MockSystem {
 		LLM: &MockLLM{
 			Responses: []string{"Hello from mock LLM"},
 		},
+		RateLimiter: core.NewRateLimiter(),
+		Backlog:     core.NewBacklog(),
+		Usage:       usage,
+		Audit:       audit,
+		Memory:      memory,
 	}
 }
 
@@ -90,5 +128,59 @@ func (m *MockSystem) UpdateLLM(cfg config.APIConfig) error {
 	return nil
 }
 
+// GetRateLimiter implements core.System
+func (m *MockSystem) GetRateLimiter() *core.RateLimiter {
+	return m.RateLimiter
+}
+
+// GetBacklog implements core.System
+func (m *MockSystem) GetBacklog() *core.Backlog {
+	return m.Backlog
+}
+
+// GetUsage implements core.System
+func (m *MockSystem) GetUsage() *store.UsageLedger {
+	return m.Usage
+}
+
+// GetAudit implements core.System
+func (m *MockSystem) GetAudit() *store.AuditLog {
+	return m.Audit
+}
+
+// GetTranscript implements core.System
+func (m *MockSystem) GetTranscript() *store.Transcript {
+	return m.Transcript
+}
+
+// GetMemory implements core.System
+func (m *MockSystem) GetMemory() *store.MemoryStore {
+	return m.Memory
+}
+
+// GetPaster implements core.System
+func (m *MockSystem) GetPaster() paste.Paster {
+	return m.Paster
+}
+
+// LoadTool implements core.System
+func (m *MockSystem) LoadTool(spec string) ([]string, error) {
+	result, err := m.ToolRegistry.LoadToolAuto(spec)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, server := range result.Servers {
+		names = append(names, server.ToolNames...)
+	}
+	return names, nil
+}
+
+// RemoveTool implements core.System
+func (m *MockSystem) RemoveTool(name string) {
+	m.RemovedTools = append(m.RemovedTools, name)
+	m.ToolRegistry.Remove(name)
+}
+
 // Verify MockSystem implements core.System
 var _ core.System = (*MockSystem)(nil)